@@ -0,0 +1,68 @@
+package tsid
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrBackfillDisabled indicates that the layout has no Backfilled segment
+	// or the BackfillWindow option is zero
+	ErrBackfillDisabled = errors.New("tsid: backfill requires a Backfilled segment and the BackfillWindow option")
+	// ErrBackfillWindow indicates that the time is in the future or older than BackfillWindow
+	ErrBackfillWindow = errors.New("tsid: the time is out of the backfill window")
+	// ErrBackfillExhausted indicates that the backfill sequence of the tick is exhausted
+	ErrBackfillExhausted = errors.New("tsid: the backfill sequence is exhausted")
+	// ErrBackfillOrder indicates that the time is in a tick before the latest one of NextAt
+	ErrBackfillOrder = errors.New("tsid: the backfill time goes backwards")
+)
+
+// NextAt returns an ID generated at the past time t, for backfilling historical records.
+// The ID has all Backfilled segments set to 1 and uses a sequence space separated from
+// Next, so it never collides with live-issued IDs. The sequence restarts whenever the
+// tick of the timestamp of t advances, and the times in the earlier ticks fail with
+// ErrBackfillOrder, which would duplicate the IDs, so backfill in time order.
+func (b *Builder) NextAt(t time.Time, argv ...int64) (*ID, error) {
+	if !b.isReady() {
+		return nil, ErrNotReady
+	}
+	window := b.options.BackfillWindow
+	if window <= 0 || !b.backfill {
+		return nil, ErrBackfillDisabled
+	}
+	now := b.timeNow()
+	if t.After(now) || now.Sub(t) > window {
+		return nil, ErrBackfillWindow
+	}
+	b.Lock()
+	defer b.Unlock()
+	q := timestampUnit(b.options.segments).Milliseconds()
+	if q < 1 {
+		q = 1
+	}
+	tick := t.UnixMilli() / q
+	seq := int64(0)
+	switch {
+	case tick < b.backfillTick:
+		return nil, ErrBackfillOrder
+	case tick == b.backfillTick:
+		seq = (b.backfillSequence + 1) & b.sequenceMask
+		if seq == 0 {
+			return nil, ErrBackfillExhausted
+		}
+	}
+	b.backfillTick = tick
+	b.backfillSequence = seq
+	main, ext, err := b.compose(&t, seq, 1, -1, argv, nil)
+	if err == nil {
+		err = b.emit(main, ext)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ID{
+		Main:   main,
+		Ext:    ext,
+		Signed: b.options.Signed,
+	}, nil
+}
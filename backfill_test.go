@@ -0,0 +1,91 @@
+package tsid
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextAt(t *testing.T) {
+	if d, e := New(Default()); e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	} else if _, e = d.NextAt(time.Now()); e != ErrBackfillDisabled {
+		t.Errorf("want: error(%s), got: %v", ErrBackfillDisabled, e)
+	}
+	opt := Options{
+		BackfillWindow: time.Hour,
+		segments: []Bits{
+			Sequence(12),
+			Backfill(1),
+			Timestamp(41, TimestampMilliseconds),
+		},
+	}
+	b, e := Make(opt)
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	now := time.Now()
+	for _, at := range []time.Time{now.Add(time.Minute), now.Add(-2 * time.Hour)} {
+		if _, e = b.NextAt(at); e != ErrBackfillWindow {
+			t.Errorf("want: error(%s), got: %v", ErrBackfillWindow, e)
+		}
+	}
+	at := now.Add(-time.Minute)
+	p := &ID{}
+	for i := 0; i < 100; i++ {
+		id, e := b.NextAt(at)
+		if e != nil {
+			t.Fatalf("want: an ID, got: error %s", e)
+			return
+		}
+		if id.Main>>12&1 != 1 {
+			t.Errorf("want: the backfill flag, got: %b", id.Main)
+		}
+		if id.Main <= p.Main {
+			t.Error("the IDs generated by NextAt are not incremental")
+		}
+		p = id
+	}
+	if id := b.Next(); id.Main>>12&1 != 0 {
+		t.Errorf("want: no backfill flag, got: %b", id.Main)
+	}
+}
+
+func TestNextAtRepeated(t *testing.T) {
+	for _, unit := range []DateTimeType{TimestampMilliseconds, TimestampSeconds} {
+		opt := Options{
+			BackfillWindow: time.Hour,
+			segments: []Bits{
+				Sequence(12),
+				Backfill(1),
+				Timestamp(41, unit),
+			},
+		}
+		b, e := Make(opt)
+		if e != nil {
+			t.Fatalf("want: a builder instance, got: error %s", e)
+			return
+		}
+		at := time.Now().Add(-time.Minute).Truncate(time.Second)
+		seen := map[ID]bool{}
+		// the same time and the milliseconds of the same tick
+		for _, d := range []time.Duration{0, 0, 0, time.Millisecond, time.Millisecond, 0} {
+			id, e := b.NextAt(at.Add(d))
+			if unit == TimestampMilliseconds && d == 0 && len(seen) == 5 {
+				if e != ErrBackfillOrder {
+					t.Errorf("want: error(%s), got: %v", ErrBackfillOrder, e)
+				}
+				continue
+			}
+			if e != nil || seen[*id] {
+				t.Fatalf("%d want: a unique ID, got: %v, error %v", unit, id, e)
+				return
+			}
+			seen[*id] = true
+		}
+		if _, e = b.NextAt(at.Add(-time.Second)); e != ErrBackfillOrder {
+			t.Errorf("want: error(%s), got: %v", ErrBackfillOrder, e)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package tsid
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrAlphabet indicates that the alphabet of NewBaseEncoder is invalid
+	ErrAlphabet = errors.New("tsid: the alphabet must be 2 to 94 unique printable ASCII characters except '!', the sign marker by default")
+	// ErrChecksumBase indicates that BaseChecksum is used with an alphabet whose
+	// length is not a power of two in [4, 64]
+	ErrChecksumBase = errors.New("tsid: the checksum requires an alphabet of 4, 8, 16, 32 or 64 characters")
+	// ErrCaseFold indicates that BaseCaseInsensitive is used with an alphabet
+	// having both cases of a letter
+	ErrCaseFold = errors.New("tsid: the case-insensitive alphabet has both cases of a letter")
+)
+
+const (
+	// Base32Crockford is the alphabet of the Crockford's Base32
+	Base32Crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	// Base32Hex is the alphabet of the base32hex of RFC 4648
+	Base32Hex = "0123456789ABCDEFGHIJKLMNOPQRSTUV"
+	// Base16 is the alphabet of the lower case hex digits
+	Base16 = "0123456789abcdef"
+)
+
+// BaseOption is an option of NewBaseEncoder
+type BaseOption int
+
+const (
+	// BaseAligned pads both parts to the fixed width, see Base64.Aligned
+	BaseAligned BaseOption = iota + 1
+	// BaseStrict rejects the non-canonical encodings, see Base64.Strict
+	BaseStrict
+	// BaseChecksum appends a check character, see Base64.Checksum
+	BaseChecksum
+	// BaseCaseInsensitive accepts both cases of the letters on Decode, since the
+	// IDs are often upper-cased by humans and legacy systems, e.g. of Base32Hex
+	BaseCaseInsensitive
+)
+
+// Drop is the substitution of the ignored characters, see BaseEncoder.Substitutions
+const Drop rune = -1
+
+// HumanSubstitutions returns the substitutions of the IDs transcribed by humans,
+// e.g. pasted from the emails: 0 for O and o, 1 for I, i, L and l, and the
+// spaces and hyphens are dropped
+func HumanSubstitutions() map[rune]rune {
+	return map[rune]rune{
+		'O': '0', 'o': '0',
+		'I': '1', 'i': '1', 'L': '1', 'l': '1',
+		' ': Drop, '\t': Drop, '\u00a0': Drop, '-': Drop,
+	}
+}
+
+// BaseEncoder encodes the IDs by a custom alphabet, see NewBaseEncoder
+type BaseEncoder struct {
+	// Substitutions are the characters replaced before decoding, Drop to ignore
+	// them, e.g. HumanSubstitutions. The digits of the alphabet and the sign
+	// marker are never replaced, and the strict decoding checks the result.
+	Substitutions map[rune]rune
+
+	digits string
+	// index is the values of the digits, -1 for the others
+	index [256]int8
+	// width is the number of the digits of 63 bits
+	width int
+	// sign is the sign marker, '!' by default, see WithSign
+	sign byte
+	aligned,
+	strict,
+	checksum,
+	fold bool
+}
+
+// NewBaseEncoder returns the Encoder of the alphabet, e.g. of the existing token
+// formats. The first digit is the zero and the padding, and the IDs are encoded
+// like Base64: the sign marker, the extension part and the main part.
+func NewBaseEncoder(alphabet string, opts ...BaseOption) (*BaseEncoder, error) {
+	if len(alphabet) < 2 || len(alphabet) > 94 {
+		return nil, ErrAlphabet
+	}
+	for i := 0; i < len(alphabet); i++ {
+		c := alphabet[i]
+		if c <= ' ' || c > '~' || c == defaultSignMarker || strings.IndexByte(alphabet[i+1:], c) >= 0 {
+			return nil, ErrAlphabet
+		}
+	}
+	e := &BaseEncoder{digits: alphabet, sign: defaultSignMarker}
+	for i := range e.index {
+		e.index[i] = -1
+	}
+	for i := 0; i < len(alphabet); i++ {
+		e.index[alphabet[i]] = int8(i)
+	}
+	for v := uint63Max; v > 0; v /= uint64(len(alphabet)) {
+		e.width++
+	}
+	for _, o := range opts {
+		switch o {
+		case BaseAligned:
+			e.aligned = true
+		case BaseStrict:
+			e.strict = true
+		case BaseChecksum:
+			if _, found := gfPolynomials[len(alphabet)]; !found {
+				return nil, ErrChecksumBase
+			}
+			e.checksum = true
+		case BaseCaseInsensitive:
+			e.fold = true
+		}
+	}
+	if e.fold {
+		for i := 0; i < len(alphabet); i++ {
+			c := other(alphabet[i])
+			if c == alphabet[i] {
+				continue
+			}
+			if e.index[c] >= 0 {
+				return nil, ErrCaseFold
+			}
+			e.index[c] = int8(i)
+		}
+	}
+	return e, nil
+}
+
+// Alphabet returns the digits of the encoder
+func (e *BaseEncoder) Alphabet() string {
+	return e.digits
+}
+
+// WithSign returns a copy of the encoder with the sign marker c, which MUST NOT
+// be a digit of the alphabet or of its other case if BaseCaseInsensitive
+func (e *BaseEncoder) WithSign(c byte) (*BaseEncoder, error) {
+	if !validSign(c, "") || e.index[c] >= 0 {
+		return nil, ErrSignMarker
+	}
+	v := *e
+	v.sign = c
+	return &v, nil
+}
+
+func (e *BaseEncoder) Encode(id *ID) string {
+	var b strings.Builder
+	b.Grow(e.width*2 + 2)
+	if id.Signed {
+		b.WriteByte(e.sign)
+	}
+	if id.Ext > 0 {
+		e.write(&b, id.Ext, e.aligned)
+	}
+	e.write(&b, id.Main, e.aligned || id.Ext > 0)
+	s := b.String()
+	if e.checksum {
+		c, _ := checksum(s, e.digits, e.sign)
+		s += string(c)
+	}
+	return s
+}
+
+// write writes the digits of v, padded to the width if aligned,
+// the negative values are written as zero
+func (e *BaseEncoder) write(b *strings.Builder, v int64, aligned bool) {
+	var a [64]byte
+	i := len(a)
+	n := uint64(len(e.digits))
+	for u := uint64(v); v > 0 && u > 0; u /= n {
+		i--
+		a[i] = e.digits[u%n]
+	}
+	if i == len(a) && !aligned {
+		i--
+		a[i] = e.digits[0]
+	}
+	for aligned && len(a)-i < e.width {
+		i--
+		a[i] = e.digits[0]
+	}
+	b.Write(a[i:])
+}
+
+func (e *BaseEncoder) Decode(no string) (*ID, error) {
+	s := e.normalize(no)
+	if e.strict {
+		if err := checkSign(s, e.sign); err != nil {
+			return nil, err
+		}
+	}
+	id, err := e.decode(no)
+	if err == nil && e.strict {
+		if err = checkCanonical(s, e.Encode(id)); err != nil {
+			return nil, err
+		}
+	}
+	return id, err
+}
+
+// IsCanonical reports whether the string is the encoding of its ID by the encoder
+func (e *BaseEncoder) IsCanonical(no string) bool {
+	id, err := e.decode(no)
+	return err == nil && e.Encode(id) == no
+}
+
+// normalize returns the string with the letters in the case of the alphabet
+// if BaseCaseInsensitive, and the Substitutions replaced
+func (e *BaseEncoder) normalize(no string) string {
+	if !e.fold && len(e.Substitutions) == 0 {
+		return no
+	}
+	var b strings.Builder
+	b.Grow(len(no))
+	for _, r := range no {
+		if r < utf8.RuneSelf {
+			if d := e.index[r]; d >= 0 {
+				b.WriteByte(e.digits[d])
+				continue
+			}
+		}
+		if v, found := e.Substitutions[r]; found && r != rune(e.sign) {
+			if v >= 0 {
+				b.WriteRune(v)
+			}
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (e *BaseEncoder) decode(no string) (*ID, error) {
+	s := e.normalize(no)
+	if e.checksum {
+		var err error
+		if s, err = verifyChecksum(s, e.digits, e.sign); err != nil {
+			return nil, err
+		}
+	}
+	if s == "" {
+		return nil, decodeError(no, DecodeErrorEmpty)
+	}
+	id := &ID{}
+	if s[0] == e.sign {
+		id.Signed = true
+		s = s[1:]
+	}
+	if s == "" {
+		return nil, decodeError(no, DecodeErrorInvalidDigit)
+	}
+	var err error
+	if len(s) > e.width {
+		if id.Ext, err = e.parse(no, s[:len(s)-e.width]); err != nil {
+			return nil, err
+		}
+		s = s[len(s)-e.width:]
+	}
+	if id.Main, err = e.parse(no, s); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// parse returns the 63 bits value of the digits of the part of no
+func (e *BaseEncoder) parse(no, part string) (int64, error) {
+	base := uint64(len(e.digits))
+	var n uint64
+	for i := 0; i < len(part); i++ {
+		d := e.index[part[i]]
+		if d < 0 {
+			return 0, decodeError(no, DecodeErrorInvalidDigit)
+		}
+		if n > (uint63Max-uint64(d))/base {
+			return 0, decodeError(no, DecodeErrorOverflow)
+		}
+		n = n*base + uint64(d)
+	}
+	return int64(n), nil
+}
+
+// other returns the letter in the other case, or c if it is not a letter
+func other(c byte) byte {
+	switch {
+	case c >= 'a' && c <= 'z':
+		return c - 'a' + 'A'
+	case c >= 'A' && c <= 'Z':
+		return c - 'A' + 'a'
+	}
+	return c
+}
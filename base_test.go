@@ -0,0 +1,126 @@
+package tsid
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestNewBaseEncoder(t *testing.T) {
+	for _, a := range []string{"", "0", "00", "0 1", "01!", "01é"} {
+		if _, err := NewBaseEncoder(a); err != ErrAlphabet {
+			t.Errorf("%q want: %s, got: %v", a, ErrAlphabet, err)
+		}
+	}
+	if _, err := NewBaseEncoder("0123456789", BaseChecksum); err != ErrChecksumBase {
+		t.Errorf("want: %s, got: %v", ErrChecksumBase, err)
+	}
+
+	dec, _ := NewBaseEncoder("0123456789")
+	if s := dec.Encode(&ID{Main: 1234567}); s != "1234567" {
+		t.Errorf("want: 1234567, got: %s", s)
+	}
+	if s := dec.Encode(&ID{Main: 1, Ext: 2, Signed: true}); s != "!20000000000000000001" {
+		t.Errorf("want: !20000000000000000001, got: %s", s)
+	}
+	if s := dec.Encode(&ID{}); s != "0" {
+		t.Errorf("want: 0, got: %s", s)
+	}
+	for _, c := range []struct {
+		alphabet string
+		opts     []BaseOption
+	}{
+		{"01", nil},
+		{"0123456789", []BaseOption{BaseAligned}},
+		{"0123456789ABCDEFGHJKMNPQRSTVWXYZ", []BaseOption{BaseChecksum, BaseStrict}},
+		{"0123456789abcdef", []BaseOption{BaseAligned, BaseChecksum}},
+		{base64Digits, []BaseOption{BaseStrict}},
+	} {
+		e, err := NewBaseEncoder(c.alphabet, c.opts...)
+		if err != nil {
+			t.Fatalf("want: encoder, got: error %s", err)
+			return
+		}
+		for i := 0; i < 50; i++ {
+			id := &ID{Main: Rand(byte(i%63 + 1)), Ext: Rand(byte(i%7*9 + 1)), Signed: i%3 == 0}
+			s := e.Encode(id)
+			if got, err := e.Decode(s); err != nil || !got.Equal(id) || got.Signed != id.Signed {
+				t.Errorf("%s want: %v, got: %v, error %v", c.alphabet, id, got, err)
+			}
+		}
+	}
+	b64, _ := NewBaseEncoder(base64Digits)
+	for i := 0; i < 50; i++ {
+		id := &ID{Main: Rand(63), Ext: Rand(byte(i + 1))}
+		if got, want := b64.Encode(id), (&Base64{}).Encode(id); got != want {
+			t.Errorf("want: %s, got: %s", want, got)
+		}
+	}
+
+	strict, _ := NewBaseEncoder("0123456789", BaseStrict)
+	var de *DecodeError
+	if _, err := strict.Decode("0012"); !errors.As(err, &de) || de.Type != DecodeErrorNonCanonical {
+		t.Errorf("want: non-canonical, got: %v", err)
+	}
+	if !dec.IsCanonical("12") || dec.IsCanonical("012") {
+		t.Error("want: 12 canonical, 012 not, got: otherwise")
+	}
+	for _, s := range []string{"", "!", "12a", strconv.FormatUint(1<<63, 10)} {
+		if _, err := dec.Decode(s); err == nil {
+			t.Errorf("%q want: error, got: nothing", s)
+		}
+	}
+}
+
+func TestBaseCaseInsensitive(t *testing.T) {
+	if _, err := NewBaseEncoder(base64Digits, BaseCaseInsensitive); err != ErrCaseFold {
+		t.Errorf("want: %s, got: %v", ErrCaseFold, err)
+	}
+	e, err := NewBaseEncoder(Base32Hex, BaseCaseInsensitive, BaseChecksum, BaseStrict)
+	if err != nil {
+		t.Fatalf("want: encoder, got: error %s", err)
+		return
+	}
+	id := &ID{Main: 1<<63 - 12345, Ext: 777}
+	s := e.Encode(id)
+	for _, v := range []string{s, strings.ToLower(s)} {
+		if got, err := e.Decode(v); err != nil || !got.Equal(id) {
+			t.Errorf("%s want: %v, got: %v, error %v", v, id, got, err)
+		}
+	}
+	if e.IsCanonical(strings.ToLower(s)) {
+		t.Errorf("want: %s not canonical, got: canonical", strings.ToLower(s))
+	}
+	hex, _ := NewBaseEncoder(Base16)
+	if _, err := hex.Decode("ABC"); err == nil {
+		t.Error("want: error of the upper case, got: nothing")
+	}
+}
+
+func TestBaseSubstitutions(t *testing.T) {
+	e, _ := NewBaseEncoder(Base32Crockford, BaseCaseInsensitive, BaseChecksum)
+	id := &ID{Main: 32*32*32 + 1, Signed: true}
+	s := e.Encode(id)
+	if s != "!1001Q" {
+		t.Fatalf("want: !1001Q, got: %s", s)
+		return
+	}
+	if _, err := e.Decode("!IOo l-q"); err == nil {
+		t.Error("want: error without the substitutions, got: nothing")
+	}
+	e.Substitutions = HumanSubstitutions()
+	for _, v := range []string{"!IOo l-q", " !1 001Q\t", "!1-0-0-1-Q"} {
+		if got, err := e.Decode(v); err != nil || !got.Equal(id) || !got.Signed {
+			t.Errorf("%q want: %v, got: %v, error %v", v, id, got, err)
+		}
+	}
+	hex, _ := NewBaseEncoder(Base32Hex, BaseStrict)
+	hex.Substitutions = HumanSubstitutions()
+	if got, err := hex.Decode("O I"); err != nil || got.Main != 24*32+18 {
+		t.Errorf("want: the digits O and I kept, got: %v, error %v", got, err)
+	}
+	if _, err := hex.Decode("0O I"); err == nil {
+		t.Error("want: non-canonical error, got: nothing")
+	}
+}
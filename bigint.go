@@ -0,0 +1,56 @@
+package tsid
+
+import (
+	"errors"
+	"math/big"
+)
+
+// ErrBigIntOverflow indicates that the value exceeds 126 bits
+var ErrBigIntOverflow = errors.New("tsid: the value exceeds 126 bits")
+
+// Uint128 returns the 126 bits value of the ID (Ext<<63 | Main)
+// as {high 64 bits, low 64 bits}, the sign flag is not included.
+func (id *ID) Uint128() [2]uint64 {
+	return [2]uint64{
+		uint64(id.Ext) >> 1,
+		uint64(id.Main) | uint64(id.Ext)<<bitsMaxWidth,
+	}
+}
+
+// FromUint128 returns the ID of the value made by ID.Uint128
+func FromUint128(v [2]uint64) (*ID, error) {
+	if v[0]>>(bitsMaxWidth-1) != 0 {
+		return nil, ErrBigIntOverflow
+	}
+	return &ID{
+		Main: int64(v[1] &^ (1 << bitsMaxWidth)),
+		Ext:  int64(v[0]<<1 | v[1]>>bitsMaxWidth),
+	}, nil
+}
+
+// BigInt returns the 126 bits value of the ID (Ext<<63 | Main),
+// which is negative if the ID is signed.
+func (id *ID) BigInt() *big.Int {
+	v := big.NewInt(id.Ext)
+	v.Lsh(v, bitsMaxWidth)
+	v.Or(v, big.NewInt(id.Main))
+	if id.Signed {
+		v.Neg(v)
+	}
+	return v
+}
+
+// FromBigInt returns the ID of the value made by ID.BigInt
+func FromBigInt(v *big.Int) (*ID, error) {
+	if v.BitLen() > bitsMaxWidth*2 {
+		return nil, ErrBigIntOverflow
+	}
+	a := new(big.Int).Abs(v)
+	mask := big.NewInt(-1 ^ (-1 << bitsMaxWidth))
+	id := &ID{
+		Main:   new(big.Int).And(a, mask).Int64(),
+		Ext:    a.Rsh(a, bitsMaxWidth).Int64(),
+		Signed: v.Sign() < 0,
+	}
+	return id, nil
+}
@@ -0,0 +1,40 @@
+package tsid
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestBigInt(t *testing.T) {
+	for _, id := range []*ID{
+		{},
+		{Main: 1<<63 - 1},
+		{Main: 12345, Ext: 1<<63 - 1},
+		{Main: 1, Ext: 3, Signed: true},
+	} {
+		v := id.BigInt()
+		got, e := FromBigInt(v)
+		if e != nil || !got.Equal(id) && !id.IsZero() {
+			t.Errorf("want: %v, got: %v, error %v", id, got, e)
+		}
+		u, e := FromUint128(id.Uint128())
+		if e != nil || u.Main != id.Main || u.Ext != id.Ext {
+			t.Errorf("want: %v, got: %v, error %v", id, u, e)
+		}
+		a := id.Uint128()
+		w := new(big.Int).Lsh(new(big.Int).SetUint64(a[0]), 64)
+		w.Or(w, new(big.Int).SetUint64(a[1]))
+		if w.Cmp(new(big.Int).Abs(v)) != 0 {
+			t.Errorf("want: %s, got: %s", v, w)
+		}
+	}
+	if v := (&ID{Main: 1, Ext: 1}).BigInt(); v.String() != "9223372036854775809" {
+		t.Errorf("want: 2^63+1, got: %s", v)
+	}
+	if _, e := FromBigInt(new(big.Int).Lsh(big.NewInt(1), 126)); e != ErrBigIntOverflow {
+		t.Errorf("want: error(%s), got: %v", ErrBigIntOverflow, e)
+	}
+	if _, e := FromUint128([2]uint64{1 << 62, 0}); e != ErrBigIntOverflow {
+		t.Errorf("want: error(%s), got: %v", ErrBigIntOverflow, e)
+	}
+}
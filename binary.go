@@ -0,0 +1,47 @@
+package tsid
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// ErrBinary16 indicates that the binary form of the Binary encoder is not 16 bytes
+// or sets the top bit of the main part
+var ErrBinary16 = errors.New("tsid: the binary form must be 16 bytes of 63 bits words")
+
+// binarySigned is the sign flag in the first byte, the unused top bit of Ext
+const binarySigned = 0x80
+
+// Binary encodes the IDs in exactly 16 big-endian bytes (Ext, Main) of Array16,
+// for the protobuf bytes fields and the binary wire formats, where the top bit
+// is the sign flag. The strings of Encode are the raw bytes.
+type Binary struct{}
+
+func (e *Binary) Encode(id *ID) string {
+	return string(e.Marshal(id))
+}
+
+func (e *Binary) Decode(no string) (*ID, error) {
+	return e.Unmarshal([]byte(no))
+}
+
+// Marshal returns the 16 bytes of the ID
+func (e *Binary) Marshal(id *ID) []byte {
+	a := id.Array16()
+	if id.Signed {
+		a[0] |= binarySigned
+	}
+	return a[:]
+}
+
+// Unmarshal returns the ID of the 16 bytes made by Marshal
+func (e *Binary) Unmarshal(data []byte) (*ID, error) {
+	if len(data) != 16 || data[8]&0x80 != 0 {
+		return nil, ErrBinary16
+	}
+	return &ID{
+		Main:   int64(binary.BigEndian.Uint64(data[8:])),
+		Ext:    int64(binary.BigEndian.Uint64(data[:8]) &^ (binarySigned << 56)),
+		Signed: data[0]&binarySigned != 0,
+	}, nil
+}
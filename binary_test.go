@@ -0,0 +1,36 @@
+package tsid
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBinary(t *testing.T) {
+	e := &Binary{}
+	b, _ := Make(OpenID())
+	for i := 0; i < 20; i++ {
+		id := b.Next()
+		id.Signed = i%2 == 0
+		s := e.Encode(id)
+		if len(s) != 16 {
+			t.Fatalf("want: 16 bytes, got: %d", len(s))
+			return
+		}
+		if got, err := e.Decode(s); err != nil || !got.Equal(id) || got.Signed != id.Signed {
+			t.Errorf("want: %v, got: %v, error %v", id, got, err)
+		}
+	}
+	id := &ID{Main: 0x0102, Ext: 0x03, Signed: true}
+	want := []byte{0x80, 0, 0, 0, 0, 0, 0, 0x03, 0, 0, 0, 0, 0, 0, 0x01, 0x02}
+	if got := e.Marshal(id); !bytes.Equal(got, want) {
+		t.Errorf("want: %x, got: %x", want, got)
+	}
+	if got := e.Marshal(&ID{Main: 1}); len(got) != 16 {
+		t.Errorf("want: 16 bytes, got: %x", got)
+	}
+	for _, data := range [][]byte{nil, make([]byte, 8), make([]byte, 17), append(make([]byte, 8), 0x80, 0, 0, 0, 0, 0, 0, 0)} {
+		if _, err := e.Unmarshal(data); err != ErrBinary16 {
+			t.Errorf("%x want: %s, got: %v", data, ErrBinary16, err)
+		}
+	}
+}
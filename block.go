@@ -0,0 +1,142 @@
+package tsid
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// BlockSize is the maximum duration of a preallocated block
+const BlockSize = time.Second
+
+// ErrWindowInvalid indicates that the preallocation window is not positive
+var ErrWindowInvalid = errors.New("tsid: the preallocation window must be positive")
+
+// Block is a range of the future ticks of the timestamp whose full sequence
+// space is reserved for exclusive offline use.
+type Block struct {
+	// From and To is the range of the time, [From, To)
+	From, To time.Time
+	// Sequences is the number of the sequence values in every tick
+	Sequences int64
+}
+
+// Contains reports whether the time t is in the block
+func (k *Block) Contains(t time.Time) bool {
+	return !t.Before(k.From) && t.Before(k.To)
+}
+
+// Preallocate reserves the future ticks of the window, starting after the latest
+// issued or reserved tick, and returns them as blocks of at most BlockSize or a
+// tick. The window is rounded up to whole ticks of the timestamp, e.g. seconds,
+// and of Options.LowVolume. The builder does not issue IDs in the reserved
+// ticks, Next sleeps without the lock until they have passed.
+func (b *Builder) Preallocate(window time.Duration) ([]Block, error) {
+	if !b.isReady() {
+		return nil, ErrNotReady
+	}
+	window = window.Truncate(time.Millisecond)
+	if window <= 0 {
+		return nil, ErrWindowInvalid
+	}
+	b.Lock()
+	defer b.Unlock()
+	unit := b.tickUnit()
+	size := BlockSize
+	if unit > size {
+		size = unit
+	}
+	from := b.nextTick(b.timeNow(), unit)
+	last := b.now
+	if b.lockFree {
+		last = b.claimed()
+	}
+	if last != nil && !from.After(*last) {
+		from = b.nextTick(*last, unit)
+	}
+	if n := len(b.reserved); n > 0 && from.Before(b.reserved[n-1].To) {
+		from = b.reserved[n-1].To
+	}
+	to := b.nextTick(from.Add(window-time.Millisecond), unit)
+	if b.lockFree {
+		b.reserveUntil(to)
+	} else {
+		b.reserved = append(b.reserved, Block{From: from, To: to, Sequences: b.sequenceMask + 1})
+	}
+	var blocks []Block
+	for t := from; t.Before(to); t = t.Add(size) {
+		e := t.Add(size)
+		if e.After(to) {
+			e = to
+		}
+		blocks = append(blocks, Block{From: t, To: e, Sequences: b.sequenceMask + 1})
+	}
+	return blocks, nil
+}
+
+// tickUnit returns the duration of a tick of the timestamp, at least a millisecond
+func (b *Builder) tickUnit() time.Duration {
+	unit := timestampUnit(b.options.segments)
+	if q := time.Duration(b.quantum) * time.Millisecond; q > unit {
+		unit = q
+	}
+	if unit < time.Millisecond {
+		unit = time.Millisecond
+	}
+	return unit
+}
+
+// nextTick returns the start of the tick after the one of the time t, the ticks
+// start at the epoch of the builder
+func (b *Builder) nextTick(t time.Time, unit time.Duration) time.Time {
+	e := epoch(atomic.LoadInt64(&b.options.EpochMS))
+	u := unit.Milliseconds()
+	ms := t.UnixMilli() - e
+	if ms < 0 {
+		ms -= u - 1
+	}
+	return time.UnixMilli((ms/u+1)*u + e)
+}
+
+// skipReserved drops the passed blocks and reports whether the time t is in a
+// reserved block, after waiting until it has passed. The caller MUST hold the
+// lock, which is released while waiting.
+func (b *Builder) skipReserved(t time.Time) bool {
+	for len(b.reserved) > 0 {
+		k := b.reserved[0]
+		if t.Before(k.From) {
+			return false
+		}
+		if k.Contains(t) {
+			b.Unlock()
+			b.sleepUntil(k.To, 0)
+			b.Lock()
+			return true
+		}
+		// the block has passed
+		b.reserved = b.reserved[1:]
+	}
+	return false
+}
+
+// sleepUntil sleeps until the clock of the builder reaches t, and returns the
+// time. The sleeps on an injected clock are at most a millisecond each, since
+// it may run faster than the wall clock, e.g. ManualClock.Step. It gives up
+// after sleeping for the timeout if positive, and returns false.
+func (b *Builder) sleepUntil(t time.Time, timeout time.Duration) (time.Time, bool) {
+	start := time.Now()
+	for {
+		n := b.timeNow()
+		d := t.Sub(n)
+		if d <= 0 {
+			return n, true
+		}
+		if timeout > 0 && time.Since(start) >= timeout {
+			return n, false
+		}
+		if b.nowFunc != nil && d > time.Millisecond {
+			d = time.Millisecond
+		}
+		time.Sleep(d)
+	}
+}
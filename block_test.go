@@ -0,0 +1,118 @@
+package tsid
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPreallocate(t *testing.T) {
+	b, e := Make(Default())
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	if _, e = b.Preallocate(0); e != ErrWindowInvalid {
+		t.Errorf("want: error(%s), got: %v", ErrWindowInvalid, e)
+	}
+	blocks, e := b.Preallocate(BlockSize + 5*time.Millisecond)
+	if e != nil {
+		t.Fatalf("want: blocks, got: error %s", e)
+		return
+	}
+	if len(blocks) != 2 || blocks[1].To.Sub(blocks[0].From) != BlockSize+5*time.Millisecond {
+		t.Fatalf("want: 2 blocks, got: %v", blocks)
+		return
+	}
+	if blocks[0].Sequences != 1<<SequenceWidth {
+		t.Errorf("want: %d sequences, got: %d", 1<<SequenceWidth, blocks[0].Sequences)
+	}
+	next, _ := b.Preallocate(time.Millisecond)
+	if next[0].From != blocks[1].To {
+		t.Errorf("want: the blocks are contiguous, got: %s, %s", blocks[1].To, next[0].From)
+	}
+	c, _ := Make(Default())
+	c.Debug = true
+	blocks, _ = c.Preallocate(5 * time.Millisecond)
+	for {
+		c.Next()
+		now := c.DebugInfo().Now
+		if blocks[0].Contains(now) {
+			t.Fatalf("want: the reserved blocks are skipped, got: %s", now)
+			return
+		}
+		if !now.Before(blocks[0].To) {
+			break
+		}
+	}
+}
+
+func TestPreallocateManualClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b, _ := Make(*Config(1, 2, Sequence(12), Env(4, "TSID_TEST_NODE", 0), Timestamp(41, TimestampMilliseconds)))
+	b.WithClock(NewManualClock(start, time.Minute))
+	blocks, e := b.Preallocate(10 * time.Minute)
+	if e != nil {
+		t.Fatalf("want: blocks, got: error %s", e)
+		return
+	}
+	id, e := b.TryNext()
+	if e != nil {
+		t.Fatalf("want: an ID, got: error %s", e)
+		return
+	}
+	if got, _ := b.TimeOf(id); got.Before(blocks[len(blocks)-1].To) {
+		t.Errorf("want: after %s, got: %s", blocks[len(blocks)-1].To, got)
+	}
+}
+
+func TestPreallocateTicks(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 300e6, time.UTC)
+	opt := *Segments(Sequence(12), Env(4, "TSID_TEST_NODE", 0), Timestamp(40, TimestampSeconds))
+	b, e := Make(opt)
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	clock := NewManualClock(start, 100*time.Millisecond)
+	b.WithClock(clock)
+	blocks, e := b.Preallocate(5 * time.Millisecond)
+	if e != nil {
+		t.Fatalf("want: blocks, got: error %s", e)
+		return
+	}
+	if k := blocks[0]; len(blocks) != 1 || k.To.Sub(k.From) != time.Second || k.From.UnixMilli()%1000 != 0 {
+		t.Fatalf("want: a whole second, got: %v", blocks)
+		return
+	}
+	clock.Set(blocks[0].From)
+	id, e := b.TryNext()
+	if e != nil {
+		t.Fatalf("want: an ID, got: error %s", e)
+		return
+	}
+	if got, _ := b.TimeOf(id); got.Before(blocks[0].To) {
+		t.Errorf("want: after %s, got: %s", blocks[0].To, got)
+	}
+}
+
+func TestPreallocateUnlocked(t *testing.T) {
+	b, _ := Make(*Config(1, 2, Sequence(12), Env(4, "TSID_TEST_NODE", 0), Timestamp(41, TimestampMilliseconds)))
+	blocks, _ := b.Preallocate(300 * time.Millisecond)
+	time.Sleep(time.Until(blocks[0].From))
+	done := make(chan struct{})
+	go func() {
+		b.Next()
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	start := time.Now()
+	// Next waits for the block without holding the lock
+	b.Stats()
+	if d := time.Since(start); d > 100*time.Millisecond {
+		t.Errorf("want: Stats without waiting, got: %s", d)
+	}
+	<-done
+	if time.Now().Before(blocks[0].To) {
+		t.Errorf("want: after %s, got: %s", blocks[0].To, time.Now())
+	}
+}
@@ -7,10 +7,12 @@ import (
 	cr "crypto/rand"
 	"encoding/binary"
 	"errors"
-	"os"
+	"io"
+	"math/bits"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -24,12 +26,19 @@ const (
 )
 
 const (
-	// Cutoff is the smallest number such that cutoff*64 > maxUint64
-	cutoff           = 1 << 63
 	uint63Max uint64 = 1<<63 - 1
 	// uint64Max        = 1<<64 - 1
 )
 
+var (
+	// ErrNotReady indicates that the builder was not made by New or Make
+	ErrNotReady = errors.New("tsid: the builder is not ready")
+	// ErrBinaryLength indicates that the binary form is not 8 or 16 bytes of 63 bits words
+	ErrBinaryLength = errors.New("tsid: the binary form must be 8 or 16 bytes of 63 bits words")
+	// ErrUnknownArg indicates that the named argument matches no Args segment
+	ErrUnknownArg = errors.New("tsid: the named argument matches no Args segment")
+)
+
 type ID struct {
 	Main,
 	Ext int64
@@ -50,6 +59,8 @@ func (id *ID) Equal(b *ID) bool {
 	return false
 }
 
+// Bytes returns the little-endian form of the ID, the main part followed by the
+// extension part if any, see MarshalBinary for the big-endian form which sorts.
 func (id *ID) Bytes() []byte {
 	var buf []byte
 	if id.Ext > 0 {
@@ -62,11 +73,95 @@ func (id *ID) Bytes() []byte {
 	return buf
 }
 
+// Compare returns -1, 0 or +1 as the numeric value of the ID (Ext, then Main)
+// is less than, equal to or greater than the other, the sign flag is ignored.
+func (id *ID) Compare(other *ID) int {
+	switch {
+	case id.Ext < other.Ext:
+		return -1
+	case id.Ext > other.Ext:
+		return 1
+	case id.Main < other.Main:
+		return -1
+	case id.Main > other.Main:
+		return 1
+	}
+	return 0
+}
+
+// Less reports whether the ID is less than the other, see Compare
+func (id *ID) Less(other *ID) bool {
+	return id.Compare(other) < 0
+}
+
+// Key returns the comparable value {Ext, Main} of the ID, which can be used as
+// a map key regardless of the pointer identity, the sign flag is not included.
+// Note that the ID value (not the pointer) is comparable as well, sign included.
+func (id *ID) Key() [2]int64 {
+	return [2]int64{id.Ext, id.Main}
+}
+
+// FromKey returns the ID of the value made by ID.Key
+func FromKey(k [2]int64) *ID {
+	return &ID{Main: k[1], Ext: k[0]}
+}
+
+// Array16 returns the canonical big-endian form of the ID, the extension part
+// followed by the main part, which is comparable and can be used as a map key.
+// The arrays sort (by bytes.Compare) in the same order as ID.Compare,
+// the sign flag is not included.
+func (id *ID) Array16() (a [16]byte) {
+	binary.BigEndian.PutUint64(a[:8], uint64(id.Ext))
+	binary.BigEndian.PutUint64(a[8:], uint64(id.Main))
+	return
+}
+
+// FromArray16 returns the ID of the canonical big-endian form made by ID.Array16
+func FromArray16(a [16]byte) *ID {
+	return &ID{
+		Main: int64(binary.BigEndian.Uint64(a[8:])),
+		Ext:  int64(binary.BigEndian.Uint64(a[:8])),
+	}
+}
+
+// MarshalBinary encodes the ID in the canonical big-endian form, 8 bytes (Main)
+// if the ID has no extension part, otherwise 16 bytes (Ext, Main), which sorts
+// the same as the numeric order. The sign flag is not included.
+func (id ID) MarshalBinary() ([]byte, error) {
+	if id.Ext == 0 {
+		buf := make([]byte, 8)
+		binary.BigEndian.PutUint64(buf, uint64(id.Main))
+		return buf, nil
+	}
+	a := id.Array16()
+	return a[:], nil
+}
+
+// UnmarshalBinary decodes the 8 or 16 bytes made by MarshalBinary
+func (id *ID) UnmarshalBinary(data []byte) error {
+	var v ID
+	switch len(data) {
+	case 8:
+		v.Main = int64(binary.BigEndian.Uint64(data))
+	case 16:
+		var a [16]byte
+		copy(a[:], data)
+		v = *FromArray16(a)
+	default:
+		return ErrBinaryLength
+	}
+	if v.Main < 0 || v.Ext < 0 {
+		return ErrBinaryLength
+	}
+	*id = v
+	return nil
+}
+
 func (id *ID) String() string {
 	s := strings.Builder{}
 	s.Grow(28)
 	if id.Signed && (id.Ext > 0 || id.Main > 0) {
-		// 1 character
+		// 1 character, see Base36.Sign for the other markers
 		s.WriteByte('-')
 	}
 	if id.Ext > 0 {
@@ -87,25 +182,152 @@ func (id *ID) String() string {
 	return s.String()
 }
 
+// Origin indicates where the value of a segment came from, see DebugInfo
+type Origin int
+
+const (
+	// OriginStatic indicates that the value is declared by the layout (Static, ReservedBits)
+	OriginStatic Origin = iota
+	// OriginGenerated indicates that the value is generated by the builder
+	// (SequenceID, DateTime, RandomID, Backfilled, GroupKind)
+	OriginGenerated
+	// OriginSource indicates that the value is supplied by the argument, environment
+	// variable, setting or data provider
+	OriginSource
+	// OriginFallback indicates that the source failed to supply the value,
+	// and the fallback value is used
+	OriginFallback
+)
+
+var originNames = []string{
+	"Static",
+	"Generated",
+	"Source",
+	"Fallback",
+}
+
+func (o Origin) String() string {
+	if int(o) < len(originNames) {
+		return originNames[o]
+	}
+	return "Undefined"
+}
+
 type DebugInfo struct {
 	Sequence int64
 	Raw      []int64
-	Now      time.Time
+	// Origins is where the values of the segments came from, in the order of Raw
+	Origins []Origin
+	// Values is the values of the segments by their labels, see Bits.Label
+	Values map[string]int64
+	Now    time.Time
 }
 
 type Builder struct {
 	sync.Mutex
+	// dropped, generated, clock and the following are accessed atomically,
+	// keep them 64-bit aligned
+	dropped,
+	generated uint64
+	// highWater is the largest sequence issued since Make, waited is the nanoseconds
+	// waited for the clock
+	highWater,
+	waited uint64
+	// clock is the packed latest millisecond and sequence of the lock-free layouts,
+	// fence is the latest millisecond reserved by Preallocate or borrowed by
+	// OverflowIntoTimestamp
+	clock,
+	fence uint64
+	// nearing is the unix millisecond from which the IDs are generated within
+	// the reserved days of the epoch exhaustion, 0 means unwatched
+	nearing uint64
 
 	Encoder Encoder
 	Debug   bool
 
-	ready   bool
+	ready bool
+	// closed is set atomically by Close, and closing excludes Close from the
+	// generation without the lock
+	closed  uint32
+	closing sync.RWMutex
 	options *Options
 
 	sequenceMask,
 	sequence int64
-	info *DebugInfo
-	now  *time.Time
+	// width is the total width of the segments
+	width byte
+	info  *DebugInfo
+	now   *time.Time
+
+	sink chan *ID
+	// sinkDone is closed when the sink goroutine exits, see Close
+	sinkDone chan struct{}
+
+	// warnings is the problems of the options found by Make
+	warnings []*OptionsError
+	// granularity is the granularity of the clock measured by Make
+	granularity time.Duration
+	// nowFunc is the clock of the builder, nil means time.Now
+	nowFunc func() time.Time
+	// rand is the random pool, nil means reading per value
+	rand *randPool
+	// fallback is the weak keystream of RandFallback
+	fallback fallbackRand
+	// journal is the issuance log, see Options.Journal
+	journal *Journal
+	// codes is the window of the short codes, see NextWithShortCode
+	codes shortCodes
+	// overflow is the latest tick borrowed by OverflowIntoTimestamp
+	overflow int64
+	// quantum is the milliseconds per tick of the sequence, see Options.LowVolume
+	quantum int64
+	// blocks is set if the sequences are reserved in blocks, see fromBlock
+	blocks bool
+	// block is the latest *seqBlock
+	block atomic.Value
+
+	// shared is the sequence shared with other processes
+	shared SharedSequence
+
+	// reserved is the preallocated blocks, which are skipped by Next
+	reserved []Block
+
+	// lockFree indicates that Next claims the sequence by CAS on clock
+	lockFree      bool
+	sequenceWidth byte
+	// clockEpoch is the epoch of the milliseconds packed in clock, which is the
+	// epoch of the builder at Make
+	clockEpoch int64
+
+	// backfill indicates that the layout has a Backfilled segment
+	backfill bool
+	// backfillTick is the latest tick of the timestamp by NextAt, and
+	// backfillSequence is its last sequence
+	backfillTick,
+	backfillSequence int64
+}
+
+// Options returns a snapshot of the options used by the builder,
+// modifying the snapshot does not affect the builder.
+func (b *Builder) Options() Options {
+	b.Lock()
+	defer b.Unlock()
+	return b.options.clone()
+}
+
+// Layout returns a copy of the segments of the builder, in the order of Add
+func (b *Builder) Layout() []Bits {
+	if !b.isReady() {
+		return nil
+	}
+	opt := b.Options()
+	return opt.segments
+}
+
+// Base64 returns the aligned Base64 encoder of the width of the layout,
+// whose strings have the same length
+func (b *Builder) Base64() *Base64 {
+	return &Base64{Aligned: true, Width: b.width}
 }
 
 // DebugInfo is used to obtain the debugging information of the latest ID
@@ -113,41 +335,104 @@ func (b *Builder) DebugInfo() *DebugInfo {
 	return b.info
 }
 
-func (b *Builder) tick() (sequence int64) {
-	n := time.Now()
-	ms := n.UnixMilli()
-	bs := int64(0)
-	if b.now != nil {
-		bs = b.now.UnixMilli()
+// isReady reports whether the builder is made and not closed
+func (b *Builder) isReady() bool {
+	return b.ready && atomic.LoadUint32(&b.closed) == 0
+}
+
+func (b *Builder) tick() (sequence int64, err error) {
+	var n time.Time
+	if b.lockFree {
+		if n, sequence, err = b.claim(); err != nil {
+			return 0, err
+		}
+		b.now = &n
+		b.sequence = sequence
+		b.mark(sequence)
+		return
 	}
-	if ms == bs {
-		sequence = (b.sequence + 1) & b.sequenceMask
-		if sequence == 0 {
-			for ms <= bs {
-				n = time.Now()
-				ms = n.UnixMilli()
+	if b.shared != nil {
+		n, sequence, err = b.shared.Tick(b.sequenceMask)
+		if err != nil {
+			return 0, err
+		}
+	} else {
+		// ms and bs are the ticks, which are the milliseconds unless LowVolume
+		q := b.quantum
+		if q < 1 {
+			q = 1
+		}
+		n = b.timeNow()
+		ms := n.UnixMilli() / q
+		bs := int64(0)
+		if b.now != nil {
+			bs = b.now.UnixMilli() / q
+		}
+		if ms < bs && bs <= b.overflow {
+			// the timestamp runs ahead of the clock, see OverflowIntoTimestamp
+			n, ms = *b.now, bs
+		} else if ms < bs {
+			var borrow bool
+			if n, borrow, err = b.backwards(n, b.now.UnixMilli(), false); err != nil {
+				return 0, err
+			}
+			if borrow {
+				n = *b.now
+			}
+			ms = n.UnixMilli() / q
+		}
+		if ms == bs {
+			sequence = (b.sequence + 1) & b.sequenceMask
+			if sequence == 0 {
+				switch b.options.OnExhausted {
+				case ExhaustionError:
+					return 0, ErrSequenceExhausted
+				case OverflowIntoTimestamp:
+					b.overflow = bs + 1
+					n = time.UnixMilli(b.overflow * q)
+				default:
+					start := time.Now()
+					for ms <= bs {
+						b.pause(n.UnixMilli(), (bs+1)*q)
+						n = b.timeNow()
+						ms = n.UnixMilli() / q
+					}
+					b.spun(start)
+				}
 			}
 		}
 	}
+	if len(b.reserved) > 0 && b.skipReserved(n) {
+		// the lock was released, tick again after the reserved block
+		return b.tick()
+	}
 	b.now = &n
 	b.sequence = sequence
+	b.mark(sequence)
 	return
 }
 
 // Rand generates a secure random number with a width specified by w,
 // which is the expected bit width, value range is [1, 63].
+// It returns 0 if the random source fails, see Options.OnRandFailure.
 func Rand(w byte) int64 {
+	v, _ := readRand(cr.Reader, w)
+	return v
+}
+
+// readRand reads a random number with the width w from r, see Rand
+func readRand(r io.Reader, w byte) (int64, error) {
 	if w < 1 || w > 63 {
-		return 0
+		return 0, nil
 	}
 	c := w / 8
 	if w%8 > 0 {
 		c += 1
 	}
 	buf := make([]byte, c)
-	n, e := cr.Read(buf)
-	if e != nil || n < 1 {
-		return 0
+	n, e := io.ReadFull(r, buf)
+	if e != nil {
+		return 0, e
 	}
 	v := uint64(0)
 	switch n {
@@ -194,14 +479,12 @@ func Rand(w byte) int64 {
 			uint64(buf[0])
 	}
 	m := -1 ^ (-1 << w)
-	return int64(v & uint64(m))
+	return int64(v & uint64(m)), nil
 }
 
 func (b *Builder) datetime(t DateTimeType, tr *time.Time) (f int64) {
-	epoch := b.options.EpochMS
-	if epoch < 0 {
-		epoch = 0
-	}
+	// ResetEpoch may run concurrently with the lock-free Next
+	epoch := epoch(atomic.LoadInt64(&b.options.EpochMS))
 	switch t {
 	case TimestampNanoseconds:
 		f = tr.UnixNano() - epoch*nsPerMilliseconds
@@ -209,6 +492,8 @@ func (b *Builder) datetime(t DateTimeType, tr *time.Time) (f int64) {
 		f = tr.UnixMicro() - epoch*usPerMilliseconds
 	case TimestampSeconds:
 		f = tr.Unix() - epoch/msPerSecond
+	case TimestampMinutes:
+		f = tr.Unix()/60 - epoch/msPerMinute
 	case TimeNanosecond:
 		f = tr.UnixNano() % (nsPerMilliseconds * msPerSecond)
 	case TimeMicrosecond:
@@ -241,78 +526,331 @@ func (b *Builder) datetime(t DateTimeType, tr *time.Time) (f int64) {
 }
 
 func (b *Builder) data(name string, query *[]interface{}) (int64, error) {
-	if h, o := dataSources[name]; o {
+	if h, o := b.options.Environment.provider(name); o {
 		return h.Read(*query...)
 	}
 	return 0, errors.New("data not found")
 }
 
-func (b *Builder) val(segment *Bits, tr *time.Time, seq int64, argv []int64, a int, f int64) int64 {
+func (b *Builder) val(segment *Bits, tr *time.Time, seq int64, argv []int64, named map[string]int64, a int, f int64) (int64, Origin) {
 	key := segment.Key
 	switch segment.Source {
 	case Args:
-		if a < len(argv) {
-			f = argv[a]
+		if named != nil {
+			if v, found := named[key]; found && key != "" {
+				return v, OriginSource
+			}
+		} else if a < len(argv) {
+			return argv[a], OriginSource
 		}
 	case OS:
 		if len(key) > 0 {
-			if y, z := os.LookupEnv(key); z {
-				if w, r := strconv.ParseInt(y, 10, 64); r == nil {
-					f = w
-				}
+			if w, z, r := b.options.Environment.lookupEnv(key, segment.mask); z && r == nil {
+				return w, OriginSource
 			}
 		}
 	case Settings:
 		if len(key) > 0 {
 			if y, z := b.options.settings[key]; z {
-				f = y
+				return y, OriginSource
 			}
 		}
 	case SequenceID:
-		f = seq
+		return seq, OriginGenerated
 	case DateTime:
-		f = b.datetime(DateTimeType(segment.Index), tr)
-	case RandomID:
-		f = Rand(segment.Width)
+		return b.datetime(DateTimeType(segment.Index), tr), OriginGenerated
 	case Provider:
-		if v, o := b.data(segment.Key, &segment.query); o == nil {
-			f = v
+		v, o := b.data(segment.Key, &segment.query)
+		if o == nil {
+			return v, OriginSource
+		}
+		if b.options.Metrics != nil {
+			b.options.Metrics.ProviderError(segment.Key, o)
 		}
+	default:
+		return f, OriginStatic
 	}
-	return f
+	return f, OriginFallback
 }
 
 // TODO: checksum
 // func (b *Builder) crc32(argv ...int64) int32 {
 // }
-// TODO: bytes
-// func (b *Builder) Bytes(argv ...int64) []byte {
-// }
 
+// NextInt64 returns the main part of the next ID, or 0 if the builder is not
+// ready or fails, use TryNext to obtain the reason.
 func (b *Builder) NextInt64(argv ...int64) int64 {
-	id := b.Next(argv...)
+	id, err := b.TryNext(argv...)
+	if err != nil {
+		return 0
+	}
 	return id.Main
 }
 
+// Next returns the next ID, or nil if the builder is not ready or fails,
+// use TryNext to obtain the reason.
 func (b *Builder) Next(argv ...int64) (id *ID) {
-	if !b.ready {
+	id, _ = b.TryNext(argv...)
+	return id
+}
+
+// TryNext returns the next ID, or the error which prevents generating it.
+func (b *Builder) TryNext(argv ...int64) (*ID, error) {
+	return b.issue(argv, nil)
+}
+
+// NextWith returns the next ID, the values of the Args segments are matched by
+// their keys against the named arguments, see NamedArg. The segments without
+// keys or missing in args use their fallback values. The names not matching
+// any Args segment fail with ErrUnknownArg.
+func (b *Builder) NextWith(args map[string]int64) (*ID, error) {
+	if !b.isReady() {
+		return nil, ErrNotReady
+	}
+	for name := range args {
+		found := false
+		for _, segment := range b.options.segments {
+			if segment.Source == Args && segment.Key == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, ErrUnknownArg
+		}
+	}
+	if args == nil {
+		args = map[string]int64{}
+	}
+	return b.issue(nil, args)
+}
+
+// issue generates the next ID by the positional or the named arguments
+func (b *Builder) issue(argv []int64, named map[string]int64) (*ID, error) {
+	if !b.isReady() {
+		return nil, ErrNotReady
+	}
+	if (b.lockFree || b.blocks) && !b.Debug {
+		b.closing.RLock()
+		defer b.closing.RUnlock()
+		if !b.isReady() {
+			return nil, ErrNotReady
+		}
+		claim := b.claim
+		if b.blocks {
+			claim = b.fromBlock
+		}
+		n, seq, err := claim()
+		if err != nil {
+			return nil, err
+		}
+		main, ext, err := b.compose(&n, seq, 0, -1, argv, named)
+		if err == nil {
+			err = b.emit(main, ext)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &ID{
+			Main:   main,
+			Ext:    ext,
+			Signed: b.options.Signed,
+		}, nil
+	}
+	b.Lock()
+	defer b.Unlock()
+	main, ext, err := b.next(argv, named)
+	if err == nil {
+		err = b.emit(main, ext)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ID{
+		Main:   main,
+		Ext:    ext,
+		Signed: b.options.Signed,
+	}, nil
+}
+
+// NextBytes writes the next ID into buf in the canonical big-endian form,
+// 8 bytes (Main) if the layout fits in 63 bits, otherwise 16 bytes (Ext, Main),
+// and returns the number of bytes written.
+func (b *Builder) NextBytes(buf []byte, argv ...int64) (n int, err error) {
+	if !b.isReady() {
+		return 0, ErrNotReady
+	}
+	n = 8
+	if b.width > bitsMaxWidth {
+		n = 16
+	}
+	if len(buf) < n {
+		return 0, io.ErrShortBuffer
+	}
+	b.Lock()
+	main, ext, err := b.next(argv, nil)
+	if err == nil {
+		err = b.emit(main, ext)
+	}
+	b.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	if n > 8 {
+		binary.BigEndian.PutUint64(buf, uint64(ext))
+		binary.BigEndian.PutUint64(buf[8:], uint64(main))
+	} else {
+		binary.BigEndian.PutUint64(buf, uint64(main))
+	}
+	return n, nil
+}
+
+// ByteWidth returns the number of bytes of the compact form written by AppendNext,
+// which is the width of the layout rounded up to bytes.
+func (b *Builder) ByteWidth() int {
+	return (int(b.width) + 7) / 8
+}
+
+// AppendNext appends the next ID to dst in the compact big-endian form of
+// ByteWidth bytes, the value Ext<<63 | Main trimmed to the width of the layout,
+// without allocating an ID. See FromCompact for the inverse.
+func (b *Builder) AppendNext(dst []byte, argv ...int64) ([]byte, error) {
+	if !b.isReady() {
+		return dst, ErrNotReady
+	}
+	b.Lock()
+	main, ext, err := b.next(argv, nil)
+	if err == nil {
+		err = b.emit(main, ext)
+	}
+	b.Unlock()
+	if err != nil {
+		return dst, err
+	}
+	var a [16]byte
+	binary.BigEndian.PutUint64(a[:8], uint64(ext)>>1)
+	binary.BigEndian.PutUint64(a[8:], uint64(main)|uint64(ext)<<bitsMaxWidth)
+	return append(dst, a[16-b.ByteWidth():]...), nil
+}
+
+// FromCompact returns the ID of the compact form written by Builder.AppendNext
+func FromCompact(buf []byte) (*ID, error) {
+	if len(buf) == 0 || len(buf) > 16 {
+		return nil, ErrBinaryLength
+	}
+	var a [16]byte
+	copy(a[16-len(buf):], buf)
+	return FromUint128([2]uint64{
+		binary.BigEndian.Uint64(a[:8]),
+		binary.BigEndian.Uint64(a[8:]),
+	})
+}
+
+// NextBatchInto fills dst with the next IDs under one lock acquisition,
+// which avoids allocating an ID per call, returns the number of IDs filled,
+// which is less than len(dst) if the builder fails.
+func (b *Builder) NextBatchInto(dst []ID, argv ...int64) int {
+	if !b.isReady() {
+		return 0
+	}
+	b.Lock()
+	defer b.Unlock()
+	for i := range dst {
+		main, ext, err := b.next(argv, nil)
+		if err == nil {
+			err = b.emit(main, ext)
+		}
+		if err != nil {
+			return i
+		}
+		dst[i] = ID{
+			Main:   main,
+			Ext:    ext,
+			Signed: b.options.Signed,
+		}
+	}
+	return len(dst)
+}
+
+// NextBatch returns the next n IDs under one lock acquisition. It reads the clock
+// once per tick rather than per ID: the IDs take the remaining sequences of the
+// tick, and it spins to the next tick only when the sequence is exhausted.
+// Fewer than n IDs are returned if the builder fails.
+func (b *Builder) NextBatch(n int, argv ...int64) []ID {
+	if !b.isReady() || n <= 0 {
 		return nil
 	}
+	dst := make([]ID, n)
 	b.Lock()
 	defer b.Unlock()
-	// ready
+	// the shared sequence, the reserved blocks and the lock-free claims need every tick
+	fast := b.shared == nil && len(b.reserved) == 0 && !b.lockFree
+	for i := range dst {
+		seq := (b.sequence + 1) & b.sequenceMask
+		if !fast || i == 0 || seq == 0 {
+			var err error
+			if seq, err = b.tick(); err != nil {
+				return dst[:i]
+			}
+		} else {
+			b.sequence = seq
+		}
+		main, ext, err := b.compose(b.now, seq, 0, -1, argv, nil)
+		if err != nil || b.emit(main, ext) != nil {
+			return dst[:i]
+		}
+		dst[i] = ID{
+			Main:   main,
+			Ext:    ext,
+			Signed: b.options.Signed,
+		}
+	}
+	return dst
+}
+
+// next generates the main and extension parts of the next ID,
+// the caller MUST hold the lock.
+func (b *Builder) next(argv []int64, named map[string]int64) (main, ext int64, err error) {
+	seq, err := b.tick()
+	if err != nil {
+		return 0, 0, err
+	}
+	return b.compose(b.now, seq, 0, -1, argv, named)
+}
+
+// compose assembles the segments values at the time tr with the sequence seq,
+// flag is the value of the Backfilled segments, kind is the value of the GroupKind
+// segments, negative means their fallback values.
+func (b *Builder) compose(tr *time.Time, seq, flag, kind int64, argv []int64, named map[string]int64) (main, ext int64, err error) {
+	if n := atomic.LoadUint64(&b.nearing); n != 0 && tr.UnixMilli() >= int64(n) {
+		b.nearExhaustion(tr)
+	}
 	var shift, width byte
-	var main, ext int64
 	var vs []int64
-	seq := b.tick()
-	tr := b.now
+	var origins []Origin
 	a := 0
 	for _, segment := range b.options.segments {
 		f := segment.Value
 		mask := segment.mask
-		f = b.val(&segment, tr, seq, argv, a, f)
+		o := OriginGenerated
+		if segment.Source == Backfilled {
+			f = flag
+		} else if segment.Source == GroupKind {
+			if kind >= 0 {
+				f = kind
+			} else {
+				o = OriginStatic
+			}
+		} else if segment.Source == RandomID {
+			if f, err = b.random(segment.Width); err != nil {
+				return 0, 0, err
+			}
+		} else {
+			f, o = b.val(&segment, tr, seq, argv, named, a, f)
+		}
 		if b.Debug {
 			vs = append(vs, f)
+			origins = append(origins, o)
 		}
 		if segment.Source == Args {
 			a++
@@ -344,28 +882,29 @@ func (b *Builder) Next(argv ...int64) (id *ID) {
 		}
 		shift = width % bitsMaxWidth
 	}
-	id = &ID{
-		Main:   main,
-		Ext:    ext,
-		Signed: b.options.Signed,
-	}
 	if b.Debug {
-		epoch := b.options.EpochMS
-		if epoch < 0 {
-			epoch = 0
+		values := make(map[string]int64, len(vs))
+		for i, l := range labels(b.options.segments) {
+			values[l] = vs[i]
 		}
 		b.info = &DebugInfo{
 			Sequence: seq,
 			Raw:      vs,
+			Origins:  origins,
+			Values:   values,
 			Now:      *tr,
 		}
 	}
-	return id
+	return
 }
 
-// NextString returns the next ID as a string.
+// NextString returns the next ID as a string, or "" if the builder is not ready
+// or fails, use TryNext to obtain the reason.
 func (b *Builder) NextString(argv ...int64) string {
-	i := b.Next(argv...)
+	i, err := b.TryNext(argv...)
+	if err != nil {
+		return ""
+	}
 	e := b.Encoder
 	if e == nil {
 		return i.String()
@@ -373,12 +912,12 @@ func (b *Builder) NextString(argv ...int64) string {
 	return e.Encode(i)
 }
 
-// ResetEpoch resets the epoch.
+// ResetEpoch resets the epoch, 0 means 1970-01-01 like EpochUnix.
 func (b *Builder) ResetEpoch(epoch int64) error {
 	if epoch < 0 {
 		return invalidOption("EpochMS", errorEpochTooSmall)
 	}
-	now := time.Now().UnixNano() / nsPerMilliseconds
+	now := b.timeNow().UnixMilli()
 	if epoch > now {
 		return invalidOption("EpochMS", errorEpochTooLarge)
 	}
@@ -389,7 +928,14 @@ func (b *Builder) ResetEpoch(epoch int64) error {
 	if now-epoch < min {
 		return invalidOption("EpochMS", errorTooPoor)
 	}
-	b.options.EpochMS = epoch
+	if epoch == 0 {
+		// a zero EpochMS is the default one, see epoch
+		epoch = EpochUnix
+	}
+	b.Lock()
+	defer b.Unlock()
+	atomic.StoreInt64(&b.options.EpochMS, epoch)
+	b.watchEpoch()
 	return nil
 }
 
@@ -409,7 +955,8 @@ var checklist = []struct {
 		}
 		return false
 	}, "EpochMS", errorEpochTooSmall},
-	{func(opt *Options) bool { return opt.EpochMS > time.Now().UnixNano()/nsPerMilliseconds }, "EpochMS", errorEpochTooLarge},
+	{func(opt *Options) bool { return opt.EpochMS < 0 && opt.EpochMS != EpochUnix }, "EpochMS", errorEpochTooSmall},
+	{func(opt *Options) bool { return opt.EpochMS > opt.now().UnixMilli() }, "EpochMS", errorEpochTooLarge},
 	{func(opt *Options) bool { return len(opt.segments) <= 0 }, "Segments", errorSegmentsEmpty},
 	{func(opt *Options) bool { return len(opt.segments) > SegmentsLimit }, "Segments", errorSegmentsTooMany},
 	{func(opt *Options) bool {
@@ -417,7 +964,7 @@ var checklist = []struct {
 		if opt.ReservedDays > min {
 			min = opt.ReservedDays
 		}
-		return time.Now().UnixNano()/nsPerMilliseconds-opt.EpochMS < min
+		return opt.now().UnixMilli()-opt.EpochMS < min
 	}, "EpochMS", errorTooPoor},
 }
 
@@ -434,15 +981,19 @@ func checkSegment(segment *Bits, required *map[DataSourceType]int) (v int64, err
 	case RandomID:
 		v = 0
 	case DateTime:
-		switch segment.Index {
-		case int(TimestampNanoseconds),
-			int(TimestampMicroseconds),
-			int(TimestampMilliseconds),
-			int(TimestampSeconds):
+		if DateTimeType(segment.Index).timestamp() {
 			delete(*required, DateTime)
 		}
 		v = 0
 	case Provider:
+	case Backfilled:
+		v = 0
+	case GroupKind:
+	case ReservedBits:
+		if v != 0 {
+			err = invalidOption("Segments", errorReservedValue)
+			return
+		}
 	default:
 		err = invalidOption("Segments", errorInvalidType)
 		return
@@ -450,14 +1001,61 @@ func checkSegment(segment *Bits, required *map[DataSourceType]int) (v int64, err
 	return v, nil
 }
 
+// negotiate validates the widths of the segments of the BoundedProvider,
+// and widens them if AutoSize is set.
+func negotiate(opt *Options) error {
+	for i, segment := range opt.segments {
+		if segment.Source != Provider {
+			continue
+		}
+		d, _ := opt.Environment.provider(segment.Key)
+		p, found := d.(BoundedProvider)
+		if !found {
+			continue
+		}
+		w := byte(0)
+		if max := p.MaxValue(); max > 0 {
+			w = byte(bits.Len64(uint64(max)))
+		}
+		if w <= segment.Width {
+			continue
+		}
+		if !opt.AutoSize {
+			return invalidOption("Segments", errorWidthTooSmall, segment.Key)
+		}
+		opt.segments[i].Width = w
+	}
+	return nil
+}
+
+// measure validates the widths of the segments and sets their masks,
+// returns the total width.
+func measure(segments []Bits) (t byte, err error) {
+	for index, segment := range segments {
+		w := segment.Width
+		if w < 1 || w > bitsMaxWidth {
+			return 0, invalidOption("Segments", errorWidthInvalid)
+		}
+		if t+w > bitsMaxWidth*2 {
+			return 0, invalidOption("Segments", errorWidthTooLarge)
+		}
+		t += w
+		segments[index].mask = int64(-1 ^ (-1 << w))
+	}
+	return t, nil
+}
+
 // Make returns a new Builder instance.
+// The builder holds a deep copy of opt, later changes to opt
+// (or to the segments and settings it shares) do not affect it.
 func Make(opt Options) (m *Builder, err error) {
+	opt = opt.clone()
 	for _, rule := range checklist {
 		if rule.test(&opt) {
 			return nil, invalidOption(rule.segment, rule.reason)
 		}
 	}
-	if opt.EpochMS <= 0 {
+	if opt.EpochMS == 0 {
 		opt.EpochMS = EpochMS
 	}
 	// Options MUST include DateTime segment AND SequenceID segment.
@@ -465,21 +1063,21 @@ func Make(opt Options) (m *Builder, err error) {
 		DateTime:   7,
 		SequenceID: 0,
 	}
+	if err = negotiate(&opt); err != nil {
+		return nil, err
+	}
+	t, err := measure(opt.segments)
+	if err != nil {
+		return nil, err
+	}
+	if opt.Max63Bits && t > bitsMaxWidth {
+		return nil, invalidOption("Segments", errorWidthOver63)
+	}
 	sequenceWidth := byte(0)
-	t := byte(0)
-	for index, segment := range opt.segments {
+	backfill := false
+	for _, segment := range opt.segments {
 		w := segment.Width
-		if w < 1 || w > bitsMaxWidth {
-			err = invalidOption("Segments", errorWidthInvalid)
-			return
-		}
-		if t+w > bitsMaxWidth*2 {
-			err = invalidOption("Segments", errorWidthTooLarge)
-			return
-		}
-		t += w
-		mask := int64(-1 ^ (-1 << w))
-		opt.segments[index].mask = mask
+		mask := segment.mask
 		v, e := checkSegment(&segment, &required)
 		if e != nil {
 			return nil, e
@@ -491,20 +1089,69 @@ func Make(opt Options) (m *Builder, err error) {
 		if segment.Source == SequenceID && w > sequenceWidth {
 			sequenceWidth = w
 		}
+		if segment.Source == Backfilled {
+			backfill = true
+		}
 	}
 	if len(required) > 0 {
 		err = invalidOption("Segments", errorSegmentMiss)
 		return
 	}
-	if sequenceWidth < 8 {
+	var warnings []*OptionsError
+	if opt.Duplicates != DuplicateAllow {
+		warnings = duplicates(opt.segments)
+		if opt.Duplicates == DuplicateReject && len(warnings) > 0 {
+			return nil, warnings[0]
+		}
+	}
+	if sequenceWidth < 8 && !opt.LowVolume {
 		err = invalidOption("Sequence.Width", errorTooSlow)
 		return
 	}
+	quantum := int64(1)
+	if opt.LowVolume {
+		if opt.Shared != nil {
+			return nil, invalidOption("Shared", errorLowVolumeShared)
+		}
+		if u := timestampUnit(opt.segments); u > time.Millisecond {
+			quantum = int64(u / time.Millisecond)
+		}
+	}
+	var granularity time.Duration
+	if opt.ClockCheck {
+		var w *OptionsError
+		if granularity, w = checkClock(opt.segments); w != nil {
+			warnings = append(warnings, w)
+		}
+	}
 	m = &Builder{
-		options:      &opt,
-		sequenceMask: -1 ^ (-1 << sequenceWidth),
-		ready:        true,
+		options:       &opt,
+		sequenceMask:  -1 ^ (-1 << sequenceWidth),
+		width:         t,
+		backfill:      backfill,
+		shared:        opt.Shared,
+		warnings:      warnings,
+		granularity:   granularity,
+		lockFree:      lockFree(&opt, sequenceWidth),
+		clockEpoch:    epoch(opt.EpochMS),
+		blocks:        opt.SequenceBlock > 0 && opt.Shared == nil && !opt.LowVolume && !lockFree(&opt, sequenceWidth),
+		quantum:       quantum,
+		sequenceWidth: sequenceWidth,
+		ready:         true,
+	}
+	if env := opt.Environment; env != nil && env.Clock != nil {
+		m.nowFunc = env.Clock.Now
+	} else if opt.Monotonic {
+		m.nowFunc = monotonic()
+	}
+	m.journal = opt.Journal
+	if opt.RandPool != nil {
+		m.rand = newRandPool(*opt.RandPool, opt.Environment.random())
+	}
+	if opt.sink != nil {
+		m.startSink(opt.sink)
 	}
+	m.watchEpoch()
 	return
 }
 
@@ -1,9 +1,11 @@
 package tsid
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"testing"
@@ -38,6 +40,42 @@ func init() {
 	Register("my_data_source", dp)
 }
 
+type testBoundedSource struct {
+	testDataSource
+	max int64
+}
+
+func (d *testBoundedSource) MaxValue() int64 {
+	return d.max
+}
+
+func TestBoundedProvider(t *testing.T) {
+	Register("test_bounded", &testBoundedSource{max: 40})
+	opt := Options{
+		segments: []Bits{
+			Sequence(12),
+			Data(5, "test_bounded", 0),
+			Timestamp(41, TimestampMilliseconds),
+		},
+	}
+	r := invalidOption("Segments", errorWidthTooSmall)
+	if _, e := Make(opt); e == nil || !r.SameAs(e) {
+		t.Errorf("want: error(%s), got: %v", r, e)
+	}
+	opt.AutoSize = true
+	b, e := Make(opt)
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	if w := b.options.segments[1].Width; w != 6 {
+		t.Errorf("want: width 6, got: %d", w)
+	}
+	if opt.segments[1].Width != 5 {
+		t.Error("Make want: the options unchanged, got: changed")
+	}
+}
+
 func TestExts(t *testing.T) {
 	_ = os.Setenv(envTest, "1")
 	defer func(key string) {
@@ -130,6 +168,8 @@ func TestOptionsError(t *testing.T) {
 	h, n := int64(10), int64(10)
 	d := Default()
 	d.NewEpoch(now + 5*msPerMinute)
+	negative := Default()
+	negative.EpochMS = -5000
 	tests := []struct {
 		name string
 		opt  *Options
@@ -138,6 +178,8 @@ func TestOptionsError(t *testing.T) {
 		// {"EpochMS.TooSmall", Default(h, n).NewEpoch(-1), invalidOption("EpochMS", errorEpochTooSmall)},
 		{"EpochMS.TooLarge", &d,
 			invalidOption("EpochMS", errorEpochTooLarge)},
+		{"EpochMS.Negative", &negative,
+			invalidOption("EpochMS", errorEpochTooSmall)},
 		// {"EpochMS.TooPoor", Config(h, n).NewEpoch(now + 7*msPerDay), invalidOption("EpochMS", errorTooPoor)},
 		{"Segments.Empty", Config(h, n),
 			invalidOption("Segments", errorSegmentsEmpty)},
@@ -158,6 +200,10 @@ func TestOptionsError(t *testing.T) {
 			Bits{Source: 0, Width: 50, Key: "Second"},
 			Bits{Source: 0, Width: 60, Key: "Error"}),
 			invalidOption("Segments", errorWidthTooLarge)},
+		{"Segments.Max63Bits", &Options{
+			Max63Bits: true,
+			segments:  Shuffle().segments,
+		}, invalidOption("Segments", errorWidthOver63)},
 		{"Segments.Sequence.Width", Config(h, n,
 			Host(6, 0),
 			Node(4, 8),
@@ -218,7 +264,13 @@ func TestMake(t *testing.T) {
 		}
 		m.NextString()
 	}
-	m.options.Add(Random(63)).Patch(1, "Node", 9, 4)
+	opt := m.Options()
+	opt.Add(Random(63)).Patch(1, "Node", 9, 4)
+	if len(m.options.segments) == len(opt.segments) {
+		t.Fatal("Builder.Options want: a snapshot, got: shared options")
+		return
+	}
+	m, _ = Make(opt)
 	for i := 0; i < 20; i++ {
 		id := m.Next()
 		no := en.Encode(id)
@@ -234,6 +286,211 @@ func TestMake(t *testing.T) {
 	}
 }
 
+func TestMakeCopy(t *testing.T) {
+	opt := Default()
+	opt.Set("Node", 1)
+	b, e := Make(opt)
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	opt.Set("Node", 2).Patch(0, "Changed", 0, 1)
+	s := b.Options()
+	if s.settings["Node"] != 1 || s.segments[0].Key == "Changed" {
+		t.Error("Make want: a deep copy of options, got: shared options")
+	}
+	s.Set("Node", 3)
+	if b.options.settings["Node"] != 1 {
+		t.Error("Builder.Options want: a snapshot, got: shared options")
+	}
+}
+
+func TestNextBytes(t *testing.T) {
+	if _, e := (&Builder{}).NextBytes(make([]byte, 16)); e != ErrNotReady {
+		t.Errorf("want: error(%s), got: %v", ErrNotReady, e)
+	}
+	tests := []struct {
+		name string
+		opt  Options
+		size int
+	}{
+		{"Default", Default(), 8},
+		{"Shuffle", Shuffle(), 16},
+	}
+	for _, o := range tests {
+		t.Run(o.name, func(t *testing.T) {
+			b, e := Make(o.opt)
+			if e != nil {
+				t.Fatalf("want: a builder instance, got: error %s", e)
+				return
+			}
+			if _, e = b.NextBytes(make([]byte, o.size-1)); e != io.ErrShortBuffer {
+				t.Errorf("want: error(%s), got: %v", io.ErrShortBuffer, e)
+			}
+			b.Debug = true
+			buf := make([]byte, 32)
+			n, e := b.NextBytes(buf)
+			if e != nil || n != o.size {
+				t.Fatalf("want: %d bytes, got: %d bytes, error %v", o.size, n, e)
+				return
+			}
+			if n == 16 && binary.BigEndian.Uint64(buf) == 0 {
+				t.Error("NextBytes want: extension part, got: zero")
+			}
+			if binary.BigEndian.Uint64(buf[n-8:]) == 0 {
+				t.Error("NextBytes want: main part, got: zero")
+			}
+		})
+	}
+}
+
+func TestAppendNext(t *testing.T) {
+	if _, e := (&Builder{}).AppendNext(nil); e != ErrNotReady {
+		t.Errorf("want: error(%s), got: %v", ErrNotReady, e)
+	}
+	for _, o := range []struct {
+		opt  *Options
+		size int
+	}{
+		{Segments(Sequence(12), Timestamp(41, TimestampMilliseconds)), 7},
+		{Segments(Sequence(12), Random(40), Timestamp(41, TimestampMilliseconds)), 12},
+	} {
+		b, e := Make(*o.opt)
+		if e != nil {
+			t.Fatalf("want: a builder instance, got: error %s", e)
+			return
+		}
+		buf := []byte{0xff}
+		for i := 0; i < 10; i++ {
+			buf, e = b.AppendNext(buf[:1])
+			if e != nil || b.ByteWidth() != o.size || len(buf) != o.size+1 {
+				t.Fatalf("want: %d bytes, got: %d, error %v", o.size, len(buf)-1, e)
+				return
+			}
+			id, e := FromCompact(buf[1:])
+			if e != nil {
+				t.Fatalf("want: an ID, got: error %s", e)
+				return
+			}
+			if e = b.Decoder().Validate(id); e != nil {
+				t.Errorf("want: valid ID, got: error %s", e)
+			}
+		}
+	}
+	if _, e := FromCompact(make([]byte, 17)); e != ErrBinaryLength {
+		t.Errorf("want: error(%s), got: %v", ErrBinaryLength, e)
+	}
+}
+
+func TestNextBatchInto(t *testing.T) {
+	if n := (&Builder{}).NextBatchInto(make([]ID, 10)); n != 0 {
+		t.Errorf("want: 0 IDs, got: %d", n)
+	}
+	b, e := Make(Default())
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	dst := make([]ID, 5000)
+	if n := b.NextBatchInto(dst); n != len(dst) {
+		t.Fatalf("want: %d IDs, got: %d", len(dst), n)
+		return
+	}
+	for i := 1; i < len(dst); i++ {
+		if dst[i].Main <= dst[i-1].Main {
+			t.Fatal("the IDs generated by NextBatchInto are not incremental")
+			return
+		}
+	}
+}
+
+func BenchmarkNextBatchInto(b *testing.B) {
+	c, e := Make(Default())
+	if e != nil {
+		b.Fatal(e)
+		return
+	}
+	dst := make([]ID, 1000)
+	for i := 0; i < b.N; i++ {
+		c.NextBatchInto(dst)
+	}
+}
+
+func TestNextBatch(t *testing.T) {
+	if ids := (&Builder{}).NextBatch(10); len(ids) != 0 {
+		t.Errorf("want: no IDs, got: %d", len(ids))
+	}
+	b, e := Make(Default())
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	ids := b.NextBatch(10000)
+	ids = append(ids, *b.Next())
+	if len(ids) != 10001 {
+		t.Fatalf("want: 10001 IDs, got: %d", len(ids))
+		return
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i].Main <= ids[i-1].Main {
+			t.Fatal("the IDs generated by NextBatch are not incremental")
+			return
+		}
+	}
+}
+
+func BenchmarkNextBatch(b *testing.B) {
+	c, e := Make(Default())
+	if e != nil {
+		b.Fatal(e)
+		return
+	}
+	for i := 0; i < b.N; i++ {
+		c.NextBatch(1000)
+	}
+}
+
+func TestIDCompare(t *testing.T) {
+	tests := []struct {
+		a, b ID
+		want int
+	}{
+		{ID{Main: 1}, ID{Main: 2}, -1},
+		{ID{Main: 2}, ID{Main: 1}, 1},
+		{ID{Main: 9, Ext: 1}, ID{Main: 1, Ext: 2}, -1},
+		{ID{Main: 1, Ext: 2}, ID{Main: 9, Ext: 1}, 1},
+		{ID{Main: 1, Ext: 1}, ID{Main: 1, Ext: 1, Signed: true}, 0},
+	}
+	for _, o := range tests {
+		if got := o.a.Compare(&o.b); got != o.want {
+			t.Errorf("%s.Compare(%s) want: %d, got: %d", &o.a, &o.b, o.want, got)
+		}
+		if got := o.a.Less(&o.b); got != (o.want < 0) {
+			t.Errorf("%s.Less(%s) want: %t, got: %t", &o.a, &o.b, o.want < 0, got)
+		}
+		x, y := o.a.Array16(), o.b.Array16()
+		if bytes.Compare(x[:], y[:]) != o.want {
+			t.Errorf("ID.Array16 want: ordered as ID.Compare, got: unordered")
+		}
+	}
+}
+
+func TestIDKey(t *testing.T) {
+	b, _ := Make(Default())
+	seen := map[[2]int64]bool{}
+	for i := 0; i < 100; i++ {
+		id := b.Next()
+		seen[id.Key()] = true
+		if got := FromKey(id.Key()); got.Main != id.Main || got.Ext != id.Ext {
+			t.Errorf("want: %s, got: %s", id, got)
+		}
+	}
+	a, c := &ID{Main: 5, Ext: 1}, &ID{Main: 5, Ext: 1}
+	if len(seen) != 100 || a.Key() != c.Key() {
+		t.Errorf("want: 100 distinct keys, got: %d", len(seen))
+	}
+}
+
 func TestID(t *testing.T) {
 	if DataSourceType(100).String() != "Undefined" {
 		t.Error("DataSourceType.String invalid")
@@ -258,6 +515,31 @@ func TestID(t *testing.T) {
 	if !i2.Equal(id) {
 		t.Error("id.Equal not expected")
 	}
+	d0 := &ID{Main: id.Main}
+	a := id.Array16()
+	if !FromArray16(a).Equal(&ID{Main: id.Main, Ext: id.Ext}) {
+		t.Error("FromArray16 not expected")
+	}
+	for _, v := range []*ID{id, d0} {
+		buf, e := v.MarshalBinary()
+		var u ID
+		if e != nil || u.UnmarshalBinary(buf) != nil || !u.Equal(&ID{Main: v.Main, Ext: v.Ext}) {
+			t.Errorf("want: %s, got: %s, error %v", v, &u, e)
+		}
+	}
+	if e := (&ID{}).UnmarshalBinary(make([]byte, 9)); e != ErrBinaryLength {
+		t.Errorf("want: error(%s), got: %v", ErrBinaryLength, e)
+	}
+	d, _ := Make(Default())
+	a, n := d.Next().Array16(), d.Next().Array16()
+	if bytes.Compare(a[:], n[:]) >= 0 {
+		t.Error("ID.Array16 want: ordered as the IDs, got: unordered")
+	}
+	x, _ := d.Next().MarshalBinary()
+	y, _ := d.Next().MarshalBinary()
+	if len(x) != 8 || bytes.Compare(x, y) >= 0 {
+		t.Error("ID.MarshalBinary want: ordered as the IDs, got: unordered")
+	}
 }
 
 func TestSeqIDExt(t *testing.T) {
@@ -362,9 +644,13 @@ func TestAll(t *testing.T) {
 				t.Error("Predefined[", n, "]", " want: valid ID, got zero")
 				continue
 			}
-			rs := fmt.Sprintf("%063b", id.Main)
-			if id.Ext > 0 {
-				rs = fmt.Sprintf("%063b", id.Ext) + rs
+			w := int(b.width)
+			if w > 63 {
+				w = 63
+			}
+			rs := fmt.Sprintf("%0*b", w, id.Main)
+			if w := int(b.width) - 63; w > 0 {
+				rs = fmt.Sprintf("%0*b", w, id.Ext) + rs
 			}
 			info := b.DebugInfo()
 			cs := ""
@@ -391,3 +677,162 @@ func TestAll(t *testing.T) {
 	}
 	Play(count)
 }
+
+func TestDebugOrigins(t *testing.T) {
+	t.Setenv("TSID_ORIGIN", "5")
+	opt := Config(9, 3,
+		Sequence(12),
+		Fixed(2, 1),
+		Env(4, "TSID_ORIGIN", 0),
+		Env(4, "TSID_ORIGIN_MISSING", 2),
+		Host(6, 0),
+		Arg(4, 0, 7),
+		Data(4, "not_registered", 1),
+		Timestamp(41, TimestampMilliseconds),
+	)
+	b, e := Make(*opt)
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	b.Debug = true
+	b.Next()
+	want := []Origin{
+		OriginGenerated,
+		OriginStatic,
+		OriginSource,
+		OriginFallback,
+		OriginSource,
+		OriginFallback,
+		OriginFallback,
+		OriginGenerated,
+	}
+	got := b.DebugInfo().Origins
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("want: %v, got: %v", want, got)
+	}
+	b.Next(4)
+	if o := b.DebugInfo().Origins[5]; o != OriginSource {
+		t.Errorf("want: %s, got: %s", OriginSource, o)
+	}
+}
+
+func TestNextWith(t *testing.T) {
+	opt := *Config(1, 2,
+		Sequence(10),
+		NamedArg(8, "tenant", 3),
+		NamedArg(4, "region", 5),
+		Arg(4, 2, 7),
+		Timestamp(41, TimestampMilliseconds))
+	b, e := Make(opt)
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	id, e := b.NextWith(map[string]int64{"region": 9, "tenant": 42})
+	vs, _ := b.Decoder().Decompose(id)
+	if e != nil || vs[1] != 42 || vs[2] != 9 || vs[3] != 7 {
+		t.Errorf("want: [_ 42 9 7 _], got: %v, error %v", vs, e)
+	}
+	id, _ = b.NextWith(nil)
+	if vs, _ = b.Decoder().Decompose(id); vs[1] != 3 || vs[2] != 5 {
+		t.Errorf("want: fallback values, got: %v", vs)
+	}
+	id, _ = b.TryNext(1, 2, 4)
+	if vs, _ = b.Decoder().Decompose(id); vs[1] != 1 || vs[2] != 2 || vs[3] != 4 {
+		t.Errorf("want: positional values, got: %v", vs)
+	}
+	if _, e = b.NextWith(map[string]int64{"tennant": 42}); e != ErrUnknownArg {
+		t.Errorf("want: %s, got: %v", ErrUnknownArg, e)
+	}
+}
+
+func TestEditSegments(t *testing.T) {
+	opt, _ := Predefined("default")
+	n := len(opt.segments)
+	o := opt.clone()
+	o.Replace(1, Data(4, "my_data_source", 0)).InsertAt(0, Fixed(2, 1)).RemoveAt(n)
+	if len(o.segments) != n || o.segments[0].Source != Static || o.segments[2].Source != Provider || o.segments[2].mask != 15 {
+		t.Errorf("want: the edited segments, got: %v", o.segments)
+	}
+	if opt.segments[1].Source == Provider || len(opt.segments) != n {
+		t.Errorf("want: the original unchanged, got: %v", opt.segments)
+	}
+	o.RemoveAt(-1).RemoveAt(n).Replace(n, Fixed(1, 0)).InsertAt(n+1, Fixed(1, 0))
+	if len(o.segments) != n {
+		t.Errorf("want: out of range ignored, got: %v", o.segments)
+	}
+	if o.InsertAt(n, Fixed(1, 0)); o.segments[n].Source != Static {
+		t.Errorf("want: appended, got: %v", o.segments)
+	}
+}
+
+func TestClonePredefined(t *testing.T) {
+	a := Default()
+	a.Patch(0, "patched", 1, 7)
+	a.Set("Host", 63)
+	if b := Default(); b.segments[0].Key == "patched" || b.settings["Host"] == 63 {
+		t.Errorf("want: the preset unchanged, got: %v", b.segments[0])
+	}
+	p, _ := Predefined("snowflake")
+	p.segments[0].Width = 1
+	if q, _ := Predefined("default"); q.segments[0].Width == 1 {
+		t.Error("want: the preset unchanged, got: shared segments")
+	}
+	c := a.Clone()
+	c.segments[0].Value = 9
+	if a.segments[0].Value == 9 {
+		t.Error("want: a deep copy, got: shared segments")
+	}
+}
+
+func TestLowVolume(t *testing.T) {
+	opt, found := Predefined("short")
+	if !found {
+		t.Fatal("want: short32, got: not found")
+		return
+	}
+	start := time.UnixMilli(EpochMS).Add(1000 * time.Hour)
+	c := NewManualClock(start, time.Millisecond)
+	opt.Environment = &Environment{Clock: c}
+	opt.OnExhausted = ExhaustionError
+	b, e := Make(opt)
+	if e != nil {
+		t.Fatalf("want: builder, got: error %s", e)
+		return
+	}
+	if b.width != 32 || b.LockFree() {
+		t.Errorf("want: 32 bits locked, got: %d bits, lock-free %v", b.width, b.LockFree())
+	}
+	seen := map[int64]bool{}
+	for i := 0; i < 128; i++ {
+		id, err := b.TryNext()
+		if err != nil || seen[id.Main] || id.Main&127 != int64(i) {
+			t.Fatalf("want: sequence %d, got: %v, error %v", i, id, err)
+			return
+		}
+		seen[id.Main] = true
+		if ts, _ := b.TimeOf(id); !ts.Equal(start) {
+			t.Errorf("want: %s, got: %s", start, ts)
+		}
+	}
+	if _, err := b.TryNext(); err != ErrSequenceExhausted {
+		t.Errorf("want: %s, got: %v", ErrSequenceExhausted, err)
+	}
+	c.Set(start.Add(time.Minute))
+	if id, err := b.TryNext(); err != nil || id.Main&127 != 0 || seen[id.Main] {
+		t.Errorf("want: sequence 0 of the next minute, got: %v, error %v", id, err)
+	}
+
+	opt.LowVolume = false
+	if _, e = Make(opt); e == nil {
+		t.Error("want: error of the 7 bits sequence, got: nothing")
+	}
+	if opt, found = Predefined("short48"); !found {
+		t.Fatal("want: short48, got: not found")
+		return
+	}
+	if b, e = Make(opt); e != nil || b.width != 48 {
+		t.Errorf("want: 48 bits, got: error %v", e)
+	}
+}
@@ -0,0 +1,85 @@
+package tsid
+
+import (
+	"math"
+	"time"
+)
+
+// daysPerYear is the mean length of the Gregorian year
+const daysPerYear = 365.2425
+
+// CapacityReport is the capacity of a layout, see Options.Capacity
+type CapacityReport struct {
+	// Width is the total width of the layout
+	Width int `json:"width"`
+	// Tick is the unit of the timestamp, 0 if the layout has no timestamp
+	Tick time.Duration `json:"tick_ns"`
+	// PerTick is the maximum number of IDs per tick of the timestamp
+	PerTick int64 `json:"per_tick"`
+	// Nodes is the number of values of every node segment (Settings, OS, Args
+	// and Provider) by the labels of the segments
+	Nodes map[string]int64 `json:"nodes,omitempty"`
+	// Lifetime is the number of years from the epoch until the timestamp
+	// overflows, -1 if the layout has no timestamp
+	Lifetime float64 `json:"lifetime_years"`
+	// Remaining is the number of years from now until the timestamp overflows,
+	// negative if it has overflowed, -1 if the layout has no timestamp
+	Remaining float64 `json:"remaining_years"`
+}
+
+// TotalWidth returns the total width of the segments
+func (o *Options) TotalWidth() int {
+	t := 0
+	for _, segment := range o.segments {
+		t += int(segment.Width)
+	}
+	return t
+}
+
+// Capacity returns the capacity of the layout: the IDs per tick, the values of
+// the node segments and the years until the timestamp overflows since EpochMS.
+func (o *Options) Capacity() CapacityReport {
+	r := CapacityReport{
+		Width:     o.TotalWidth(),
+		Lifetime:  -1,
+		Remaining: -1,
+	}
+	names := labels(o.segments)
+	for i, segment := range o.segments {
+		switch segment.Source {
+		case SequenceID:
+			if n := values(segment.Width); n > r.PerTick {
+				r.PerTick = n
+			}
+		case Settings, OS, Args, Provider:
+			if r.Nodes == nil {
+				r.Nodes = make(map[string]int64)
+			}
+			r.Nodes[names[i]] = values(segment.Width)
+		case DateTime:
+			u := timestampUnit([]Bits{segment})
+			if u == 0 || r.Tick != 0 {
+				break
+			}
+			r.Tick = u
+			span := math.Ldexp(1, int(segment.Width)) * u.Seconds()
+			start := time.UnixMilli(epoch(o.EpochMS))
+			r.Lifetime = years(span)
+			r.Remaining = years(span - o.now().Sub(start).Seconds())
+		}
+	}
+	return r
+}
+
+// values returns the number of values of w bits, capped at math.MaxInt64
+func values(w byte) int64 {
+	if w >= 63 {
+		return math.MaxInt64
+	}
+	return 1 << w
+}
+
+// years converts the seconds to the years
+func years(seconds float64) float64 {
+	return seconds / (daysPerYear * 24 * 3600)
+}
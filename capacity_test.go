@@ -0,0 +1,43 @@
+package tsid
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCapacity(t *testing.T) {
+	o := Default()
+	if w := o.TotalWidth(); w != 63 {
+		t.Errorf("want: 63, got: %d", w)
+	}
+	c := o.Capacity()
+	if c.Width != 63 || c.PerTick != 1<<12 || c.Tick != time.Millisecond {
+		t.Errorf("want: 4096 IDs per 1ms, got: %+v", c)
+	}
+	if len(c.Nodes) == 0 {
+		t.Errorf("want: node segments, got: %+v", c.Nodes)
+	}
+	if c.Lifetime < 69 || c.Lifetime > 70 || c.Remaining >= c.Lifetime {
+		t.Errorf("want: 69.7 years, got: %f (%f remaining)", c.Lifetime, c.Remaining)
+	}
+
+	p := Segments(Sequence(12), Host(5, 1), Timestamp(10, TimestampSeconds))
+	p.EpochMS = 1000
+	p.Environment = &Environment{Clock: NewManualClock(time.Unix(513, 0), 0)}
+	c = p.Capacity()
+	if n := c.Nodes["Host"]; n != 32 {
+		t.Errorf("want: 32 hosts, got: %d", n)
+	}
+	if want := years(1024); math.Abs(c.Lifetime-want) > 1e-12 {
+		t.Errorf("want: %g, got: %g", want, c.Lifetime)
+	}
+	if want := years(512); math.Abs(c.Remaining-want) > 1e-12 {
+		t.Errorf("want: %g, got: %g", want, c.Remaining)
+	}
+
+	c = Segments(Sequence(12), Random(20)).Capacity()
+	if c.Tick != 0 || c.Lifetime != -1 || c.Remaining != -1 {
+		t.Errorf("want: no timestamp, got: %+v", c)
+	}
+}
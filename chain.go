@@ -0,0 +1,137 @@
+package tsid
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrChainInvalid indicates that the stages of a chain encoder are not
+// ordered as ID stages, one Render stage, then string stages.
+var ErrChainInvalid = errors.New("tsid: the chain requires exactly one Render stage, preceded by ID stages and followed by string stages")
+
+// ErrStageInvalid indicates that a stage of the chain lacks its encoder or
+// functions, e.g. the zero Stage, which is not made by Transform, Render or Wrap.
+var ErrStageInvalid = errors.New("tsid: the stage requires its encoder or both functions")
+
+type stageKind int
+
+const (
+	stageID stageKind = iota
+	stageRender
+	stageString
+)
+
+// Stage is a step of the encoder chain, made by Transform, Render or Wrap
+type Stage struct {
+	// Name is used to identify the stage in errors
+	Name string
+
+	kind     stageKind
+	encoder  Encoder
+	encodeID func(*ID) *ID
+	decodeID func(*ID) (*ID, error)
+	encodeS  func(string) string
+	decodeS  func(string) (string, error)
+}
+
+// Transform makes a stage which transforms the ID before rendering, e.g. obfuscation
+func Transform(name string, encode func(*ID) *ID, decode func(*ID) (*ID, error)) Stage {
+	return Stage{Name: name, kind: stageID, encodeID: encode, decodeID: decode}
+}
+
+// Render makes a stage which renders the ID as a string by the encoder
+func Render(name string, e Encoder) Stage {
+	return Stage{Name: name, kind: stageRender, encoder: e}
+}
+
+// Wrap makes a stage which transforms the rendered string, e.g. checksum
+func Wrap(name string, encode func(string) string, decode func(string) (string, error)) Stage {
+	return Stage{Name: name, kind: stageString, encodeS: encode, decodeS: decode}
+}
+
+// ChainError indicates that a stage of the chain is invalid or failed on decoding
+type ChainError struct {
+	Stage string
+	Err   error
+}
+
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("tsid.Chain: stage %q failed, reason: %s", e.Stage, e.Err)
+}
+
+func (e *ChainError) Unwrap() error {
+	return e.Err
+}
+
+// valid reports whether the stage has the encoder or the functions of its kind
+func (s *Stage) valid() bool {
+	switch s.kind {
+	case stageID:
+		return s.encodeID != nil && s.decodeID != nil
+	case stageRender:
+		return s.encoder != nil
+	case stageString:
+		return s.encodeS != nil && s.decodeS != nil
+	}
+	return false
+}
+
+// Chain is an encoder composed of stages
+type Chain struct {
+	stages []Stage
+}
+
+// ChainEncoder returns an encoder which runs the stages in order on Encode,
+// and in reverse order on Decode.
+func ChainEncoder(stages ...Stage) (*Chain, error) {
+	render := 0
+	last := stageID
+	for _, s := range stages {
+		if !s.valid() {
+			return nil, &ChainError{Stage: s.Name, Err: ErrStageInvalid}
+		}
+		if s.kind < last {
+			return nil, ErrChainInvalid
+		}
+		if s.kind == stageRender {
+			render++
+		}
+		last = s.kind
+	}
+	if render != 1 {
+		return nil, ErrChainInvalid
+	}
+	return &Chain{stages: append([]Stage(nil), stages...)}, nil
+}
+
+func (c *Chain) Encode(id *ID) (no string) {
+	for _, s := range c.stages {
+		switch s.kind {
+		case stageID:
+			id = s.encodeID(id)
+		case stageRender:
+			no = s.encoder.Encode(id)
+		case stageString:
+			no = s.encodeS(no)
+		}
+	}
+	return no
+}
+
+func (c *Chain) Decode(no string) (id *ID, err error) {
+	for i := len(c.stages) - 1; i >= 0; i-- {
+		s := c.stages[i]
+		switch s.kind {
+		case stageString:
+			no, err = s.decodeS(no)
+		case stageRender:
+			id, err = s.encoder.Decode(no)
+		case stageID:
+			id, err = s.decodeID(id)
+		}
+		if err != nil {
+			return nil, &ChainError{Stage: s.Name, Err: err}
+		}
+	}
+	return id, nil
+}
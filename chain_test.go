@@ -0,0 +1,57 @@
+package tsid
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestChainEncoder(t *testing.T) {
+	if _, e := ChainEncoder(); e != ErrChainInvalid {
+		t.Errorf("want: error(%s), got: %v", ErrChainInvalid, e)
+	}
+	suffix := Wrap("suffix", func(s string) string {
+		return s + "~"
+	}, func(s string) (string, error) {
+		if !strings.HasSuffix(s, "~") {
+			return "", errors.New("suffix missing")
+		}
+		return s[:len(s)-1], nil
+	})
+	xor := Transform("xor", func(id *ID) *ID {
+		return &ID{Main: id.Main ^ 0x5555, Ext: id.Ext, Signed: id.Signed}
+	}, func(id *ID) (*ID, error) {
+		return &ID{Main: id.Main ^ 0x5555, Ext: id.Ext, Signed: id.Signed}, nil
+	})
+	render := Render("base64", &Base64{})
+	if _, e := ChainEncoder(suffix, render, xor); e != ErrChainInvalid {
+		t.Errorf("want: error(%s), got: %v", ErrChainInvalid, e)
+	}
+	for _, stages := range [][]Stage{
+		{{}, render},
+		{xor, Render("nil", nil)},
+		{xor, render, Wrap("half", strings.ToUpper, nil)},
+	} {
+		var ce *ChainError
+		if _, e := ChainEncoder(stages...); !errors.As(e, &ce) || ce.Err != ErrStageInvalid {
+			t.Errorf("want: error(%s), got: %v", ErrStageInvalid, e)
+		}
+	}
+	c, e := ChainEncoder(xor, render, suffix)
+	if e != nil {
+		t.Fatalf("want: an encoder, got: error %s", e)
+		return
+	}
+	id := &ID{Main: 123456789, Ext: 42}
+	no := c.Encode(id)
+	if !strings.HasSuffix(no, "~") || no == (&Base64{}).Encode(id)+"~" {
+		t.Errorf("want: the stages applied, got: %s", no)
+	}
+	if d, e := c.Decode(no); e != nil || !d.Equal(id) {
+		t.Errorf("want: %s, got: %v, error %v", id, d, e)
+	}
+	var ce *ChainError
+	if _, e = c.Decode(no[:len(no)-1]); !errors.As(e, &ce) || ce.Stage != "suffix" {
+		t.Errorf("want: ChainError of stage suffix, got: %v", e)
+	}
+}
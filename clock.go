@@ -0,0 +1,201 @@
+package tsid
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/StarryLab/tsid.go/tsidapi"
+)
+
+// ClockPolicy indicates how the builder handles the wall clock moving backwards
+// (NTP step, VM migration), see Options.OnClockBackwards
+type ClockPolicy int
+
+const (
+	// ClockIgnore issues the IDs at the earlier time, which may duplicate the
+	// IDs issued before. The lock-free builders borrow the sequence instead.
+	ClockIgnore ClockPolicy = iota
+	// ClockWait blocks until the clock catches up, and fails with
+	// ErrClockBackwards if it does not within the tolerance
+	ClockWait
+	// ClockError fails the generation with ErrClockBackwards
+	ClockError
+	// ClockBorrow keeps issuing the IDs at the latest time with the following
+	// sequences, and blocks if they are exhausted
+	ClockBorrow
+)
+
+// DefaultClockTolerance is the tolerance of ClockWait and ClockBorrow
+// if Options.ClockTolerance is zero
+const DefaultClockTolerance = time.Second
+
+// ErrClockBackwards indicates that the clock moved backwards, by more than
+// the tolerance of the policy
+var ErrClockBackwards = errors.New("tsid: the clock moved backwards")
+
+// backwards handles the clock n moving backwards from the latest millisecond
+// last by the policy. It returns the current time, and true if the sequence
+// of the latest millisecond is borrowed.
+func (b *Builder) backwards(n time.Time, last int64, lockFree bool) (time.Time, bool, error) {
+	policy := b.options.OnClockBackwards
+	if policy == ClockIgnore {
+		return n, lockFree, nil
+	}
+	if policy == ClockError {
+		return n, false, ErrClockBackwards
+	}
+	tolerance := b.options.ClockTolerance
+	if tolerance <= 0 {
+		tolerance = DefaultClockTolerance
+	}
+	d := time.Duration(last-n.UnixMilli()) * time.Millisecond
+	if d > tolerance {
+		return n, false, ErrClockBackwards
+	}
+	if policy == ClockBorrow {
+		return n, true, nil
+	}
+	// the clock may not advance, e.g. an injected clock, so the wait is bounded
+	start := time.Now()
+	n, ok := b.sleepUntil(time.UnixMilli(last), tolerance)
+	atomic.AddUint64(&b.waited, uint64(time.Since(start)))
+	if !ok {
+		return n, false, ErrClockBackwards
+	}
+	return n, false, nil
+}
+
+// monotonic returns a clock of the wall time at the call advanced by the monotonic
+// clock, which keeps increasing even when the wall clock is adjusted.
+func monotonic() func() time.Time {
+	start := time.Now()
+	return func() time.Time {
+		return start.Add(time.Since(start))
+	}
+}
+
+// Clock is the source of the current time of a builder, see Builder.WithClock
+type Clock = tsidapi.Clock
+
+// WithClock replaces the clock of the builder, e.g. by a ManualClock to test the
+// layouts, the sequence rollover and the epoch exhaustion without sleeping.
+// The builder spins on the clock when the sequence is exhausted, so the clock
+// MUST advance. Call it before generating the IDs, it is not safe to replace
+// the clock of the lock-free builders concurrently.
+func (b *Builder) WithClock(c Clock) *Builder {
+	b.Lock()
+	defer b.Unlock()
+	b.nowFunc = c.Now
+	return b
+}
+
+// ManualClock is a deterministic Clock for the tests, which is advanced
+// by Add, Set or by Step after every Now.
+type ManualClock struct {
+	mu sync.Mutex
+	t  time.Time
+	// Step is the duration advanced after every Now
+	Step time.Duration
+}
+
+// NewManualClock returns a ManualClock starting at t
+func NewManualClock(t time.Time, step time.Duration) *ManualClock {
+	return &ManualClock{t: t, Step: step}
+}
+
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := c.t
+	c.t = c.t.Add(c.Step)
+	return t
+}
+
+// Set sets the current time of the clock
+func (c *ManualClock) Set(t time.Time) {
+	c.mu.Lock()
+	c.t = t
+	c.mu.Unlock()
+}
+
+// Add advances the clock by d, which may be negative
+func (c *ManualClock) Add(d time.Duration) {
+	c.mu.Lock()
+	c.t = c.t.Add(d)
+	c.mu.Unlock()
+}
+
+// timeNow returns the current time of the builder
+func (b *Builder) timeNow() time.Time {
+	if b.nowFunc != nil {
+		return b.nowFunc()
+	}
+	return time.Now()
+}
+
+// clockProbe measures the granularity of the system clock, replaced by the tests
+var clockProbe = probeClock
+
+// probeClock returns the minimum step of the wall clock observed in 100ms at most,
+// e.g. about 15.6ms on the Windows systems without the high-resolution timer.
+func probeClock() time.Duration {
+	deadline := time.Now().Add(100 * time.Millisecond)
+	prev := time.Now().UnixNano()
+	step := time.Duration(0)
+	for changes := 0; changes < 8; {
+		now := time.Now()
+		v := now.UnixNano()
+		if v != prev {
+			if d := time.Duration(v - prev); step == 0 || d < step {
+				step = d
+			}
+			prev = v
+			changes++
+		}
+		if now.After(deadline) {
+			break
+		}
+	}
+	return step
+}
+
+// timestampUnit returns the unit of the first timestamp segment, 0 if none
+func timestampUnit(segments []Bits) time.Duration {
+	for _, segment := range segments {
+		if segment.Source != DateTime {
+			continue
+		}
+		switch DateTimeType(segment.Index) {
+		case TimestampMilliseconds:
+			return time.Millisecond
+		case TimestampNanoseconds:
+			return time.Nanosecond
+		case TimestampMicroseconds:
+			return time.Microsecond
+		case TimestampSeconds:
+			return time.Second
+		case TimestampMinutes:
+			return time.Minute
+		}
+	}
+	return 0
+}
+
+// checkClock returns the granularity of the clock, and a warning if it is coarser
+// than both 1ms and the unit of the timestamp, which reduces the throughput.
+func checkClock(segments []Bits) (time.Duration, *OptionsError) {
+	g := clockProbe()
+	if unit := timestampUnit(segments); g > unit && g > time.Millisecond {
+		return g, invalidOption("Clock", errorClockCoarse, g.String())
+	}
+	return g, nil
+}
+
+// ClockGranularity returns the granularity of the clock measured by Make
+// if Options.ClockCheck is on, otherwise 0. The effective throughput of the
+// builder is 2^(sequence width) IDs per granularity.
+func (b *Builder) ClockGranularity() time.Duration {
+	return b.granularity
+}
@@ -0,0 +1,196 @@
+package tsid
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClockCheck(t *testing.T) {
+	if g := probeClock(); g <= 0 {
+		t.Errorf("want: positive granularity, got: %s", g)
+	}
+	defer func(f func() time.Duration) { clockProbe = f }(clockProbe)
+	clockProbe = func() time.Duration { return 15600 * time.Microsecond }
+	opt := Default()
+	if b, _ := Make(opt); b.ClockGranularity() != 0 || len(b.Warnings()) != 0 {
+		t.Errorf("want: no clock check, got: %s, %v", b.ClockGranularity(), b.Warnings())
+	}
+	opt.ClockCheck = true
+	b, e := Make(opt)
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	w := invalidOption("Clock", errorClockCoarse)
+	if ws := b.Warnings(); b.ClockGranularity() != 15600*time.Microsecond || len(ws) != 1 || !w.SameAs(ws[0]) {
+		t.Errorf("want: warning(%s), got: %v", w, ws)
+	}
+	opt = *Segments(Sequence(12), Timestamp(41, TimestampSeconds))
+	opt.ClockCheck = true
+	if b, _ = Make(opt); len(b.Warnings()) != 0 {
+		t.Errorf("want: no warnings, got: %v", b.Warnings())
+	}
+}
+
+func TestOnClockBackwards(t *testing.T) {
+	for _, o := range []*Options{
+		Segments(Sequence(12), Env(4, "TSID_CLOCK", 0), Timestamp(41, TimestampMilliseconds)),
+		Segments(Sequence(12), Timestamp(41, TimestampMilliseconds)),
+	} {
+		for _, p := range []ClockPolicy{ClockIgnore, ClockWait, ClockError, ClockBorrow} {
+			opt := o.clone()
+			opt.OnClockBackwards = p
+			opt.ClockTolerance = 200 * time.Millisecond
+			b, e := Make(opt)
+			if e != nil {
+				t.Fatalf("want: a builder instance, got: error %s", e)
+				return
+			}
+			// the clock moves backwards by 50ms
+			future := time.Now().Add(50 * time.Millisecond)
+			if b.LockFree() {
+				b.clock = uint64(future.UnixMilli()-b.clockEpoch) << b.sequenceWidth
+			} else {
+				b.now = &future
+				b.sequence = 0
+			}
+			id, e := b.TryNext()
+			switch p {
+			case ClockError:
+				if e != ErrClockBackwards {
+					t.Errorf("want: error(%s), got: %v", ErrClockBackwards, e)
+				}
+				// the convenience getters fail cleanly
+				if v := b.NextInt64(); v != 0 {
+					t.Errorf("want: 0, got: %d", v)
+				}
+				if s := b.NextString(); s != "" {
+					t.Errorf("want: empty, got: %s", s)
+				}
+				continue
+			case ClockIgnore:
+				got, _ := b.TimeOf(id)
+				// the lock-free builders borrow the sequence
+				if b.LockFree() == got.Before(future.Truncate(time.Millisecond)) {
+					t.Errorf("want: lock-free %t, got: %s before %s", b.LockFree(), got, future)
+				}
+				continue
+			}
+			if e != nil {
+				t.Fatalf("%d want: an ID, got: error %s", p, e)
+				return
+			}
+			got, _ := b.TimeOf(id)
+			if got.Before(future.Truncate(time.Millisecond)) {
+				t.Errorf("%d want: not before %s, got: %s", p, future, got)
+			}
+			vs, _ := b.Parse(id)
+			if p == ClockBorrow && vs[0].Value != 1 {
+				t.Errorf("want: borrowed sequence 1, got: %d", vs[0].Value)
+			}
+			// beyond the tolerance
+			future = time.Now().Add(time.Second)
+			if b.LockFree() {
+				b.clock = uint64(future.UnixMilli()-b.clockEpoch) << b.sequenceWidth
+			} else {
+				b.now = &future
+			}
+			if _, e = b.TryNext(); e != ErrClockBackwards {
+				t.Errorf("want: error(%s), got: %v", ErrClockBackwards, e)
+			}
+		}
+	}
+}
+
+func TestClockWaitStopped(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, o := range []*Options{
+		Segments(Sequence(12), Env(4, "TSID_CLOCK", 0), Timestamp(41, TimestampMilliseconds)),
+		Segments(Sequence(12), Timestamp(41, TimestampMilliseconds)),
+	} {
+		opt := o.clone()
+		opt.OnClockBackwards = ClockWait
+		opt.ClockTolerance = 50 * time.Millisecond
+		b, e := Make(opt)
+		if e != nil {
+			t.Fatalf("want: a builder instance, got: error %s", e)
+			return
+		}
+		// the clock stops 10ms before the latest ID
+		clock := NewManualClock(start.Add(10*time.Millisecond), 0)
+		b.WithClock(clock)
+		if _, e = b.TryNext(); e != nil {
+			t.Fatalf("want: an ID, got: error %s", e)
+			return
+		}
+		clock.Set(start)
+		begin := time.Now()
+		if _, e = b.TryNext(); e != ErrClockBackwards {
+			t.Errorf("want: error(%s), got: %v", ErrClockBackwards, e)
+		}
+		if d := time.Since(begin); d > time.Second {
+			t.Errorf("want: the wait within the tolerance, got: %s", d)
+		}
+		// the clock catches up on its own time
+		clock.Step = time.Millisecond
+		if _, e = b.TryNext(); e != nil {
+			t.Errorf("want: an ID, got: error %s", e)
+		}
+	}
+}
+
+func TestMonotonic(t *testing.T) {
+	opt := Default()
+	opt.Monotonic = true
+	b, e := Make(opt)
+	if e != nil || b.nowFunc == nil {
+		t.Fatalf("want: a monotonic builder, got: error %v", e)
+		return
+	}
+	if d := time.Since(b.timeNow()); d < -time.Millisecond || d > time.Second {
+		t.Errorf("want: about now, got: %s", d)
+	}
+	prev := b.Next()
+	for i := 0; i < 1000; i++ {
+		id := b.Next()
+		if !prev.Less(id) {
+			t.Fatalf("want: incremental IDs, got: %s after %s", id, prev)
+			return
+		}
+		prev = id
+	}
+	if b, _ = Make(Default()); b.nowFunc != nil {
+		t.Error("want: wall clock, got: monotonic")
+	}
+}
+
+func TestWithClock(t *testing.T) {
+	start := time.UnixMilli(EpochMS + 1000)
+	for _, o := range []*Options{
+		Segments(Sequence(8), Env(4, "TSID_CLOCK", 0), Timestamp(41, TimestampMilliseconds)),
+		Segments(Sequence(8), Timestamp(41, TimestampMilliseconds)),
+	} {
+		c := NewManualClock(start, 0)
+		b, _ := Make(*o)
+		b.WithClock(c)
+		for i := 0; i < 256; i++ {
+			id := b.Next()
+			vs, _ := b.Parse(id)
+			if got, _ := b.TimeOf(id); !got.Equal(start) || vs[0].Value != int64(i) {
+				t.Fatalf("want: %s #%d, got: %s #%d", start, i, got, vs[0].Value)
+				return
+			}
+		}
+		// the sequence is exhausted, it spins until the clock advances
+		c.Step = time.Millisecond / 4
+		id := b.Next()
+		vs, _ := b.Parse(id)
+		if got, _ := b.TimeOf(id); !got.Equal(start.Add(time.Millisecond)) || vs[0].Value != 0 {
+			t.Errorf("want: %s #0, got: %s #%d", start.Add(time.Millisecond), got, vs[0].Value)
+		}
+		c.Set(start.Add(time.Hour))
+		if got, _ := b.TimeOf(b.Next()); !got.Equal(start.Add(time.Hour)) {
+			t.Errorf("want: %s, got: %s", start.Add(time.Hour), got)
+		}
+	}
+}
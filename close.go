@@ -0,0 +1,50 @@
+package tsid
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// Close stops the background goroutines of the builder: it delivers the IDs
+// queued for the sink, stops the background refill of the random pool, syncs
+// the journal, and closes the DataProviders of its segments implementing
+// io.Closer. The Shared sequence and the writer of the journal are left open
+// for their owners. The builder is not ready after Close, which waits for the
+// IDs being generated, and the later calls fail with ErrNotReady. It returns
+// the first error.
+func (b *Builder) Close() error {
+	b.closing.Lock()
+	defer b.closing.Unlock()
+	b.Lock()
+	defer b.Unlock()
+	if !b.isReady() {
+		return nil
+	}
+	atomic.StoreUint32(&b.closed, 1)
+	if b.sink != nil {
+		close(b.sink)
+		<-b.sinkDone
+		b.sink = nil
+	}
+	if b.rand != nil {
+		b.rand.close()
+	}
+	var err error
+	if b.journal != nil {
+		err = b.journal.sync()
+	}
+	closed := map[string]bool{}
+	for _, segment := range b.options.segments {
+		if segment.Source != Provider || closed[segment.Key] {
+			continue
+		}
+		closed[segment.Key] = true
+		d, _ := b.options.Environment.provider(segment.Key)
+		if c, ok := d.(io.Closer); ok {
+			if e := c.Close(); e != nil && err == nil {
+				err = e
+			}
+		}
+	}
+	return err
+}
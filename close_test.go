@@ -0,0 +1,99 @@
+package tsid
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// closingSource is a DataProvider implementing io.Closer
+type closingSource struct {
+	closed int32
+}
+
+func (c *closingSource) Read(query ...interface{}) (int64, error) {
+	return 1, nil
+}
+
+func (c *closingSource) Close() error {
+	atomic.AddInt32(&c.closed, 1)
+	return nil
+}
+
+func TestClose(t *testing.T) {
+	src := &closingSource{}
+	Register("test_closing", src)
+	opt := *Config(1, 2, Sequence(10), Data(2, "test_closing", 0), Data(2, "test_closing", 0), Timestamp(41, TimestampMilliseconds))
+	opt.RandPool = &RandPool{ChunkSize: 64, Background: true}
+	var delivered int32
+	opt.Sink(func(*ID) { atomic.AddInt32(&delivered, 1) })
+	b, e := Make(opt)
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	for i := 0; i < 100; i++ {
+		b.Next()
+	}
+	if e = b.Close(); e != nil {
+		t.Errorf("want: closed, got: error %s", e)
+	}
+	if n := atomic.LoadInt32(&delivered); n != 100 {
+		t.Errorf("want: 100 delivered, got: %d", n)
+	}
+	if n := atomic.LoadInt32(&src.closed); n != 1 {
+		t.Errorf("want: the provider closed once, got: %d", n)
+	}
+	if _, e = b.TryNext(); e != ErrNotReady {
+		t.Errorf("want: %s, got: %v", ErrNotReady, e)
+	}
+	if e = b.Close(); e != nil || atomic.LoadInt32(&src.closed) != 1 {
+		t.Errorf("want: no-op, got: error %v", e)
+	}
+}
+
+func TestCloseConcurrent(t *testing.T) {
+	blocks := Default()
+	blocks.SequenceBlock = 16
+	for _, opt := range []Options{*Segments(Sequence(12), Timestamp(41, TimestampMilliseconds)), blocks} {
+		var delivered int32
+		opt.Sink(func(*ID) { atomic.AddInt32(&delivered, 1) })
+		b, e := Make(opt)
+		if e != nil {
+			t.Fatalf("want: a builder instance, got: error %s", e)
+			return
+		}
+		if !b.LockFree() && !b.blocks {
+			t.Fatalf("want: the generation without the lock, got: %v", opt)
+			return
+		}
+		var wg sync.WaitGroup
+		var generated int32
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					if _, err := b.TryNext(); err != nil {
+						if err != ErrNotReady {
+							t.Errorf("want: %s, got: %s", ErrNotReady, err)
+						}
+						return
+					}
+					atomic.AddInt32(&generated, 1)
+				}
+			}()
+		}
+		for atomic.LoadInt32(&generated) < 100 {
+			runtime.Gosched()
+		}
+		if e = b.Close(); e != nil {
+			t.Errorf("want: closed, got: error %s", e)
+		}
+		wg.Wait()
+		if n := atomic.LoadInt32(&delivered); n+int32(b.SinkDropped()) != atomic.LoadInt32(&generated) {
+			t.Errorf("want: %d delivered or dropped, got: %d", generated, n)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// Command tsid is a command line tool for the TSID layouts.
+//
+//	tsid export [-scene default] [-n 100] [-o ids.csv]
+//	tsid vet [paths ...]
+//	tsid serve [-scene default] [-addr :8080]
+//	tsid journal -key key.bin [-head records:chain] [files ...]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/StarryLab/tsid.go"
+	"github.com/StarryLab/tsid.go/tsidprom"
+	"github.com/StarryLab/tsid.go/vet"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: tsid <command> [arguments]")
+	fmt.Fprintln(os.Stderr, "Commands:")
+	fmt.Fprintln(os.Stderr, "  export  generates IDs and writes them with the decomposed segments as CSV")
+	fmt.Fprintln(os.Stderr, "  vet     reports the mistakes of the layouts declared in Go source files")
+	fmt.Fprintln(os.Stderr, "  serve   serves IDs at /next, the statistics at /stats and /metrics over HTTP")
+	fmt.Fprintln(os.Stderr, "  journal verifies the issuance journal files in order, or stdin")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	var err error
+	switch os.Args[1] {
+	case "export":
+		err = export(os.Args[2:])
+	case "vet":
+		err = check(os.Args[2:])
+	case "serve":
+		err = serve(os.Args[2:])
+	case "journal":
+		err = journal(os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "tsid:", err)
+		os.Exit(1)
+	}
+}
+
+func export(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	scene := fs.String("scene", "default", "the predefined options")
+	count := fs.Int("n", 100, "the number of IDs")
+	output := fs.String("o", "", "the output file, default is stdout")
+	_ = fs.Parse(args)
+	opt, found := tsid.Predefined(*scene)
+	if !found {
+		return fmt.Errorf("predefined options %q not found", *scene)
+	}
+	b, err := tsid.Make(opt)
+	if err != nil {
+		return err
+	}
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return tsid.ExportCSV(w, b, *count)
+}
+
+func check(paths []string) error {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	diags, err := vet.CheckPaths(paths...)
+	if err != nil {
+		return err
+	}
+	failed := false
+	for _, d := range diags {
+		fmt.Println(d)
+		failed = failed || d.Severity == vet.Error
+	}
+	if failed {
+		return fmt.Errorf("vet: found errors in the layouts")
+	}
+	return nil
+}
+
+func serve(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	scene := fs.String("scene", "default", "the predefined options")
+	addr := fs.String("addr", ":8080", "the address to listen on")
+	_ = fs.Parse(args)
+	opt, found := tsid.Predefined(*scene)
+	if !found {
+		return fmt.Errorf("predefined options %q not found", *scene)
+	}
+	b, err := tsid.Make(opt)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/next", func(w http.ResponseWriter, r *http.Request) {
+		id, err := b.TryNext()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, id)
+	})
+	mux.Handle("/stats", tsid.StatsHandler(map[string]*tsid.Builder{*scene: b}))
+	metrics := tsidprom.NewCollector()
+	metrics.Register(*scene, b)
+	mux.Handle("/metrics", metrics)
+	return http.ListenAndServe(*addr, mux)
+}
+
+func journal(args []string) error {
+	fs := flag.NewFlagSet("journal", flag.ExitOnError)
+	keyFile := fs.String("key", "", "the file of the journal key")
+	last := fs.String("head", "", "the head of the journal kept aside, <records>:<chain>")
+	_ = fs.Parse(args)
+	key, err := os.ReadFile(*keyFile)
+	if err != nil {
+		return err
+	}
+	var head *tsid.JournalHead
+	if *last != "" {
+		records, chain, found := strings.Cut(*last, ":")
+		n, err := strconv.ParseUint(records, 10, 64)
+		if !found || err != nil {
+			return fmt.Errorf("invalid head %q", *last)
+		}
+		head = &tsid.JournalHead{Records: n, Chain: chain}
+	}
+	paths := fs.Args()
+	rs := []io.Reader{os.Stdin}
+	if len(paths) > 0 {
+		rs = rs[:0]
+		for _, p := range paths {
+			f, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			rs = append(rs, f)
+		}
+	}
+	s, err := tsid.VerifyJournal(io.MultiReader(rs...), key, head)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d records verified, %d out of order\n", s.Records, s.Unordered)
+	return nil
+}
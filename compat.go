@@ -0,0 +1,94 @@
+package tsid
+
+import "fmt"
+
+// CompatibilityIssue describes a difference between two layouts
+// that breaks the decoding of the IDs generated by the old layout.
+type CompatibilityIssue struct {
+	// Segment is the index of the segment in the old layout, -1 for the whole layout
+	Segment int
+	Reason  string
+}
+
+func (i CompatibilityIssue) String() string {
+	if i.Segment < 0 {
+		return i.Reason
+	}
+	return fmt.Sprintf("segment %d: %s", i.Segment, i.Reason)
+}
+
+// CompatibilityReport is the result of CompatibleWith
+type CompatibilityReport struct {
+	// Compatible reports whether the IDs generated by the old layout
+	// decode to the same values under the new layout
+	Compatible bool
+	Issues     []CompatibilityIssue
+}
+
+// CompatibleWith examines whether the IDs generated by the old layout decode
+// correctly under the new one, by comparing the epochs, the total widths and
+// the position, width and source of every segment. It returns an error if any
+// layout is invalid.
+func CompatibleWith(old, new Options) (CompatibilityReport, error) {
+	old, new = old.clone(), new.clone()
+	var r CompatibilityReport
+	ow, err := measure(old.segments)
+	if err != nil {
+		return r, err
+	}
+	nw, err := measure(new.segments)
+	if err != nil {
+		return r, err
+	}
+	issue := func(segment int, format string, a ...interface{}) {
+		r.Issues = append(r.Issues, CompatibilityIssue{segment, fmt.Sprintf(format, a...)})
+	}
+	if epoch(old.EpochMS) != epoch(new.EpochMS) {
+		issue(-1, "the epoch changed from %d to %d", epoch(old.EpochMS), epoch(new.EpochMS))
+	}
+	if nw < ow {
+		issue(-1, "the total width shrank from %d to %d bits", ow, nw)
+	}
+	offsets := map[int]int{}
+	offset := 0
+	for i, segment := range new.segments {
+		offsets[offset] = i
+		offset += int(segment.Width)
+	}
+	offset = 0
+	for i, o := range old.segments {
+		j, found := offsets[offset]
+		offset += int(o.Width)
+		if !found {
+			issue(i, "no segment starts at bit %d", offset-int(o.Width))
+			continue
+		}
+		n := new.segments[j]
+		switch {
+		case n.Width != o.Width:
+			issue(i, "the width changed from %d to %d", o.Width, n.Width)
+		case o.Source == ReservedBits:
+			// the reserved bits of the old IDs are zero, they can be claimed
+		case n.Source != o.Source:
+			issue(i, "the source changed from %s to %s", o.Source, n.Source)
+		case o.Source == DateTime && n.Index != o.Index:
+			issue(i, "the time unit changed from %s to %s", DateTimeType(o.Index), DateTimeType(n.Index))
+		case o.Source == Static && n.Value != o.Value:
+			issue(i, "the fixed value changed from %d to %d", o.Value, n.Value)
+		}
+	}
+	r.Compatible = len(r.Issues) == 0
+	return r, nil
+}
+
+// epoch returns the effective epoch of the option EpochMS, the same as Make,
+// every lookup of the epoch goes through it, including the generation
+func epoch(v int64) int64 {
+	if v == EpochUnix {
+		return 0
+	}
+	if v <= 0 {
+		return EpochMS
+	}
+	return v
+}
@@ -0,0 +1,32 @@
+package tsid
+
+import "testing"
+
+func TestCompatibleWith(t *testing.T) {
+	if _, e := CompatibleWith(Default(), *Segments(Fixed(0, 0))); e == nil {
+		t.Error("want: error, got: a report")
+	}
+	r, e := CompatibleWith(Default(), Default())
+	if e != nil || !r.Compatible {
+		t.Errorf("want: compatible, got: %v, error %v", r.Issues, e)
+	}
+	grown := Default()
+	grown.segments = append(grown.segments, Random(20))
+	if r, _ = CompatibleWith(Default(), grown); !r.Compatible {
+		t.Errorf("want: compatible, got: %v", r.Issues)
+	}
+	if r, _ = CompatibleWith(grown, Default()); r.Compatible {
+		t.Error("want: incompatible, got: compatible")
+	}
+	tests := map[string]Options{
+		"epoch":  *Segments(Sequence(12), Env(4, EnvServerNode, 0), Env(6, EnvServerHost, 0), Timestamp(41, TimestampMilliseconds)).NewEpoch(EpochMS - 1),
+		"width":  *Segments(Sequence(13), Env(3, EnvServerNode, 0), Env(6, EnvServerHost, 0), Timestamp(41, TimestampMilliseconds)),
+		"source": *Segments(Sequence(12), Random(4), Env(6, EnvServerHost, 0), Timestamp(41, TimestampMilliseconds)),
+		"unit":   *Segments(Sequence(12), Env(4, EnvServerNode, 0), Env(6, EnvServerHost, 0), Timestamp(41, TimestampSeconds)),
+	}
+	for name, o := range tests {
+		if r, _ = CompatibleWith(Default(), o); r.Compatible || len(r.Issues) == 0 {
+			t.Errorf("%s want: incompatible, got: compatible", name)
+		}
+	}
+}
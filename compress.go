@@ -0,0 +1,111 @@
+package tsid
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// ErrCompressed indicates that the compressed ID list is malformed
+var ErrCompressed = errors.New("tsid: the compressed ID list is malformed")
+
+// CompressIDs encodes the IDs as deltas of the previous ones in varints. It is
+// compact for the sorted IDs, whose high bits (the timestamp) are shared, and
+// lossless for any order. The list is decoded by DecompressIDs.
+//
+// Layout: uvarint(count<<1 | any signed), the bitmap of the sign flags if any,
+// then per ID varint(deltaExt) followed by varint(deltaMain) if deltaExt is 0,
+// otherwise uvarint(Main).
+func CompressIDs(ids []ID) []byte {
+	var signs []byte
+	for i := range ids {
+		if ids[i].Signed {
+			if signs == nil {
+				signs = make([]byte, (len(ids)+7)/8)
+			}
+			signs[i/8] |= 1 << (i % 8)
+		}
+	}
+	head := uint64(len(ids)) << 1
+	if signs != nil {
+		head |= 1
+	}
+	buf := make([]byte, 0, len(ids)*4+len(signs)+binary.MaxVarintLen64)
+	buf = appendUvarint(buf, head)
+	buf = append(buf, signs...)
+	var prev ID
+	for i := range ids {
+		id := &ids[i]
+		d := id.Ext - prev.Ext
+		buf = appendVarint(buf, d)
+		if d == 0 {
+			buf = appendVarint(buf, id.Main-prev.Main)
+		} else {
+			buf = appendUvarint(buf, uint64(id.Main))
+		}
+		prev = *id
+	}
+	return buf
+}
+
+// DecompressIDs decodes the IDs encoded by CompressIDs
+func DecompressIDs(data []byte) ([]ID, error) {
+	head, k := binary.Uvarint(data)
+	n := head >> 1
+	// each ID takes 2 bytes at least
+	if k <= 0 || n > uint64(len(data)-k)/2 {
+		return nil, ErrCompressed
+	}
+	data = data[k:]
+	var signs []byte
+	if head&1 == 1 {
+		size := int(n+7) / 8
+		if len(data) < size {
+			return nil, ErrCompressed
+		}
+		signs, data = data[:size], data[size:]
+	}
+	ids := make([]ID, n)
+	var prev ID
+	for i := range ids {
+		d, k := binary.Varint(data)
+		if k <= 0 {
+			return nil, ErrCompressed
+		}
+		data = data[k:]
+		id := ID{Ext: prev.Ext + d}
+		if d == 0 {
+			v, k := binary.Varint(data)
+			if k <= 0 {
+				return nil, ErrCompressed
+			}
+			data = data[k:]
+			id.Main = prev.Main + v
+		} else {
+			v, k := binary.Uvarint(data)
+			if k <= 0 {
+				return nil, ErrCompressed
+			}
+			data = data[k:]
+			id.Main = int64(v)
+		}
+		if signs != nil {
+			id.Signed = signs[i/8]&(1<<(i%8)) != 0
+		}
+		ids[i] = id
+		prev = id
+	}
+	if len(data) != 0 {
+		return nil, ErrCompressed
+	}
+	return ids, nil
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	var a [binary.MaxVarintLen64]byte
+	return append(buf, a[:binary.PutUvarint(a[:], v)]...)
+}
+
+func appendVarint(buf []byte, v int64) []byte {
+	var a [binary.MaxVarintLen64]byte
+	return append(buf, a[:binary.PutVarint(a[:], v)]...)
+}
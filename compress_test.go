@@ -0,0 +1,36 @@
+package tsid
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestCompressIDs(t *testing.T) {
+	b, _ := Make(Default())
+	ids := make([]ID, 1000)
+	b.NextBatchInto(ids)
+	sort.Slice(ids, func(i, j int) bool { return ids[i].Less(&ids[j]) })
+	ids = append(ids, ID{Main: 7, Ext: 9, Signed: true}, ID{Main: 1})
+	data := CompressIDs(ids)
+	if len(data) >= len(ids)*8 {
+		t.Errorf("want: less than %d bytes, got: %d", len(ids)*8, len(data))
+	}
+	got, e := DecompressIDs(data)
+	if e != nil || len(got) != len(ids) {
+		t.Fatalf("want: %d IDs, got: %d, error %v", len(ids), len(got), e)
+		return
+	}
+	for i := range ids {
+		if !got[i].Equal(&ids[i]) {
+			t.Errorf("want: %v, got: %v", ids[i], got[i])
+		}
+	}
+	if got, e = DecompressIDs(CompressIDs(nil)); e != nil || len(got) != 0 {
+		t.Errorf("want: empty, got: %v, error %v", got, e)
+	}
+	for _, data := range [][]byte{nil, {2}, {2, 0}, {2, 0, 0, 0}, {3, 0, 0}, {200, 0, 0}} {
+		if _, e = DecompressIDs(data); e != ErrCompressed {
+			t.Errorf("%v want: error(%s), got: %v", data, ErrCompressed, e)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package tsid
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrOutOfLayout indicates that the ID has bits beyond the width of the layout
+	ErrOutOfLayout = errors.New("tsid: the ID exceeds the width of the layout")
+	// ErrNoTimestamp indicates that the layout has no timestamp segment
+	ErrNoTimestamp = errors.New("tsid: the layout has no timestamp segment")
+	// ErrReservedBits indicates that the reserved segments of the ID are not zero
+	ErrReservedBits = errors.New("tsid: the reserved bits of the ID are not zero")
+	// ErrRedacted indicates that the value is from a private segment
+	ErrRedacted = errors.New("tsid: the segment is private")
+)
+
+// Redacted is the value of the private segments decomposed by the public decoders
+const Redacted int64 = -1
+
+// Decoder interprets the IDs of a layout without being able to generate them
+type Decoder struct {
+	options *Options
+	width   byte
+	public  bool
+}
+
+// NewDecoder returns a Decoder of the layout declared by opt. Unlike Make, it
+// does not require the timestamp and sequence segments, nor checks the epoch
+// against now.
+func NewDecoder(opt Options) (*Decoder, error) {
+	opt = opt.clone()
+	if len(opt.segments) <= 0 {
+		return nil, invalidOption("Segments", errorSegmentsEmpty)
+	}
+	if len(opt.segments) > SegmentsLimit {
+		return nil, invalidOption("Segments", errorSegmentsTooMany)
+	}
+	if opt.EpochMS < 0 && opt.EpochMS != EpochUnix {
+		return nil, invalidOption("EpochMS", errorEpochTooSmall)
+	}
+	if opt.EpochMS == 0 {
+		opt.EpochMS = EpochMS
+	}
+	t, err := measure(opt.segments)
+	if err != nil {
+		return nil, err
+	}
+	return &Decoder{options: &opt, width: t}, nil
+}
+
+// Decoder returns a Decoder of the layout used by the builder
+func (b *Builder) Decoder() *Decoder {
+	opt := b.Options()
+	return &Decoder{options: &opt, width: b.width}
+}
+
+// Public returns a copy of the decoder for external callers,
+// which redacts the values of the private segments.
+func (d *Decoder) Public() *Decoder {
+	p := *d
+	p.public = true
+	return &p
+}
+
+// Validate checks that the ID fits in the width of the layout,
+// and its reserved segments are zero.
+func (d *Decoder) Validate(id *ID) error {
+	if id == nil || id.Main < 0 || id.Ext < 0 {
+		return ErrOutOfLayout
+	}
+	if d.width <= bitsMaxWidth {
+		if id.Ext != 0 || d.width < bitsMaxWidth && id.Main>>d.width != 0 {
+			return ErrOutOfLayout
+		}
+	} else if d.width < bitsMaxWidth*2 && id.Ext>>(d.width-bitsMaxWidth) != 0 {
+		return ErrOutOfLayout
+	}
+	for i, v := range decompose(d.options.segments, id.Main, id.Ext) {
+		if v != 0 && d.options.segments[i].Source == ReservedBits {
+			return ErrReservedBits
+		}
+	}
+	return nil
+}
+
+// Decompose returns the values of the segments in the order of the layout
+func (d *Decoder) Decompose(id *ID) ([]int64, error) {
+	if err := d.Validate(id); err != nil {
+		return nil, err
+	}
+	vs := decompose(d.options.segments, id.Main, id.Ext)
+	if d.public {
+		for i, segment := range d.options.segments {
+			if segment.Private {
+				vs[i] = Redacted
+			}
+		}
+	}
+	return vs, nil
+}
+
+// Time returns the time when the ID was generated, which is restored
+// from the first timestamp segment of the layout.
+func (d *Decoder) Time(id *ID) (time.Time, error) {
+	vs, err := d.Decompose(id)
+	if err != nil {
+		return time.Time{}, err
+	}
+	epoch := epoch(d.options.EpochMS)
+	for i, segment := range d.options.segments {
+		if segment.Source != DateTime || !DateTimeType(segment.Index).timestamp() {
+			continue
+		}
+		if d.public && segment.Private {
+			return time.Time{}, ErrRedacted
+		}
+		v := vs[i]
+		switch DateTimeType(segment.Index) {
+		case TimestampMilliseconds:
+			return time.UnixMilli(v + epoch), nil
+		case TimestampNanoseconds:
+			return time.Unix(0, v+epoch*nsPerMilliseconds), nil
+		case TimestampMicroseconds:
+			return time.UnixMicro(v + epoch*usPerMilliseconds), nil
+		case TimestampSeconds:
+			return time.Unix(v+epoch/msPerSecond, 0), nil
+		case TimestampMinutes:
+			return time.Unix((v+epoch/msPerMinute)*60, 0), nil
+		}
+	}
+	return time.Time{}, ErrNoTimestamp
+}
+
+// TimeOf returns the time when the ID was generated by the layout of the builder,
+// see Decoder.Time
+func (b *Builder) TimeOf(id *ID) (time.Time, error) {
+	if !b.isReady() {
+		return time.Time{}, ErrNotReady
+	}
+	return b.Decoder().Time(id)
+}
+
+// SegmentValue is the value of a segment of an ID
+type SegmentValue struct {
+	// Segment is the declaration of the segment in the layout
+	Segment Bits
+	Value   int64
+}
+
+// Parse returns the values of the segments of the ID in the order of the layout,
+// see Decoder.Decompose
+func (d *Decoder) Parse(id *ID) ([]SegmentValue, error) {
+	vs, err := d.Decompose(id)
+	if err != nil {
+		return nil, err
+	}
+	r := make([]SegmentValue, len(vs))
+	for i, v := range vs {
+		r[i] = SegmentValue{Segment: d.options.segments[i], Value: v}
+	}
+	return r, nil
+}
+
+// Values returns the values of the segments of the ID by their labels,
+// see Bits.Label
+func (d *Decoder) Values(id *ID) (map[string]int64, error) {
+	vs, err := d.Decompose(id)
+	if err != nil {
+		return nil, err
+	}
+	r := make(map[string]int64, len(vs))
+	for i, l := range labels(d.options.segments) {
+		r[l] = vs[i]
+	}
+	return r, nil
+}
+
+// Values returns the values of the segments of the ID generated by the builder
+// by their labels
+func (b *Builder) Values(id *ID) (map[string]int64, error) {
+	if !b.isReady() {
+		return nil, ErrNotReady
+	}
+	return b.Decoder().Values(id)
+}
+
+// Parse returns the values of the segments of the ID generated by the builder
+func (b *Builder) Parse(id *ID) ([]SegmentValue, error) {
+	if !b.isReady() {
+		return nil, ErrNotReady
+	}
+	return b.Decoder().Parse(id)
+}
+
+// ParseInt64ID parses a 64-bit ID of the layout in the forms found in the CSV
+// exports: decimal, hexadecimal with the 0x prefix, both zero-padded or not,
+// and validates it by the layout.
+func ParseInt64ID(s string, opt Options) (*ID, error) {
+	d, err := NewDecoder(opt)
+	if err != nil {
+		return nil, err
+	}
+	no := strings.TrimSpace(s)
+	base := 10
+	if len(no) > 2 && no[0] == '0' && (no[1] == 'x' || no[1] == 'X') {
+		no, base = no[2:], 16
+	}
+	if no == "" {
+		return nil, decodeError(s, DecodeErrorEmpty)
+	}
+	if no[0] == '-' || no[0] == '+' {
+		return nil, decodeError(s, DecodeErrorInvalidDigit)
+	}
+	v, err := strconv.ParseInt(no, base, 64)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return nil, decodeError(s, DecodeErrorOverflow)
+		}
+		return nil, decodeError(s, DecodeErrorInvalidDigit)
+	}
+	id := &ID{Main: v, Signed: opt.Signed}
+	if err = d.Validate(id); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
@@ -0,0 +1,223 @@
+package tsid
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewDecoder(t *testing.T) {
+	if _, e := NewDecoder(Options{}); e == nil {
+		t.Error("want: error, got: an instance")
+	}
+	d, e := NewDecoder(*Segments(Host(6, 0), Node(4, 0)))
+	if e != nil {
+		t.Fatalf("want: an instance, got: error %s", e)
+		return
+	}
+	if _, e = (&Builder{}).TimeOf(&ID{Main: 1}); e != ErrNotReady {
+		t.Errorf("want: error(%s), got: %v", ErrNotReady, e)
+	}
+	if _, e = d.Time(&ID{Main: 1}); e != ErrNoTimestamp {
+		t.Errorf("want: error(%s), got: %v", ErrNoTimestamp, e)
+	}
+	if _, e = d.Decompose(&ID{Main: 1 << 10}); e != ErrOutOfLayout {
+		t.Errorf("want: error(%s), got: %v", ErrOutOfLayout, e)
+	}
+	if vs, e := d.Decompose(&ID{Main: 5<<6 | 9}); e != nil || vs[0] != 9 || vs[1] != 5 {
+		t.Errorf("want: [9 5], got: %v, error %v", vs, e)
+	}
+}
+
+func TestDecoderTime(t *testing.T) {
+	for _, u := range []DateTimeType{
+		TimestampMilliseconds,
+		TimestampMicroseconds,
+		TimestampNanoseconds,
+		TimestampSeconds,
+	} {
+		opt := Options{
+			segments: []Bits{
+				Sequence(12),
+				Timestamp(63, u),
+			},
+		}
+		b, e := Make(opt)
+		if e != nil {
+			t.Fatalf("want: a builder instance, got: error %s", e)
+			return
+		}
+		start := time.Now().Truncate(time.Second)
+		id := b.Next()
+		got, e := b.Decoder().Time(id)
+		if e != nil {
+			t.Fatalf("want: time, got: error %s", e)
+			return
+		}
+		if got.Before(start) || got.After(time.Now()) {
+			t.Errorf("%s want: about %s, got: %s", u, start, got)
+		}
+		if v, e := b.TimeOf(id); e != nil || !v.Equal(got) {
+			t.Errorf("%s want: %s, got: %s, error %v", u, got, v, e)
+		}
+	}
+}
+
+func TestDecoderPublic(t *testing.T) {
+	opt := Segments(
+		Sequence(12).Hide(),
+		Node(4, 3),
+		Timestamp(41, TimestampMilliseconds).Hide(),
+	)
+	b, e := Make(*opt)
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	id := b.Next()
+	d := b.Decoder()
+	p := d.Public()
+	if vs, e := p.Decompose(id); e != nil || vs[0] != Redacted || vs[1] != 3 || vs[2] != Redacted {
+		t.Errorf("want: [%d 3 %d], got: %v, error %v", Redacted, Redacted, vs, e)
+	}
+	if _, e = p.Time(id); e != ErrRedacted {
+		t.Errorf("want: error(%s), got: %v", ErrRedacted, e)
+	}
+	if vs, e := d.Decompose(id); e != nil || vs[0] == Redacted || vs[2] == Redacted {
+		t.Errorf("want: all values, got: %v, error %v", vs, e)
+	}
+	if _, e = d.Time(id); e != nil {
+		t.Errorf("want: time, got: error %s", e)
+	}
+}
+
+func TestReserved(t *testing.T) {
+	opt := Segments(Sequence(12), Reserved(4), Timestamp(41, TimestampMilliseconds))
+	b, e := Make(*opt)
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	id := b.Next()
+	if id.Main>>12&15 != 0 {
+		t.Errorf("want: zero reserved bits, got: %b", id.Main)
+	}
+	d := b.Decoder()
+	if e = d.Validate(id); e != nil {
+		t.Errorf("want: valid, got: error %s", e)
+	}
+	if e = d.Validate(&ID{Main: id.Main | 1<<12}); e != ErrReservedBits {
+		t.Errorf("want: error(%s), got: %v", ErrReservedBits, e)
+	}
+	bad := Reserved(4)
+	bad.Value = 1
+	r := invalidOption("Segments", errorReservedValue)
+	if _, e = Make(*Segments(Sequence(12), bad, Timestamp(41, TimestampMilliseconds))); e == nil || !r.SameAs(e) {
+		t.Errorf("want: error(%s), got: %v", r, e)
+	}
+	claimed := Segments(Sequence(12), Fixed(4, 1), Timestamp(41, TimestampMilliseconds))
+	if c, _ := CompatibleWith(*opt, *claimed); !c.Compatible {
+		t.Errorf("want: compatible, got: %v", c.Issues)
+	}
+}
+
+func TestParse(t *testing.T) {
+	b, e := Make(*Config(9, 3, Sequence(12), Host(6, 0), Node(4, 0), Timestamp(41, TimestampMilliseconds)))
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	id := b.Next()
+	vs, e := b.Parse(id)
+	if e != nil || len(vs) != 4 {
+		t.Fatalf("want: 4 values, got: %v, error %v", vs, e)
+		return
+	}
+	if vs[1].Value != 9 || vs[1].Segment.Source != Settings || vs[2].Value != 3 || vs[0].Segment.Source != SequenceID {
+		t.Errorf("want: host 9, node 3, got: %v", vs)
+	}
+	if _, e = b.Parse(&ID{Ext: 1}); e != ErrOutOfLayout {
+		t.Errorf("want: error(%s), got: %v", ErrOutOfLayout, e)
+	}
+	if _, e = (&Builder{}).Parse(id); e != ErrNotReady {
+		t.Errorf("want: error(%s), got: %v", ErrNotReady, e)
+	}
+}
+
+func TestValues(t *testing.T) {
+	b, e := Make(*Config(9, 3,
+		Sequence(12).Named("seq"),
+		Host(6, 0),
+		Fixed(2, 1),
+		Fixed(2, 2),
+		Timestamp(41, TimestampMilliseconds).Named("ts")))
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	b.Debug = true
+	id := b.Next()
+	want := map[string]int64{"seq": 0, "Host": 9, "Static": 1, "Static#3": 2}
+	vs, e := b.Values(id)
+	for k, v := range want {
+		if vs[k] != v || b.DebugInfo().Values[k] != v {
+			t.Errorf("want: %s = %d, got: %v, debug %v, error %v", k, v, vs, b.DebugInfo().Values, e)
+		}
+	}
+	if len(vs) != 5 || vs["ts"] <= 0 {
+		t.Errorf("want: 5 values, got: %v", vs)
+	}
+}
+
+func TestParseInt64ID(t *testing.T) {
+	opt := *Segments(Sequence(12), Timestamp(41, TimestampMilliseconds))
+	for _, s := range []string{"1234567", "0001234567", "0x12d687", "0X0012D687", " 1234567 "} {
+		if id, e := ParseInt64ID(s, opt); e != nil || id.Main != 1234567 {
+			t.Errorf("%q want: 1234567, got: %v, error %v", s, id, e)
+		}
+	}
+	for _, s := range []string{"", "0x", "-1", "+1", "12a", "99999999999999999999", "0x7fffffffffffffff"} {
+		if _, e := ParseInt64ID(s, opt); e == nil {
+			t.Errorf("%q want: error, got: nothing", s)
+		}
+	}
+	if _, e := ParseInt64ID("1", Options{}); e == nil {
+		t.Error("want: error, got: nothing")
+	}
+}
+
+func TestLayout(t *testing.T) {
+	opt := Config(1, 2, Sequence(12), Data(4, "my_data_source", 0, "q"), Timestamp(41, TimestampMilliseconds))
+	s := opt.Segments()
+	if len(s) != 3 || s[0].Source != SequenceID || s[2].Width != 41 {
+		t.Errorf("want: the segments, got: %v", s)
+	}
+	s[0].Width = 1
+	s[1].query[0] = "x"
+	if o := opt.Segments(); o[0].Width != 12 || o[1].query[0] != "q" {
+		t.Errorf("want: a copy, got: the segments shared %v", o)
+	}
+	b, _ := Make(Default())
+	if l := b.Layout(); len(l) != 4 || l[3].Source != DateTime {
+		t.Errorf("want: the layout of the builder, got: %v", l)
+	}
+	if l := (&Builder{}).Layout(); l != nil {
+		t.Errorf("want: nil, got: %v", l)
+	}
+}
+
+func TestDecoderEpoch(t *testing.T) {
+	opt := Default()
+	opt.EpochMS = -5000
+	if _, e := NewDecoder(opt); e == nil {
+		t.Error("want: error of the negative epoch, got: nothing")
+	}
+	opt.EpochMS = EpochUnix
+	d, e := NewDecoder(opt)
+	if e != nil {
+		t.Fatalf("want: a decoder, got: error %s", e)
+		return
+	}
+	if got, _ := d.Time(&ID{Main: 1000 << 22}); !got.Equal(time.UnixMilli(1000)) {
+		t.Errorf("want: %s, got: %s", time.UnixMilli(1000), got)
+	}
+}
@@ -0,0 +1,113 @@
+package tsid
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"strings"
+)
+
+var (
+	// ErrHeaderUnknown indicates that no layout and encoder is registered for the header
+	ErrHeaderUnknown = errors.New("tsid: unknown header of the self-describing string")
+	// ErrHeaderConflict indicates that two registered encoders have the same header
+	ErrHeaderConflict = errors.New("tsid: two encoders have the same header")
+	// ErrEncoderID indicates that the encoder id is out of range [0, 3]
+	ErrEncoderID = errors.New("tsid: the encoder id is out of range [0, 3]")
+)
+
+// Fingerprint returns a hash of the layout, the epoch and the segments
+func (o *Options) Fingerprint() uint32 {
+	h := fnv.New32a()
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(epoch(o.EpochMS)))
+	h.Write(buf[:])
+	for _, b := range o.segments {
+		h.Write([]byte{byte(b.Source), b.Width, byte(b.Index)})
+		h.Write([]byte(b.Key))
+	}
+	return h.Sum32()
+}
+
+// SelfDescribing is an encoder which prepends a header character to the string,
+// encoding {4 bits layout fingerprint, 2 bits encoder id}, so DecodeAny can
+// route a string to the right layout and encoder.
+type SelfDescribing struct {
+	encoder Encoder
+	decoder *Decoder
+	header  byte
+}
+
+// Describe returns the SelfDescribing encoder of the layout and the encoder,
+// the value range of encoderID is [0, 3].
+func Describe(opt Options, encoderID byte, e Encoder) (*SelfDescribing, error) {
+	if encoderID > 3 {
+		return nil, ErrEncoderID
+	}
+	d, err := NewDecoder(opt)
+	if err != nil {
+		return nil, err
+	}
+	fp := byte(opt.Fingerprint() & 15)
+	return &SelfDescribing{
+		encoder: e,
+		decoder: d,
+		header:  base64Digits[fp<<2|encoderID],
+	}, nil
+}
+
+// Decoder returns the Decoder of the layout
+func (s *SelfDescribing) Decoder() *Decoder {
+	return s.decoder
+}
+
+func (s *SelfDescribing) Encode(id *ID) string {
+	return string(s.header) + s.encoder.Encode(id)
+}
+
+func (s *SelfDescribing) Decode(no string) (*ID, error) {
+	if no == "" {
+		return nil, decodeError(no, DecodeErrorEmpty)
+	}
+	if no[0] != s.header {
+		return nil, ErrHeaderUnknown
+	}
+	return s.encoder.Decode(no[1:])
+}
+
+// Router routes the self-describing strings to their layouts and encoders
+type Router struct {
+	routes map[byte]*SelfDescribing
+}
+
+// NewRouter returns the Router of the encoders, whose headers MUST be different
+func NewRouter(encoders ...*SelfDescribing) (*Router, error) {
+	r := &Router{routes: map[byte]*SelfDescribing{}}
+	for _, e := range encoders {
+		if _, found := r.routes[e.header]; found {
+			return nil, ErrHeaderConflict
+		}
+		r.routes[e.header] = e
+	}
+	return r, nil
+}
+
+// DecodeAny decodes the self-describing string by the encoder of its header,
+// returns the ID and the Decoder of its layout.
+func (r *Router) DecodeAny(no string) (*ID, *Decoder, error) {
+	if no == "" {
+		return nil, nil, decodeError(no, DecodeErrorEmpty)
+	}
+	if strings.IndexByte(base64Digits, no[0]) < 0 {
+		return nil, nil, ErrHeaderUnknown
+	}
+	e, found := r.routes[no[0]]
+	if !found {
+		return nil, nil, ErrHeaderUnknown
+	}
+	id, err := e.Decode(no)
+	if err != nil {
+		return nil, nil, err
+	}
+	return id, e.decoder, nil
+}
@@ -0,0 +1,37 @@
+package tsid
+
+import "testing"
+
+func TestDecodeAny(t *testing.T) {
+	if _, e := Describe(Default(), 4, &Base64{}); e != ErrEncoderID {
+		t.Errorf("want: error(%s), got: %v", ErrEncoderID, e)
+	}
+	a, _ := Describe(Default(), 0, &Base64{})
+	b, _ := Describe(Default(), 1, &Base64{Aligned: true})
+	c, _ := Describe(Shuffle(), 2, &Base64{})
+	if _, e := NewRouter(a, a); e != ErrHeaderConflict {
+		t.Errorf("want: error(%s), got: %v", ErrHeaderConflict, e)
+	}
+	r, e := NewRouter(a, b, c)
+	if e != nil {
+		t.Fatalf("want: a router, got: error %s", e)
+		return
+	}
+	for _, o := range []struct {
+		encoder *SelfDescribing
+		opt     Options
+	}{{a, Default()}, {b, Default()}, {c, Shuffle()}} {
+		m, _ := Make(o.opt)
+		id := m.Next()
+		no := o.encoder.Encode(id)
+		got, d, e := r.DecodeAny(no)
+		if e != nil || !got.Equal(id) || d != o.encoder.Decoder() {
+			t.Errorf("want: %s, got: %v, error %v", id, got, e)
+		}
+	}
+	for _, no := range []string{"", "[", string(base64Digits[63]) + "0"} {
+		if _, _, e = r.DecodeAny(no); e == nil {
+			t.Errorf("%q want: error, got: nothing", no)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package tsid
+
+import "strconv"
+
+// DuplicatePolicy indicates how Make handles the redundant or conflicting segments
+type DuplicatePolicy int
+
+const (
+	// DuplicateAllow ignores the redundant or conflicting segments
+	DuplicateAllow DuplicatePolicy = iota
+	// DuplicateWarn records them as the warnings of the builder, see Builder.Warnings
+	DuplicateWarn
+	// DuplicateReject makes Make fail
+	DuplicateReject
+)
+
+// duplicates returns the errors of the redundant or conflicting segments:
+// two absolute timestamps, two sequences, or the environment and settings
+// segments bound to the same key with different fallbacks.
+func duplicates(segments []Bits) (errs []*OptionsError) {
+	timestamp, sequence := -1, -1
+	keys := map[string]int{}
+	for i, segment := range segments {
+		index := strconv.Itoa(i)
+		switch segment.Source {
+		case DateTime:
+			if !DateTimeType(segment.Index).timestamp() {
+				break
+			}
+			if timestamp >= 0 {
+				errs = append(errs, invalidOption("Segments", errorSegmentDuplicate, strconv.Itoa(timestamp), index))
+			} else {
+				timestamp = i
+			}
+		case SequenceID:
+			if sequence >= 0 {
+				errs = append(errs, invalidOption("Segments", errorSegmentDuplicate, strconv.Itoa(sequence), index))
+			} else {
+				sequence = i
+			}
+		case OS, Settings:
+			if j, found := keys[segment.Key]; found {
+				if segments[j].Value != segment.Value {
+					errs = append(errs, invalidOption("Segments", errorSegmentConflict, strconv.Itoa(j), index))
+				} else if segments[j].Source == segment.Source {
+					errs = append(errs, invalidOption("Segments", errorSegmentDuplicate, strconv.Itoa(j), index))
+				}
+			} else {
+				keys[segment.Key] = i
+			}
+		}
+	}
+	return errs
+}
+
+// Warnings returns the problems of the options found by Make,
+// which are not serious enough to fail.
+func (b *Builder) Warnings() []error {
+	errs := make([]error, len(b.warnings))
+	for i, w := range b.warnings {
+		errs[i] = w
+	}
+	return errs
+}
@@ -0,0 +1,46 @@
+package tsid
+
+import "testing"
+
+func TestDuplicates(t *testing.T) {
+	tests := []struct {
+		name     string
+		segments []Bits
+		want     string
+	}{
+		{"Timestamp", []Bits{Sequence(12), Timestamp(20, TimestampSeconds), Timestamp(41, TimestampMilliseconds)}, errorSegmentDuplicate},
+		{"Sequence", []Bits{Sequence(12), Sequence(8), Timestamp(41, TimestampMilliseconds)}, errorSegmentDuplicate},
+		{"Conflict", []Bits{Sequence(12), Env(4, "Node", 1), Option(4, "Node", 2), Timestamp(41, TimestampMilliseconds)}, errorSegmentConflict},
+		{"Redundant", []Bits{Sequence(12), Env(4, "Node", 1), Env(4, "Node", 1), Timestamp(41, TimestampMilliseconds)}, errorSegmentDuplicate},
+		{"None", []Bits{Sequence(12), Env(4, "Node", 1), Option(4, "Node", 1), Timestamp(10, TimeMillisecond), Timestamp(31, TimestampSeconds)}, ""},
+	}
+	for _, o := range tests {
+		t.Run(o.name, func(t *testing.T) {
+			opt := Options{segments: o.segments}
+			if _, e := Make(opt); e != nil {
+				t.Fatalf("DuplicateAllow want: a builder instance, got: error %s", e)
+				return
+			}
+			opt.Duplicates = DuplicateWarn
+			b, e := Make(opt)
+			if e != nil {
+				t.Fatalf("DuplicateWarn want: a builder instance, got: error %s", e)
+				return
+			}
+			opt.Duplicates = DuplicateReject
+			_, e = Make(opt)
+			if o.want == "" {
+				if len(b.Warnings()) > 0 || e != nil {
+					t.Errorf("want: no warnings, got: %v, error %v", b.Warnings(), e)
+				}
+				return
+			}
+			if w := b.Warnings(); len(w) != 1 || !invalidOption("Segments", o.want).SameAs(w[0]) {
+				t.Errorf("DuplicateWarn want: warning(%s), got: %v", o.want, w)
+			}
+			if x, f := e.(*OptionsError); !f || x.Err.Error() != o.want || len(x.Extra) != 2 {
+				t.Errorf("DuplicateReject want: error(%s), got: %v", o.want, e)
+			}
+		})
+	}
+}
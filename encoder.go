@@ -1,7 +1,6 @@
 package tsid
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	"math/bits"
@@ -16,13 +15,29 @@ type Encoder interface {
 
 const (
 	base64Digits   = "0xHqN63nKLpM1hJRwZ9jklm.Y4aPoIiQA2DrsVB5Ob7CzcFGdv8U-EefgWXtuSTy"
-	base64Signed   = '!'
+	sortableDigits = "-0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ_abcdefghijklmnopqrstuvwxyz"
 	base64Widths   = 11
 	base64Paddings = "00000000000000000000"
 )
 
 type Base64 struct {
 	Aligned bool
+	// Width is the width of the layout, which derives the widths of the aligned
+	// parts, e.g. 6 digits of the 32 bits layouts, and the extension part of the
+	// layouts wider than 63 bits is always encoded. Zero means 11 digits of each
+	// part, see Builder.Base64
+	Width byte
+	// Strict is used to reject the non-canonical encodings on Decode, e.g. the
+	// unnecessary paddings, which decode to the same ID as the canonical one,
+	// the missing paddings of the aligned parts and the misplaced sign marker.
+	// The lenient decoding (default) accepts every string of the same ID.
+	Strict bool
+	// Checksum is used to append a check character, which is validated on Decode
+	// to catch the transcription errors, see checksum
+	Checksum bool
+	// Sign is the prefix of the signed IDs, a printable ASCII character other
+	// than the digits, e.g. '~'. Zero means '!'
+	Sign byte
 }
 
 type DecodeError struct {
@@ -44,6 +59,18 @@ func decodeError(no string, errorType decodeErrorType) *DecodeError {
 }
 
 func (e *Base64) Encode(id *ID) string {
+	sign := signOf(e.Sign)
+	s := encodeBase64(id, base64Digits, sign, e.Width, e.Aligned)
+	if e.Checksum {
+		c, _ := checksum(s, base64Digits, sign)
+		s += string(c)
+	}
+	return s
+}
+
+// encodeBase64 encodes the ID by the 64 digits alphabet and the sign marker,
+// the first digit is used as the padding.
+func encodeBase64(id *ID, digits string, sign, width byte, aligned bool) string {
 	s := [2]struct {
 		val int64  // value
 		buf []byte // string buffers
@@ -52,26 +79,41 @@ func (e *Base64) Encode(id *ID) string {
 	}{}
 	s[0].val = id.Ext
 	s[1].val = id.Main
+	// ws are the aligned widths of the parts
+	ws := [2]int{base64Widths, base64Widths}
+	if width > bitsMaxWidth {
+		ws[0] = (int(width) - bitsMaxWidth + 5) / 6
+	} else if width > 0 {
+		ws[1] = (int(width) + 5) / 6
+	}
+	fixed := aligned && width > 0
 	// g is the capacity of the builder's underlying byte slice.
 	g := 0
 	for i, p := range s {
 		if p.val <= 0 {
-			// ignore negative value or zero
+			// ignore negative value or zero, unless the width is fixed
+			if fixed && (i == 1 || width > bitsMaxWidth) {
+				s[i].pad = ws[i]
+				g += ws[i]
+			}
 			continue
 		}
-		s[i].buf = formatBits(p.val)
+		s[i].buf = formatBits(p.val, digits)
 		s[i].len = len(s[i].buf)
-		if e.Aligned && base64Widths > s[i].len {
-			s[i].pad = base64Widths - s[i].len
+		if aligned && ws[i] > s[i].len {
+			s[i].pad = ws[i] - s[i].len
 		}
 		g += s[i].len + s[i].pad
 	}
-	if id.Ext > 0 && base64Widths > s[1].len {
+	if (id.Ext > 0 || s[0].pad > 0) && base64Widths > s[1].len {
 		s[1].pad = base64Widths - s[1].len
 		g += s[1].pad
 	}
 	if g == 0 {
-		return base64Digits[:1]
+		if id.Signed {
+			return string([]byte{sign, digits[0]})
+		}
+		return digits[:1]
 	}
 	if id.Signed {
 		g += 1
@@ -80,11 +122,11 @@ func (e *Base64) Encode(id *ID) string {
 	b := strings.Builder{}
 	b.Grow(g)
 	if id.Signed {
-		b.WriteByte(base64Signed)
+		b.WriteByte(sign)
 	}
 	for i := 0; i < 2; i++ {
-		if s[i].pad > 0 {
-			b.Write([]byte(base64Paddings)[:s[i].pad])
+		for j := 0; j < s[i].pad; j++ {
+			b.WriteByte(digits[0])
 		}
 		if s[i].len > 0 {
 			b.Write(s[i].buf)
@@ -93,6 +135,77 @@ func (e *Base64) Encode(id *ID) string {
 	return b.String()
 }
 
+// SortableBase64 encodes the IDs by the URL-safe 64 digits in ASCII order with
+// the fixed width, so the byte order of the strings is the order of the IDs, e.g.
+// for the ordered keys of Redis, S3 and LevelDB. The signed IDs are not ordered.
+type SortableBase64 struct {
+	// Wide is used to encode the extension part of every ID, which keeps the
+	// order of the layouts wider than 63 bits when the extension part is zero
+	Wide bool
+	// Checksum is used to append a check character, see Base64.Checksum
+	Checksum bool
+	// Strict is used to reject the strings not of the fixed width on Decode,
+	// see Base64.Strict
+	Strict bool
+	// Sign is the prefix of the signed IDs, see Base64.Sign
+	Sign byte
+}
+
+func (e *SortableBase64) Encode(id *ID) string {
+	n := base64Widths
+	if e.Wide || id.Ext > 0 {
+		n *= 2
+	}
+	sign := signOf(e.Sign)
+	buf := make([]byte, 0, n+2)
+	if id.Signed {
+		buf = append(buf, sign)
+	}
+	if n > base64Widths {
+		buf = appendSortable(buf, id.Ext)
+	}
+	buf = appendSortable(buf, id.Main)
+	if e.Checksum {
+		c, _ := checksum(string(buf), sortableDigits, sign)
+		buf = append(buf, c)
+	}
+	return string(buf)
+}
+
+func (e *SortableBase64) Decode(no string) (*ID, error) {
+	sign := signOf(e.Sign)
+	if !validSign(sign, sortableDigits) {
+		return nil, signError(no)
+	}
+	if e.Strict {
+		if err := checkSign(no, sign); err != nil {
+			return nil, err
+		}
+	}
+	s := no
+	if e.Checksum {
+		var err error
+		if s, err = verifyChecksum(no, sortableDigits, sign); err != nil {
+			return nil, err
+		}
+	}
+	id, err := decodeBase64(s, sortableDigits, sign)
+	if err == nil && e.Strict {
+		if err = checkCanonical(no, e.Encode(id)); err != nil {
+			return nil, err
+		}
+	}
+	return id, err
+}
+
+// appendSortable appends the base64Widths digits of v, which is not negative
+func appendSortable(buf []byte, v int64) []byte {
+	for i := base64Widths - 1; i >= 0; i-- {
+		buf = append(buf, sortableDigits[uint64(v)>>(6*i)&63])
+	}
+	return buf
+}
+
 type decodeErrorType int
 
 const (
@@ -100,6 +213,10 @@ const (
 	DecodeErrorInvalidDigit
 	DecodeErrorOverflow
 	DecodeErrorOutOfRange
+	DecodeErrorNonCanonical
+	DecodeErrorChecksum
+	DecodeErrorLength
+	DecodeErrorSign
 )
 
 var decodeErrors = map[decodeErrorType]string{
@@ -107,15 +224,133 @@ var decodeErrors = map[decodeErrorType]string{
 	DecodeErrorInvalidDigit: "invalid base64 digit",
 	DecodeErrorOverflow:     "number overflows",
 	DecodeErrorOutOfRange:   "value out of range",
+	DecodeErrorNonCanonical: "non-canonical encoding",
+	DecodeErrorChecksum:     "checksum mismatch",
+	DecodeErrorLength:       "invalid length",
+	DecodeErrorSign:         "misplaced sign marker",
+}
+
+// checksum returns the check digit of the encoded string s by the Damm algorithm
+// over GF(n) of the n digits (a power of two, 4 to 64), x∘y = 2x ⊕ y, which
+// detects every single substituted digit and every adjacent transposition.
+// The sign marker starts the interim at 1.
+func checksum(s, digits string, sign byte) (byte, bool) {
+	poly, found := gfPolynomials[len(digits)]
+	if !found {
+		return 0, false
+	}
+	interim := 0
+	for i := 0; i < len(s); i++ {
+		if i == 0 && s[0] == sign {
+			interim = 1
+			continue
+		}
+		d := strings.IndexByte(digits, s[i])
+		if d < 0 {
+			return 0, false
+		}
+		interim = gfDouble(interim, len(digits), poly) ^ d
+	}
+	return digits[gfDouble(interim, len(digits), poly)], true
+}
+
+// gfPolynomials are the primitive polynomials of GF(n) by n
+var gfPolynomials = map[int]int{
+	4:  0x7,  // x^2 + x + 1
+	8:  0xb,  // x^3 + x + 1
+	16: 0x13, // x^4 + x + 1
+	32: 0x25, // x^5 + x^2 + 1
+	64: 0x43, // x^6 + x + 1
+}
+
+// gfDouble returns 2x in GF(n) of the primitive polynomial poly
+func gfDouble(x, n, poly int) int {
+	x <<= 1
+	if x >= n {
+		x ^= poly
+	}
+	return x
+}
+
+// verifyChecksum returns the string without the check digit, see checksum
+func verifyChecksum(no, digits string, sign byte) (string, error) {
+	n := len(no) - 1
+	if n < 1 {
+		return "", decodeError(no, DecodeErrorEmpty)
+	}
+	if c, ok := checksum(no[:n], digits, sign); !ok || c != no[n] {
+		return "", decodeError(no, DecodeErrorChecksum)
+	}
+	return no[:n], nil
 }
 
 func (e *Base64) Decode(no string) (id *ID, err error) {
+	if !validSign(signOf(e.Sign), base64Digits) {
+		return nil, signError(no)
+	}
+	if e.Strict {
+		if err = checkSign(no, signOf(e.Sign)); err != nil {
+			return nil, err
+		}
+	}
+	id, err = e.decode(no)
+	if err == nil && e.Strict {
+		if err = checkCanonical(no, e.Encode(id)); err != nil {
+			return nil, err
+		}
+	}
+	return id, err
+}
+
+// checkSign returns the error of the sign marker after the first character,
+// which the strict decoding reports before the digits
+func checkSign(no string, sign byte) error {
+	if len(no) > 1 && strings.IndexByte(no[1:], sign) >= 0 {
+		return decodeError(no, DecodeErrorSign)
+	}
+	return nil
+}
+
+// checkCanonical returns the error of the strict decoding if no differs from
+// the canonical encoding of its ID: the longer strings have the unnecessary
+// paddings, and the shorter ones miss the paddings of the fixed width
+func checkCanonical(no, canonical string) error {
+	switch {
+	case len(no) < len(canonical):
+		return decodeError(no, DecodeErrorLength)
+	case no != canonical:
+		return decodeError(no, DecodeErrorNonCanonical)
+	}
+	return nil
+}
+
+// IsCanonical reports whether the string is the encoding of its ID by the encoder
+func (e *Base64) IsCanonical(no string) bool {
+	id, err := e.decode(no)
+	return err == nil && e.Encode(id) == no
+}
+
+func (e *Base64) decode(no string) (*ID, error) {
+	sign := signOf(e.Sign)
+	if e.Checksum {
+		s, err := verifyChecksum(no, base64Digits, sign)
+		if err != nil {
+			return nil, err
+		}
+		no = s
+	}
+	return decodeBase64(no, base64Digits, sign)
+}
+
+// decodeBase64 decodes the string encoded by encodeBase64 with the same digits
+// and sign marker
+func decodeBase64(no, digits string, sign byte) (id *ID, err error) {
 	w := len(no)
 	if w < 1 {
 		return nil, decodeError(no, DecodeErrorEmpty)
 	}
 	i := 0
-	s := no[0] == base64Signed
+	s := no[0] == sign
 	if s {
 		i++
 		w--
@@ -134,12 +369,12 @@ func (e *Base64) Decode(no string) (id *ID, err error) {
 		m = no
 	}
 	var main, ext int64
-	main, err = parseBits(m)
+	main, err = parseBits(m, digits)
 	if err != nil {
 		return nil, err
 	}
 	if len(x) > 0 {
-		ext, err = parseBits(x)
+		ext, err = parseBits(x, digits)
 		if err != nil {
 			return nil, err
 		}
@@ -156,7 +391,7 @@ func (e *Base64) Decode(no string) (id *ID, err error) {
 // If neg is set, u is treated as negative int64 value.
 // From: `$GOROOT/src/strconv/itoa.go`
 // [https://cs.opensource.google/go/go/+/refs/tags/go1.16:src/strconv/itoa.go]
-func formatBits(u int64) []byte {
+func formatBits(u int64, digits string) []byte {
 	var a [64]byte
 	i := len(a)
 	s := u < 0
@@ -168,51 +403,33 @@ func formatBits(u int64) []byte {
 	m := uint(64) - 1 // == 1<<shift - 1
 	for v >= 64 {
 		i--
-		a[i] = base64Digits[uint(v)&m]
+		a[i] = digits[uint(v)&m]
 		v >>= shift
 	}
 	// u < base
 	i--
-	a[i] = base64Digits[uint(v)]
+	a[i] = digits[uint(v)]
 	return a[i:]
 }
 
 // From: `$GOROOT/src/strconv/atoi.go`
 // [https://cs.opensource.google/go/go/+/refs/tags/go1.16:src/strconv/atoi.go]
-func parseBits(s string) (v int64, err error) {
+func parseBits(s, digits string) (v int64, err error) {
 	if s == "" {
 		return 0, decodeError(s, DecodeErrorEmpty)
 	}
-	b := 64
-	maxVal := uint64(1)<<uint(b) - 1
 	var n uint64
 	for _, c := range []byte(s) {
-		d := bytes.IndexByte([]byte(base64Digits), c)
+		d := strings.IndexByte(digits, c)
 		if d < 0 {
 			return 0, decodeError(s, DecodeErrorInvalidDigit)
 		}
-		if n >= cutoff {
-			// n*base overflows
-			n = maxVal
-			err = decodeError(s, DecodeErrorOverflow)
-			break
+		if n > (uint63Max-uint64(d))/64 {
+			// n*base+d overflows the 63 bits
+			return 0, decodeError(s, DecodeErrorOverflow)
 		}
-		n *= 64
-		n1 := n + uint64(d)
-		if n1 < n || n1 > maxVal {
-			// n+d overflows
-			n = maxVal
-			err = decodeError(s, DecodeErrorOverflow)
-			break
-		}
-		n = n1
-	}
-
-	co := uint64(1 << uint(63))
-	if n >= co {
-		return int64(co - 1), decodeError(s, DecodeErrorOutOfRange)
+		n = n*64 + uint64(d)
 	}
-
 	return int64(n), nil
 }
 
@@ -1,6 +1,10 @@
 package tsid
 
 import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -111,3 +115,249 @@ func BenchmarkBase64DecodeExt(b *testing.B) {
 		}
 	}
 }
+
+func FuzzBase64Decode(f *testing.F) {
+	for _, c := range loadMalformed(f) {
+		f.Add(c.input)
+	}
+	f.Add("0")
+	f.Add("!xHqN63nKLpM1")
+	f.Fuzz(func(t *testing.T, no string) {
+		e := Base64{}
+		id, err := e.Decode(no)
+		if err != nil {
+			if id != nil {
+				t.Fatalf("want: no ID with error %s, got: %s", err, id)
+			}
+			return
+		}
+		if id.Main < 0 || id.Ext < 0 {
+			t.Fatalf("want: non-negative parts, got: %d, %d", id.Main, id.Ext)
+		}
+		again, err := e.Decode(e.Encode(id))
+		if err != nil || !again.Equal(id) {
+			t.Fatalf("want: %+v, got: %+v, error %v", id, again, err)
+		}
+	})
+}
+
+type malformedCase struct {
+	input string
+	want  decodeErrorType
+}
+
+// loadMalformed loads the corpus of the malformed encodings,
+// one case per line: the quoted input, a tab, and the error name.
+func loadMalformed(tb testing.TB) (cases []malformedCase) {
+	buf, err := os.ReadFile("testdata/malformed.tsv")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	names := map[string]decodeErrorType{
+		"empty":         DecodeErrorEmpty,
+		"invalid_digit": DecodeErrorInvalidDigit,
+		"overflow":      DecodeErrorOverflow,
+		"out_of_range":  DecodeErrorOutOfRange,
+	}
+	for _, line := range strings.Split(string(buf), "\n") {
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		input, err := strconv.Unquote(fields[0])
+		if err != nil || len(fields) != 2 {
+			tb.Fatalf("malformed corpus line: %s", line)
+		}
+		cases = append(cases, malformedCase{input, names[fields[1]]})
+	}
+	return cases
+}
+
+func TestMalformed(t *testing.T) {
+	e := Base64{}
+	for _, c := range loadMalformed(t) {
+		id, err := e.Decode(c.input)
+		if x, o := err.(*DecodeError); !o || x.Type != c.want {
+			t.Errorf("%q want: error type %d, got: %v, %v", c.input, c.want, id, err)
+		}
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	id := &ID{Main: 12345}
+	for _, aligned := range []bool{true, false} {
+		e := Base64{Aligned: aligned, Strict: true}
+		s := e.Encode(id)
+		if !e.IsCanonical(s) || !e.IsCanonical("!"+s) {
+			t.Errorf("want: canonical %s, got: false", s)
+		}
+		for _, bad := range []string{"0" + s, "00000000000000" + s} {
+			if e.IsCanonical(bad) {
+				t.Errorf("want: non-canonical %s, got: true", bad)
+			}
+			_, err := e.Decode(bad)
+			if x, o := err.(*DecodeError); !o || x.Type != DecodeErrorNonCanonical {
+				t.Errorf("want: non-canonical error, got: %v", err)
+			}
+			e.Strict = false
+			if v, err := e.Decode(bad); err != nil || v.Main != id.Main {
+				t.Errorf("want: lenient %d, got: %v, error %v", id.Main, v, err)
+			}
+			e.Strict = true
+		}
+	}
+	if s := id.String(); !IsCanonical(s) || IsCanonical("0"+s) || IsCanonical("") {
+		t.Errorf("want: canonical only %s, got: %v", s, IsCanonical("0"+s))
+	}
+}
+
+func TestSortableBase64(t *testing.T) {
+	for _, c := range []struct {
+		id   ID
+		wide bool
+		want string
+	}{
+		{ID{}, false, "-----------"},
+		{ID{Main: 63}, false, "----------z"},
+		{ID{Main: 1<<63 - 1}, false, "6zzzzzzzzzz"},
+		{ID{Main: 1}, true, "---------------------0"},
+		{ID{Main: 1, Ext: 64}, false, "---------0-----------0"},
+	} {
+		e := &SortableBase64{Wide: c.wide}
+		if s := e.Encode(&c.id); s != c.want {
+			t.Errorf("want: %s, got: %s", c.want, s)
+		}
+	}
+	b, _ := Make(Shuffle())
+	e := &SortableBase64{Wide: true}
+	prev, p := "", &ID{}
+	for i := 0; i < 1000; i++ {
+		id := b.Next()
+		s := e.Encode(id)
+		if (s > prev) != (id.Ext > p.Ext || id.Ext == p.Ext && id.Main > p.Main) {
+			t.Errorf("want: the order of %v and %v, got: %s, %s", p, id, prev, s)
+		}
+		if got, err := e.Decode(s); err != nil || !got.Equal(id) {
+			t.Errorf("want: %v, got: %v, error %v", id, got, err)
+		}
+		prev, p = s, id
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	for _, e := range []Encoder{&Base64{Checksum: true, Strict: true}, &SortableBase64{Checksum: true}} {
+		digits := base64Digits
+		if _, found := e.(*SortableBase64); found {
+			digits = sortableDigits
+		}
+		for i := 0; i < 20; i++ {
+			id := &ID{Main: Rand(63), Ext: Rand(byte(i + 1)), Signed: i%2 == 0}
+			s := e.Encode(id)
+			if got, err := e.Decode(s); err != nil || !got.Equal(id) || got.Signed != id.Signed {
+				t.Fatalf("want: %v, got: %v, error %v", id, got, err)
+				return
+			}
+			buf := []byte(s)
+			for j := range buf {
+				if buf[j] == defaultSignMarker {
+					continue
+				}
+				c := buf[j]
+				buf[j] = digits[(strings.IndexByte(digits, c)+1+i)%64]
+				if _, err := e.Decode(string(buf)); err == nil {
+					t.Errorf("want: error of %q, got: nothing", buf)
+				}
+				buf[j] = c
+				if k := j + 1; k < len(buf) && buf[k] != c {
+					buf[j], buf[k] = buf[k], buf[j]
+					if _, err := e.Decode(string(buf)); err == nil {
+						t.Errorf("want: error of %q, got: nothing", buf)
+					}
+					buf[j], buf[k] = buf[k], buf[j]
+				}
+			}
+			if id.Signed {
+				if _, err := e.Decode(s[1:]); err == nil {
+					t.Errorf("want: error of %q, got: nothing", s[1:])
+				}
+			}
+		}
+	}
+	var de *DecodeError
+	if _, err := (&Base64{Checksum: true}).Decode("0"); !errors.As(err, &de) || de.Type != DecodeErrorEmpty {
+		t.Errorf("want: empty, got: %v", err)
+	}
+	if _, err := (&Base64{Checksum: true}).Decode("xx"); !errors.As(err, &de) || de.Type != DecodeErrorChecksum {
+		t.Errorf("want: checksum mismatch, got: %v", err)
+	}
+}
+
+func TestBase64Width(t *testing.T) {
+	for _, c := range []struct {
+		opt  Options
+		want int
+	}{
+		{Default(), 11},
+		{OpenID(), 22},
+		{*Segments(Sequence(12), Random(35), Timestamp(41, TimestampMilliseconds)), 11 + 5},
+	} {
+		b, err := Make(c.opt)
+		if err != nil {
+			t.Fatalf("want: builder, got: error %s", err)
+			return
+		}
+		e := b.Base64()
+		e.Strict = true
+		for _, id := range []*ID{b.Next(), {}, {Main: 1}} {
+			s := e.Encode(id)
+			if len(s) != c.want {
+				t.Errorf("want: %d digits, got: %s", c.want, s)
+			}
+			if got, err := e.Decode(s); err != nil || !got.Equal(id) {
+				t.Errorf("want: %v, got: %v, error %v", id, got, err)
+			}
+		}
+	}
+	e := &Base64{Aligned: true, Width: 32}
+	if s := e.Encode(&ID{Main: 1}); s != "00000x" {
+		t.Errorf("want: 00000x, got: %s", s)
+	}
+}
+
+func TestStrictDecoding(t *testing.T) {
+	base, _ := NewBaseEncoder(Base32Crockford, BaseAligned, BaseStrict)
+	for _, c := range []struct {
+		e    Encoder
+		no   string
+		want decodeErrorType
+	}{
+		{&Base64{Aligned: true, Strict: true}, "x", DecodeErrorLength},
+		{&Base64{Aligned: true, Strict: true}, "0000000000x!", DecodeErrorSign},
+		{&Base64{Strict: true}, "0x", DecodeErrorNonCanonical},
+		{&SortableBase64{Strict: true}, "0", DecodeErrorLength},
+		{&SortableBase64{Strict: true}, "-----------z-", DecodeErrorNonCanonical},
+		{&Base36{Strict: true}, "1", DecodeErrorLength},
+		{&Base36{Strict: true}, "!0000000000001", DecodeErrorNonCanonical},
+		{&Base36{Strict: true}, "0000000000001!", DecodeErrorSign},
+		{base, "1", DecodeErrorLength},
+		{base, "000000000000!", DecodeErrorSign},
+	} {
+		var de *DecodeError
+		if _, err := c.e.Decode(c.no); !errors.As(err, &de) || de.Type != c.want {
+			t.Errorf("%q want: %s, got: %v", c.no, decodeErrors[c.want], err)
+		}
+	}
+	for _, c := range []struct {
+		e  Encoder
+		no string
+	}{
+		{&Base64{Aligned: true}, "x"},
+		{&SortableBase64{}, "0"},
+		{&Base36{}, "1"},
+		{&Base36{}, "-1"},
+	} {
+		if id, err := c.e.Decode(c.no); err != nil || id.Main != 1 {
+			t.Errorf("%q want: lenient 1, got: %v, error %v", c.no, id, err)
+		}
+	}
+}
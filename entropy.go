@@ -0,0 +1,115 @@
+package tsid
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	cr "crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrRandUnavailable indicates that the random source fails, see RandError
+var ErrRandUnavailable = errors.New("tsid: the random source is unavailable")
+
+// RandPolicy indicates how the builder handles the failures of the random source
+// (fd exhaustion, sandboxes) of the RandomID segments, see Options.OnRandFailure
+type RandPolicy int
+
+const (
+	// RandFallback reads the in-process fallback keystream instead, which is NOT
+	// secure, see fallbackRand; the source is tried again by the next read
+	RandFallback RandPolicy = iota
+	// RandError fails the generation with ErrRandUnavailable
+	RandError
+	// RandBlock retries the source with backoff until it recovers
+	RandBlock
+)
+
+// RandEvents is implemented by the Metrics which receive the failures of the
+// random source, see Options.Metrics
+type RandEvents interface {
+	RandFailure(err error)
+}
+
+// RandFailure implements RandEvents
+func (c *Counters) RandFailure(error) {
+	atomic.AddUint64(&c.randFailures, 1)
+}
+
+// randBackoff is the maximum delay between the retries of RandBlock
+const randBackoff = time.Second
+
+// random returns a random number of the width w for the RandomID segments
+func (b *Builder) random(w byte) (int64, error) {
+	delay := time.Millisecond
+	for {
+		var v int64
+		var err error
+		if b.rand != nil {
+			v, err = b.rand.read(w)
+		} else {
+			v, err = readRand(b.options.Environment.random(), w)
+		}
+		if err == nil {
+			return v, nil
+		}
+		if e, ok := b.options.Metrics.(RandEvents); ok {
+			e.RandFailure(err)
+		}
+		switch b.options.OnRandFailure {
+		case RandFallback:
+			return b.fallback.read(w), nil
+		case RandBlock:
+			time.Sleep(delay)
+			if delay *= 2; delay > randBackoff {
+				delay = randBackoff
+			}
+		default:
+			return 0, ErrRandUnavailable
+		}
+	}
+}
+
+// fallbackSeed is read from crypto/rand at init, while the source is likely
+// available, and keys the fallback keystreams of the builders
+var fallbackSeed [32]byte
+
+func init() {
+	_, _ = io.ReadFull(cr.Reader, fallbackSeed[:])
+}
+
+// fallbackRand is the weak fallback of RandFallback, the AES-CTR keystream keyed
+// by the hash of fallbackSeed, the time and the process. It is NOT secure: the
+// output is predictable if the seed is known or crypto/rand failed at init.
+type fallbackRand struct {
+	once   sync.Once
+	mu     sync.Mutex
+	stream cipher.Stream
+}
+
+// read returns a random number of the width w
+func (f *fallbackRand) read(w byte) int64 {
+	f.once.Do(func() {
+		var seed [48]byte
+		copy(seed[:], fallbackSeed[:])
+		binary.LittleEndian.PutUint64(seed[32:], uint64(time.Now().UnixNano()))
+		binary.LittleEndian.PutUint64(seed[40:], uint64(os.Getpid()))
+		key := sha256.Sum256(seed[:])
+		block, _ := aes.NewCipher(key[:16])
+		f.stream = cipher.NewCTR(block, key[16:])
+	})
+	if w < 1 || w > 63 {
+		return 0
+	}
+	var a [8]byte
+	f.mu.Lock()
+	f.stream.XORKeyStream(a[:], a[:])
+	f.mu.Unlock()
+	return int64(binary.LittleEndian.Uint64(a[:]) & (1<<w - 1))
+}
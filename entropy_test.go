@@ -0,0 +1,83 @@
+package tsid
+
+import (
+	"errors"
+	"testing"
+)
+
+// failingReader fails the first failures reads
+type failingReader struct {
+	reads, failures int
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	f.reads++
+	if f.failures < 0 || f.reads <= f.failures {
+		return 0, errors.New("too many open files")
+	}
+	for i := range p {
+		p[i] = 0x5A
+	}
+	return len(p), nil
+}
+
+func TestRandFailure(t *testing.T) {
+	opt := *Config(1, 2, Sequence(10), Random(16), Timestamp(41, TimestampMilliseconds))
+	c := &Counters{}
+	opt.Metrics = c
+	opt.Environment = &Environment{Rand: &failingReader{failures: -1}}
+	opt.OnRandFailure = RandError
+	b, _ := Make(opt)
+	if _, e := b.TryNext(); e != ErrRandUnavailable {
+		t.Errorf("want: %s, got: %v", ErrRandUnavailable, e)
+	}
+
+	opt.OnRandFailure = RandFallback
+	b, _ = Make(opt)
+	seen := map[int64]bool{}
+	for i := 0; i < 50; i++ {
+		id, e := b.TryNext()
+		if e != nil {
+			t.Fatalf("want: an ID, got: error %s", e)
+			return
+		}
+		vs, _ := b.Decoder().Decompose(id)
+		seen[vs[1]] = true
+	}
+	if len(seen) < 45 {
+		t.Errorf("want: distinct random values, got: %d", len(seen))
+	}
+
+	opt.OnRandFailure = RandBlock
+	opt.Environment = &Environment{Rand: &failingReader{failures: 2}}
+	b, _ = Make(opt)
+	id, e := b.TryNext()
+	if vs, _ := b.Decoder().Decompose(id); e != nil || vs[1] != 0x5A5A {
+		t.Errorf("want: 0x5A5A after retries, got: %v, error %v", id, e)
+	}
+	if s := c.Snapshot(); s.RandFailures != 53 {
+		t.Errorf("want: 53 failures, got: %d", s.RandFailures)
+	}
+
+	// the random pool reports the failures as well
+	opt.OnRandFailure = RandError
+	opt.RandPool = &RandPool{ChunkSize: 64}
+	opt.Environment = &Environment{Rand: &failingReader{failures: -1}}
+	b, _ = Make(opt)
+	if _, e = b.TryNext(); e != ErrRandUnavailable {
+		t.Errorf("want: %s, got: %v", ErrRandUnavailable, e)
+	}
+	if v := b.NextInt64(); v != 0 {
+		t.Errorf("want: 0, got: %d", v)
+	}
+	if s := b.NextString(); s != "" {
+		t.Errorf("want: empty, got: %s", s)
+	}
+
+	// the zero value falls back instead of failing
+	opt.OnRandFailure = 0
+	b, _ = Make(opt)
+	if v := b.NextInt64(); v == 0 {
+		t.Error("want: an ID by the fallback, got: 0")
+	}
+}
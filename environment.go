@@ -0,0 +1,86 @@
+package tsid
+
+import (
+	cr "crypto/rand"
+	"hash/fnv"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// EnvHostname is the name of the Env segments whose value is the FNV-1a hash
+// of the host name, truncated to the width of the segment
+const EnvHostname = "@hostname"
+
+// Environment is the time, random and OS sources of a builder, see Options.Environment.
+// The nil fields are backed by the OS.
+type Environment struct {
+	// Clock is the clock of the builder, which overrides Options.Monotonic
+	Clock Clock
+	// Rand is the source of the RandomID segments
+	Rand io.Reader
+	// Getenv returns the value of the environment variable, empty means unset
+	Getenv func(key string) string
+	// Hostname returns the host name, see EnvHostname
+	Hostname func() (string, error)
+	// Providers overrides the registered DataProviders by name, see Register
+	Providers map[string]DataProvider
+}
+
+// now returns the current time of the environment of the options
+func (o *Options) now() time.Time {
+	if o.Environment != nil && o.Environment.Clock != nil {
+		return o.Environment.Clock.Now()
+	}
+	return time.Now()
+}
+
+// random returns the source of the random numbers
+func (e *Environment) random() io.Reader {
+	if e != nil && e.Rand != nil {
+		return e.Rand
+	}
+	return cr.Reader
+}
+
+// provider returns the DataProvider of the name
+func (e *Environment) provider(name string) (DataProvider, bool) {
+	if e != nil {
+		if p, found := e.Providers[name]; found {
+			return p, true
+		}
+	}
+	p, found := dataSources[name]
+	return p, found
+}
+
+// lookupEnv returns the value of the environment variable, or the hash of the
+// host name for EnvHostname, found is false if it is unset.
+func (e *Environment) lookupEnv(key string, mask int64) (v int64, found bool, err error) {
+	if key == EnvHostname {
+		hostname := os.Hostname
+		if e != nil && e.Hostname != nil {
+			hostname = e.Hostname
+		}
+		s, err := hostname()
+		if err != nil {
+			return 0, false, nil
+		}
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(s))
+		return int64(h.Sum64()>>1) & mask, true, nil
+	}
+	var s string
+	if e != nil && e.Getenv != nil {
+		s = e.Getenv(key)
+		found = s != ""
+	} else {
+		s, found = os.LookupEnv(key)
+	}
+	if !found {
+		return 0, false, nil
+	}
+	v, err = strconv.ParseInt(s, 10, 64)
+	return v, true, err
+}
@@ -0,0 +1,56 @@
+package tsid
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEnvironment(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	env := &Environment{
+		Clock: NewManualClock(start, 0),
+		Rand:  bytes.NewReader(bytes.Repeat([]byte{0xAB}, 64)),
+		Getenv: func(key string) string {
+			if key == "TSID_TEST_NODE" {
+				return "5"
+			}
+			return ""
+		},
+		Hostname: func() (string, error) { return "node-1", nil },
+	}
+	opt := *Config(1, 2,
+		Sequence(10),
+		Random(8),
+		Env(4, "TSID_TEST_NODE", 0),
+		Env(6, EnvHostname, 0),
+		Timestamp(41, TimestampMilliseconds))
+	opt.Environment = env
+	b, e := Make(opt)
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	id, e := b.TryNext()
+	if e != nil {
+		t.Fatalf("want: an ID, got: error %s", e)
+		return
+	}
+	vs, _ := b.Decoder().Decompose(id)
+	host, _, _ := env.lookupEnv(EnvHostname, 63)
+	if vs[1] != 0xAB || vs[2] != 5 || vs[3] != host {
+		t.Errorf("want: values from the environment, got: %v", vs)
+	}
+	if ts, _ := b.TimeOf(id); !ts.Equal(start) {
+		t.Errorf("want: %s, got: %s", start, ts)
+	}
+
+	// the missing variables and host name use the fallback values
+	env.Getenv = func(string) string { return "" }
+	env.Hostname = func() (string, error) { return "", errors.New("no host") }
+	id, _ = b.TryNext()
+	if vs, _ = b.Decoder().Decompose(id); vs[2] != 0 || vs[3] != 0 {
+		t.Errorf("want: fallback values, got: %v", vs)
+	}
+}
@@ -0,0 +1,55 @@
+package tsid
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// OnEpochNearExhaustion to set a function that receives the remaining lifetime
+// of the timestamp, once the IDs are generated within ReservedDays (at least
+// EpochReservedDays) of its overflow, see Builder.Lifespan. It is invoked
+// asynchronously, once per builder and again after ResetEpoch.
+func (o *Options) OnEpochNearExhaustion(f func(remaining time.Duration)) *Options {
+	o.nearing = f
+	return o
+}
+
+// reserved returns the reserved lifetime of the epoch
+func (o *Options) reserved() time.Duration {
+	days := int64(EpochReservedDays)
+	if o.ReservedDays > days {
+		days = o.ReservedDays
+	}
+	return time.Duration(days) * msPerDay * time.Millisecond
+}
+
+// watchEpoch arms the OnEpochNearExhaustion callback at the start of the reserved
+// days of the epoch, with the builder locked or not shared yet
+func (b *Builder) watchEpoch() {
+	if b.options.nearing == nil {
+		return
+	}
+	t, found := b.options.expiry()
+	if !found {
+		atomic.StoreUint64(&b.nearing, 0)
+		return
+	}
+	at := t.Add(-b.options.reserved()).UnixMilli()
+	if at < 1 {
+		at = 1
+	}
+	atomic.StoreUint64(&b.nearing, uint64(at))
+}
+
+// nearExhaustion fires the OnEpochNearExhaustion callback at the time tr once
+func (b *Builder) nearExhaustion(tr *time.Time) {
+	n := atomic.LoadUint64(&b.nearing)
+	if n == 0 || !atomic.CompareAndSwapUint64(&b.nearing, n, 0) {
+		return
+	}
+	remaining := b.options.reserved() - tr.Sub(time.UnixMilli(int64(n)))
+	if remaining < 0 {
+		remaining = 0
+	}
+	go b.options.nearing(remaining)
+}
@@ -0,0 +1,70 @@
+package tsid
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOnEpochNearExhaustion(t *testing.T) {
+	fired := make(chan time.Duration, 2)
+	opt := Default()
+	opt.OnEpochNearExhaustion(func(remaining time.Duration) { fired <- remaining })
+	end, _ := opt.expiry()
+	c := NewManualClock(end.Add(-8*24*time.Hour), 0)
+	opt.Environment = &Environment{Clock: c}
+	b, err := Make(opt)
+	if err != nil {
+		t.Fatalf("want: builder, got: error %s", err)
+		return
+	}
+	b.Next()
+	select {
+	case r := <-fired:
+		t.Errorf("want: not fired, got: %s", r)
+	case <-time.After(10 * time.Millisecond):
+	}
+	c.Add(5 * 24 * time.Hour)
+	b.Next()
+	b.Next()
+	select {
+	case r := <-fired:
+		if r != 3*24*time.Hour {
+			t.Errorf("want: 72h, got: %s", r)
+		}
+	case <-time.After(time.Second):
+		t.Error("want: fired, got: none")
+	}
+	select {
+	case r := <-fired:
+		t.Errorf("want: fired once, got: again %s", r)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestResetEpochRoundTrip(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, e := range []int64{0, start.Add(-100 * 24 * time.Hour).UnixMilli()} {
+		opt := *Segments(Sequence(12), Timestamp(43, TimestampMilliseconds))
+		opt.Environment = &Environment{Clock: NewManualClock(start, 0)}
+		b, err := Make(opt)
+		if err != nil {
+			t.Fatalf("want: builder, got: error %s", err)
+			return
+		}
+		if err = b.ResetEpoch(e); err != nil {
+			t.Fatalf("want: nothing, got: error %s", err)
+			return
+		}
+		id, err := b.TryNext()
+		if err != nil {
+			t.Fatalf("want: an ID, got: error %s", err)
+			return
+		}
+		if got, _ := b.TimeOf(id); !got.Equal(start) {
+			t.Errorf("%d want: %s, got: %s", e, start, got)
+		}
+		if s := b.Stats(); !s.Epoch.Equal(time.UnixMilli(e)) {
+			t.Errorf("want: epoch %s, got: %s", time.UnixMilli(e), s.Epoch)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package tsid
+
+import "time"
+
+// ExampleID is an example ID with its decomposition, see Examples
+type ExampleID struct {
+	ID     ID
+	String string
+	// Time is the time restored from the timestamp, zero if the layout has none
+	Time   time.Time
+	Values []SegmentValue
+}
+
+// exampleStart is the offset of the first example from the epoch
+const exampleStart = 365 * msPerDay
+
+// Examples returns n deterministic example IDs of the layout for the documents,
+// which are stable across the releases given the same layout and seed.
+// The random and sequence values come from the seed, the timestamps increase
+// from one year after the epoch, and the other segments take their settings
+// or fallback values. It returns nil if the layout is invalid.
+func Examples(opt Options, n int, seed int64) []ExampleID {
+	d, err := NewDecoder(opt)
+	if err != nil || n <= 0 {
+		return nil
+	}
+	segments := d.options.segments
+	b := &Builder{options: d.options}
+	r := splitmix64(seed)
+	t := time.UnixMilli(epoch(d.options.EpochMS) + exampleStart).UTC()
+	examples := make([]ExampleID, n)
+	for i := range examples {
+		t = t.Add(time.Duration(r.next()%997+1) * time.Millisecond)
+		vs := make([]int64, len(segments))
+		for j := range segments {
+			segment := &segments[j]
+			v := segment.Value
+			switch segment.Source {
+			case Settings:
+				if s, found := d.options.settings[segment.Key]; found {
+					v = s
+				}
+			case SequenceID, RandomID:
+				v = int64(r.next() >> 1)
+			case DateTime:
+				v = b.datetime(DateTimeType(segment.Index), &t)
+			case Backfilled:
+				v = 0
+			}
+			vs[j] = v & segment.mask
+		}
+		main, ext := assemble(segments, vs)
+		id := ID{Main: main, Ext: ext, Signed: d.options.Signed}
+		e := ExampleID{ID: id, String: id.String()}
+		if tt, err := d.Time(&id); err == nil {
+			e.Time = tt.UTC()
+		}
+		e.Values, _ = d.Parse(&id)
+		examples[i] = e
+	}
+	return examples
+}
+
+// splitmix64 is the generator of the examples, whose sequence is fixed
+// for a seed regardless of the Go release.
+type splitmix64 uint64
+
+func (s *splitmix64) next() uint64 {
+	*s += 0x9e3779b97f4a7c15
+	z := uint64(*s)
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	return z ^ z>>31
+}
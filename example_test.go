@@ -0,0 +1,42 @@
+package tsid
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExamples(t *testing.T) {
+	opt := Config(9, 3, Sequence(12), Host(6, 0), Node(4, 0), Random(8), Timestamp(41, TimestampMilliseconds))
+	opt.EpochMS = 1_600_000_000_000
+	got := Examples(*opt, 3, 42)
+	// stable across the releases, do not change
+	want := []string{
+		"0000000000003.1b1f2hxt9mjcx",
+		"0000000000003.1b1f2i9kteos9",
+		"0000000000003.1b1f2iwgtew9e",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("want: %d examples, got: %d", len(want), len(got))
+		return
+	}
+	for i, e := range got {
+		if e.String != want[i] {
+			t.Errorf("want: %s, got: %s", want[i], e.String)
+		}
+		if e.Values[1].Value != 9 || e.Values[2].Value != 3 || e.Time.IsZero() {
+			t.Errorf("want: host 9, node 3, got: %v", e.Values)
+		}
+		if i > 0 && !got[i-1].Time.Before(e.Time) {
+			t.Errorf("want: increasing times, got: %s after %s", e.Time, got[i-1].Time)
+		}
+	}
+	if !reflect.DeepEqual(got, Examples(*opt, 3, 42)) {
+		t.Error("want: deterministic examples, got: different")
+	}
+	if other := Examples(*opt, 3, 43); other[0].String == got[0].String {
+		t.Error("want: different examples of the seeds, got: same")
+	}
+	if Examples(Options{}, 3, 42) != nil {
+		t.Error("want: nil, got: examples")
+	}
+}
@@ -0,0 +1,34 @@
+package tsid
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrSequenceExhausted indicates that the sequence overflows within a tick,
+// see ExhaustionError
+var ErrSequenceExhausted = errors.New("tsid: the sequence is exhausted in the current tick")
+
+// ExhaustionPolicy indicates how the builder handles the sequence overflowing
+// within a tick, see Options.OnExhausted
+type ExhaustionPolicy int
+
+const (
+	// SpinWait busy-spins on the clock until the next millisecond
+	SpinWait ExhaustionPolicy = iota
+	// SleepWait sleeps until the next millisecond, which saves the CPU
+	// at the cost of the latency of the sleep
+	SleepWait
+	// ExhaustionError fails the generation with ErrSequenceExhausted
+	ExhaustionError
+	// OverflowIntoTimestamp issues the IDs at the next millisecond without
+	// waiting, the timestamps run ahead of the clock until it catches up
+	OverflowIntoTimestamp
+)
+
+// pause waits before reading the clock again, now and next are milliseconds
+func (b *Builder) pause(now, next int64) {
+	if b.options.OnExhausted == SleepWait && next > now {
+		time.Sleep(time.Duration(next-now) * time.Millisecond)
+	}
+}
@@ -0,0 +1,66 @@
+package tsid
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExhaustion(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	layouts := map[string]Options{
+		"lock-free": *Config(1, 2, Sequence(8), Node(4, 0), Timestamp(41, TimestampMilliseconds)),
+		"locked":    *Config(1, 2, Sequence(8), Env(4, "TSID_TEST_NODE", 0), Timestamp(41, TimestampMilliseconds)),
+	}
+	for name, opt := range layouts {
+		opt.OnExhausted = ExhaustionError
+		b, _ := Make(opt)
+		b.WithClock(NewManualClock(start, 0))
+		var e error
+		for i := 0; i < 256 && e == nil; i++ {
+			_, e = b.TryNext()
+		}
+		if e != nil {
+			t.Errorf("%s want: 256 IDs, got: error %s", name, e)
+		}
+		if _, e = b.TryNext(); e != ErrSequenceExhausted {
+			t.Errorf("%s want: %s, got: %v", name, ErrSequenceExhausted, e)
+		}
+		// the convenience getters fail cleanly
+		if v := b.NextInt64(); v != 0 {
+			t.Errorf("%s want: 0, got: %d", name, v)
+		}
+		if s := b.NextString(); s != "" {
+			t.Errorf("%s want: empty, got: %s", name, s)
+		}
+
+		opt.OnExhausted = OverflowIntoTimestamp
+		b, _ = Make(opt)
+		b.WithClock(NewManualClock(start, 0))
+		seen := map[ID]bool{}
+		var last time.Time
+		for i := 0; i < 1000; i++ {
+			id, e := b.TryNext()
+			if e != nil || seen[*id] {
+				t.Fatalf("%s want: unique IDs, got: %v, error %v", name, id, e)
+				return
+			}
+			seen[*id] = true
+			last, _ = b.TimeOf(id)
+		}
+		if want := start.Add(3 * time.Millisecond); !last.Equal(want) {
+			t.Errorf("%s want: %s, got: %s", name, want, last)
+		}
+
+		opt.OnExhausted = SleepWait
+		b, _ = Make(opt)
+		seen = map[ID]bool{}
+		for i := 0; i < 600; i++ {
+			id, e := b.TryNext()
+			if e != nil || seen[*id] {
+				t.Fatalf("%s want: unique IDs, got: %v, error %v", name, id, e)
+				return
+			}
+			seen[*id] = true
+		}
+	}
+}
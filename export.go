@@ -0,0 +1,89 @@
+package tsid
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// decompose extracts the values of the segments from the main and extension parts,
+// which is the reverse of Builder.compose.
+func decompose(segments []Bits, main, ext int64) []int64 {
+	vs := make([]int64, len(segments))
+	var shift, width byte
+	for i, segment := range segments {
+		width += segment.Width
+		var v uint64
+		if width <= bitsMaxWidth {
+			v = uint64(main) >> shift
+		} else if width-segment.Width < bitsMaxWidth {
+			v = uint64(main)>>shift | uint64(ext)<<(bitsMaxWidth-shift)
+		} else {
+			v = uint64(ext) >> shift
+		}
+		vs[i] = int64(v) & segment.mask
+		shift = width % bitsMaxWidth
+	}
+	return vs
+}
+
+// assemble is the inverse of decompose, the values MUST fit in the segments
+func assemble(segments []Bits, vs []int64) (main, ext int64) {
+	var shift, width byte
+	for i, segment := range segments {
+		v := uint64(vs[i])
+		width += segment.Width
+		if width <= bitsMaxWidth {
+			main |= int64(v << shift & uint63Max)
+		} else if width-segment.Width < bitsMaxWidth {
+			main |= int64(v << shift & uint63Max)
+			ext |= int64(v >> (bitsMaxWidth - shift) & uint63Max)
+		} else {
+			ext |= int64(v << shift & uint63Max)
+		}
+		shift = width % bitsMaxWidth
+	}
+	return
+}
+
+// ExportCSV generates n IDs by b and writes them to w as CSV with the decomposed values,
+// the columns are "id", "main", "ext" and one column per segment named by its label,
+// see Bits.Label. It stops at the first error of the generation.
+func ExportCSV(w io.Writer, b *Builder, n int, argv ...int64) error {
+	if b == nil || !b.isReady() {
+		return ErrNotReady
+	}
+	segments := b.options.segments
+	c := csv.NewWriter(w)
+	row := make([]string, 0, len(segments)+3)
+	row = append(row, "id", "main", "ext")
+	row = append(row, labels(segments)...)
+	if e := c.Write(row); e != nil {
+		return e
+	}
+	for i := 0; i < n; i++ {
+		id, e := b.TryNext(argv...)
+		if e != nil {
+			c.Flush()
+			return e
+		}
+		row = row[:0]
+		no := ""
+		if b.Encoder != nil {
+			no = b.Encoder.Encode(id)
+		} else {
+			no = id.String()
+		}
+		row = append(row, no,
+			strconv.FormatInt(id.Main, 10),
+			strconv.FormatInt(id.Ext, 10))
+		for _, v := range decompose(segments, id.Main, id.Ext) {
+			row = append(row, strconv.FormatInt(v, 10))
+		}
+		if e = c.Write(row); e != nil {
+			return e
+		}
+	}
+	c.Flush()
+	return c.Error()
+}
@@ -0,0 +1,72 @@
+package tsid
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestDecompose(t *testing.T) {
+	for n, o := range predefined {
+		b, e := New(*o)
+		if e != nil {
+			t.Error("Predefined[", n, "]", " want: a builder instance, got error: ", e)
+			continue
+		}
+		b.Debug = true
+		for i := 0; i < 10; i++ {
+			id := b.Next(1)
+			vs := decompose(b.options.segments, id.Main, id.Ext)
+			for j, v := range b.DebugInfo().Raw {
+				if v&b.options.segments[j].mask != vs[j] {
+					t.Errorf("Predefined[%s] segment %d want: %d, got: %d", n, j, v, vs[j])
+				}
+			}
+		}
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	b, e := Make(Shuffle())
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	var buf bytes.Buffer
+	if e = ExportCSV(&buf, b, 10); e != nil {
+		t.Fatalf("want: nothing, got: error %s", e)
+		return
+	}
+	rows, e := csv.NewReader(&buf).ReadAll()
+	if e != nil {
+		t.Fatalf("want: valid CSV, got: error %s", e)
+		return
+	}
+	if len(rows) != 11 || len(rows[0]) != len(b.options.segments)+3 {
+		t.Fatalf("want: 11 rows of %d columns, got: %d rows", len(b.options.segments)+3, len(rows))
+		return
+	}
+	if rows[0][3] != "RandomID" {
+		t.Errorf("want: column RandomID, got: %s", rows[0][3])
+	}
+	for _, row := range rows[1:] {
+		if _, e = strconv.ParseInt(row[1], 10, 64); e != nil {
+			t.Errorf("want: main part, got: %s", row[1])
+		}
+	}
+
+	opt := *Segments(Sequence(8).Named("seq"), Timestamp(41, TimestampMilliseconds).Named("ts"))
+	opt.OnExhausted = ExhaustionError
+	b, _ = Make(opt)
+	b.WithClock(NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 0))
+	buf.Reset()
+	if e = ExportCSV(&buf, b, 300); e != ErrSequenceExhausted {
+		t.Errorf("want: %s, got: %v", ErrSequenceExhausted, e)
+	}
+	rows, _ = csv.NewReader(&buf).ReadAll()
+	if len(rows) != 257 || rows[0][3] != "seq" || rows[0][4] != "ts" {
+		t.Errorf("want: the header of the names and 256 rows, got: %d rows, %v", len(rows), rows[0])
+	}
+}
@@ -0,0 +1,59 @@
+package tsid
+
+import "errors"
+
+var (
+	// ErrNoKind indicates that the layout has no GroupKind segment
+	ErrNoKind = errors.New("tsid: the layout has no GroupKind segment")
+	// ErrKindInvalid indicates that a kind is duplicated or out of the range of the segment
+	ErrKindInvalid = errors.New("tsid: the kind is duplicated or out of range")
+)
+
+// NextGroup returns the IDs of the related entities, one per kind, which share
+// the same timestamp and sequence and differ in the GroupKind segments, so they
+// sort adjacently if the Kind segment is below the timestamp and sequence. The
+// other segments are computed per ID, so the Random and Provider ones differ too.
+func (b *Builder) NextGroup(kinds ...int64) ([]*ID, error) {
+	if !b.isReady() {
+		return nil, ErrNotReady
+	}
+	var mask int64 = -1
+	for _, segment := range b.options.segments {
+		if segment.Source == GroupKind {
+			mask = segment.mask
+			break
+		}
+	}
+	if mask < 0 {
+		return nil, ErrNoKind
+	}
+	seen := make(map[int64]bool, len(kinds))
+	for _, k := range kinds {
+		if k < 0 || k > mask || seen[k] {
+			return nil, ErrKindInvalid
+		}
+		seen[k] = true
+	}
+	b.Lock()
+	defer b.Unlock()
+	seq, err := b.tick()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]*ID, len(kinds))
+	for i, k := range kinds {
+		main, ext, err := b.compose(b.now, seq, 0, k, nil, nil)
+		if err == nil {
+			err = b.emit(main, ext)
+		}
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = &ID{
+			Main:   main,
+			Ext:    ext,
+			Signed: b.options.Signed,
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,45 @@
+package tsid
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestNextGroup(t *testing.T) {
+	b, e := Make(*Segments(Kind(2, 0), Sequence(12), Timestamp(41, TimestampMilliseconds)))
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	var all []*ID
+	for i := 0; i < 10; i++ {
+		all = append(all, b.Next())
+		g, e := b.NextGroup(1, 3)
+		if e != nil || len(g) != 2 {
+			t.Fatalf("want: 2 IDs, got: %v, error %v", g, e)
+			return
+		}
+		if g[0].Main>>2 != g[1].Main>>2 || g[0].Main&3 != 1 || g[1].Main&3 != 3 {
+			t.Errorf("want: same base, kinds 1 and 3, got: %b, %b", g[0].Main, g[1].Main)
+		}
+		all = append(all, g...)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Less(all[j]) })
+	for i := 1; i < len(all); i++ {
+		if all[i].Equal(all[i-1]) {
+			t.Errorf("want: unique IDs, got: duplicated %s", all[i])
+		}
+		if all[i].Main&3 == 3 && all[i-1].Main&3 != 1 {
+			t.Errorf("want: adjacent group, got: %b before %b", all[i-1].Main, all[i].Main)
+		}
+	}
+	for _, kinds := range [][]int64{{1, 1}, {4}, {-1}} {
+		if _, e = b.NextGroup(kinds...); e != ErrKindInvalid {
+			t.Errorf("%v want: error(%s), got: %v", kinds, ErrKindInvalid, e)
+		}
+	}
+	b, _ = Make(Default())
+	if _, e = b.NextGroup(1); e != ErrNoKind {
+		t.Errorf("want: error(%s), got: %v", ErrNoKind, e)
+	}
+}
@@ -0,0 +1,117 @@
+package tsid
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrHandoffLayout indicates that the handoff is from a builder of another layout
+	ErrHandoffLayout = errors.New("tsid: the handoff is from another layout")
+	// ErrHandoffMalformed indicates that the handoff cannot be parsed
+	ErrHandoffMalformed = errors.New("tsid: the handoff is malformed")
+)
+
+// handoffState is the identity and the last issued state of a terminating builder
+type handoffState struct {
+	Fingerprint uint32           `json:"fingerprint"`
+	Settings    map[string]int64 `json:"settings,omitempty"`
+	// LastMS is the Unix milliseconds of the latest tick, zero if none
+	LastMS   int64 `json:"last_ms"`
+	Sequence int64 `json:"sequence"`
+}
+
+// Handoff closes the builder (see Close) and writes its node identity (the
+// settings) and the last issued tick and sequence to w, for the replacement
+// process on the same host to continue by Takeover without a gap or a window
+// of duplicates during the rolling restart.
+func (b *Builder) Handoff(w io.Writer) error {
+	if b.options == nil {
+		return ErrNotReady
+	}
+	if err := b.Close(); err != nil {
+		return err
+	}
+	b.Lock()
+	s := handoffState{Fingerprint: b.options.Fingerprint(), Settings: b.options.settings}
+	if b.lockFree {
+		if t := b.claimed(); t != nil {
+			s.LastMS = t.UnixMilli()
+			s.Sequence = int64(atomic.LoadUint64(&b.clock)) & b.sequenceMask
+		}
+	} else if b.now != nil {
+		s.LastMS, s.Sequence = b.now.UnixMilli(), b.sequence
+	}
+	b.Unlock()
+	return json.NewEncoder(w).Encode(&s)
+}
+
+// Takeover makes a builder of the layout, which takes over the node identity
+// and continues after the last issued state of the handoff read from r.
+func Takeover(opt Options, r io.Reader) (*Builder, error) {
+	var s handoffState
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, ErrHandoffMalformed
+	}
+	if s.Fingerprint != opt.Fingerprint() {
+		return nil, ErrHandoffLayout
+	}
+	opt = opt.clone()
+	for k, v := range s.Settings {
+		opt.Set(k, v)
+	}
+	b, err := Make(opt)
+	if err != nil || s.LastMS <= 0 {
+		return b, err
+	}
+	s.Sequence &= b.sequenceMask
+	if b.lockFree {
+		atomic.StoreUint64(&b.clock, uint64(s.LastMS-b.clockEpoch)<<b.sequenceWidth|uint64(s.Sequence))
+	} else {
+		t := time.UnixMilli(s.LastMS)
+		b.now, b.sequence = &t, s.Sequence
+	}
+	return b, nil
+}
+
+// HandoffFile writes the handoff to the file atomically, see Handoff
+func (b *Builder) HandoffFile(path string) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if err = b.Handoff(f); err == nil {
+		err = f.Sync()
+	}
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// TakeoverFile takes over the handoff written by HandoffFile and removes the
+// file, so it is taken over once. It makes a fresh builder if there is no file.
+func TakeoverFile(opt Options, path string) (*Builder, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return Make(opt)
+	}
+	if err != nil {
+		return nil, err
+	}
+	b, err := Takeover(opt, f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	return b, os.Remove(path)
+}
@@ -0,0 +1,62 @@
+package tsid
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHandoff(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "node.handoff")
+	layouts := map[string][]Bits{
+		"lock-free": {Sequence(10), Node(4, 0), Host(6, 0), Timestamp(41, TimestampMilliseconds)},
+		"locked":    {Sequence(10), Env(2, "TSID_TEST_NODE", 0), Node(4, 0), Host(6, 0), Timestamp(41, TimestampMilliseconds)},
+	}
+	for name, segments := range layouts {
+		clock := NewManualClock(start, 0)
+		b, _ := Make(*Config(3, 5, segments...))
+		b.WithClock(clock)
+		var last *ID
+		for i := 0; i < 10; i++ {
+			last = b.Next()
+		}
+		if e := b.HandoffFile(path); e != nil {
+			t.Fatalf("%s want: handoff, got: error %s", name, e)
+			return
+		}
+		if _, e := b.TryNext(); e != ErrNotReady {
+			t.Errorf("%s want: %s, got: %v", name, ErrNotReady, e)
+		}
+		n, e := TakeoverFile(*Config(0, 0, segments...), path)
+		if e != nil {
+			t.Fatalf("%s want: takeover, got: error %s", name, e)
+			return
+		}
+		n.WithClock(clock)
+		id := n.Next()
+		if !last.Less(id) {
+			t.Errorf("%s want: after %s, got: %s", name, last, id)
+		}
+		if o := n.Options(); o.settings["Host"] != 3 || o.settings["Node"] != 5 {
+			t.Errorf("%s want: the identity taken over, got: %v", name, o.settings)
+		}
+		if _, e = os.Stat(path); !os.IsNotExist(e) {
+			t.Errorf("%s want: the handoff removed, got: %v", name, e)
+		}
+	}
+	if b, e := TakeoverFile(Default(), path); e != nil || b == nil {
+		t.Errorf("want: a fresh builder, got: error %v", e)
+	}
+	var buf bytes.Buffer
+	b, _ := Make(Default())
+	_ = b.Handoff(&buf)
+	if _, e := Takeover(*Config(0, 0, Sequence(12), Timestamp(41, TimestampMilliseconds)), &buf); e != ErrHandoffLayout {
+		t.Errorf("want: %s, got: %v", ErrHandoffLayout, e)
+	}
+	if _, e := Takeover(Default(), bytes.NewBufferString("{")); e != ErrHandoffMalformed {
+		t.Errorf("want: %s, got: %v", ErrHandoffMalformed, e)
+	}
+}
@@ -0,0 +1,76 @@
+package tsid
+
+import "errors"
+
+var (
+	// ErrChildOutOfRange indicates that the child counter exceeds the child width
+	ErrChildOutOfRange = errors.New("tsid: the child counter is out of range")
+	// ErrNilParent indicates that the parent ID is nil, e.g. Parent failed
+	ErrNilParent = errors.New("tsid: the parent ID is nil")
+)
+
+// Hierarchical derives child IDs from parent IDs, e.g. order-line IDs from an order ID.
+// A child ID is the parent ID shifted left by ChildWidth bits plus the child counter,
+// so the parent can be extracted from any child. The children of a parent sort
+// together, in the order of their parents, but far from the parent itself, which
+// sorts among the unrelated IDs of the smaller values.
+type Hierarchical struct {
+	builder *Builder
+	width   byte
+	mask    int64
+}
+
+// NewHierarchical returns a Hierarchical which generates the parent IDs by b,
+// the width of the layout of b plus childWidth MUST NOT exceed 126 bits.
+func NewHierarchical(b *Builder, childWidth byte) (*Hierarchical, error) {
+	if childWidth < 1 || childWidth >= bitsMaxWidth {
+		return nil, invalidOption("ChildWidth", errorWidthInvalid)
+	}
+	if b == nil || !b.isReady() {
+		return nil, ErrNotReady
+	}
+	if b.width+childWidth > bitsMaxWidth*2 {
+		return nil, invalidOption("ChildWidth", errorWidthTooLarge)
+	}
+	return &Hierarchical{
+		builder: b,
+		width:   childWidth,
+		mask:    -1 ^ (-1 << childWidth),
+	}, nil
+}
+
+// Parent returns the next parent ID
+func (h *Hierarchical) Parent(argv ...int64) *ID {
+	return h.builder.Next(argv...)
+}
+
+// Child returns the n-th child ID of the parent, the value range of n is [0, 2^ChildWidth-1]
+func (h *Hierarchical) Child(parent *ID, n int64) (*ID, error) {
+	if parent == nil {
+		return nil, ErrNilParent
+	}
+	if n < 0 || n > h.mask {
+		return nil, ErrChildOutOfRange
+	}
+	w := h.width
+	return &ID{
+		Main:   (parent.Main<<w | n) & int64(uint63Max),
+		Ext:    (parent.Ext<<w | parent.Main>>(bitsMaxWidth-w)) & int64(uint63Max),
+		Signed: parent.Signed,
+	}, nil
+}
+
+// ParentOf extracts the parent ID from the child ID
+func (h *Hierarchical) ParentOf(child *ID) *ID {
+	w := h.width
+	return &ID{
+		Main:   (child.Main>>w | child.Ext<<(bitsMaxWidth-w)) & int64(uint63Max),
+		Ext:    child.Ext >> w,
+		Signed: child.Signed,
+	}
+}
+
+// ChildIndex extracts the child counter from the child ID
+func (h *Hierarchical) ChildIndex(child *ID) int64 {
+	return child.Main & h.mask
+}
@@ -0,0 +1,48 @@
+package tsid
+
+import "testing"
+
+func TestHierarchical(t *testing.T) {
+	b, e := Make(Default())
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	if _, e = NewHierarchical(b, 0); e == nil {
+		t.Error("want: error, got: an instance")
+	}
+	s, _ := Make(Shuffle())
+	if _, e = NewHierarchical(s, 8); e == nil {
+		t.Error("want: error, got: an instance")
+	}
+	h, e := NewHierarchical(b, 8)
+	if e != nil {
+		t.Fatalf("want: an instance, got: error %s", e)
+		return
+	}
+	if _, e = h.Child(nil, 0); e != ErrNilParent {
+		t.Errorf("want: error(%s), got: %v", ErrNilParent, e)
+	}
+	parent := h.Parent()
+	if _, e = h.Child(parent, 256); e != ErrChildOutOfRange {
+		t.Errorf("want: error(%s), got: %v", ErrChildOutOfRange, e)
+	}
+	var p *ID
+	for n := int64(0); n < 256; n++ {
+		c, e := h.Child(parent, n)
+		if e != nil {
+			t.Fatalf("want: a child ID, got: error %s", e)
+			return
+		}
+		if !h.ParentOf(c).Equal(parent) {
+			t.Errorf("want: parent %s, got: %s", parent, h.ParentOf(c))
+		}
+		if h.ChildIndex(c) != n {
+			t.Errorf("want: child index %d, got: %d", n, h.ChildIndex(c))
+		}
+		if p != nil && (c.Ext < p.Ext || c.Ext == p.Ext && c.Main <= p.Main) {
+			t.Error("the child IDs are not incremental")
+		}
+		p = c
+	}
+}
@@ -0,0 +1,53 @@
+package tsid
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// seqBlock is the sequences [base, end) of a tick reserved by the locked
+// builders, which are handed out by atomic increments, see Options.SequenceBlock
+type seqBlock struct {
+	next uint64
+	now  time.Time
+	ms   int64
+	base int64
+	end  int64
+}
+
+// fromBlock returns the time and the sequence of the next ID from the reserved
+// block of the current tick, or reserves a new block under the lock.
+func (b *Builder) fromBlock() (time.Time, int64, error) {
+	if n, seq, ok := b.take(); ok {
+		return n, seq, nil
+	}
+	b.Lock()
+	defer b.Unlock()
+	// another caller may have reserved a block while waiting for the lock
+	if n, seq, ok := b.take(); ok {
+		return n, seq, nil
+	}
+	seq, err := b.tick()
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	end := seq + 1 + int64(b.options.SequenceBlock)
+	if end > b.sequenceMask+1 {
+		end = b.sequenceMask + 1
+	}
+	b.sequence = end - 1
+	b.block.Store(&seqBlock{now: *b.now, ms: b.now.UnixMilli(), base: seq + 1, end: end})
+	return *b.now, seq, nil
+}
+
+// take returns the next sequence of the block if it is of the current tick
+func (b *Builder) take() (time.Time, int64, bool) {
+	blk, ok := b.block.Load().(*seqBlock)
+	if !ok || b.timeNow().UnixMilli() != blk.ms || atomic.LoadUint64(&blk.next) >= uint64(blk.end-blk.base) {
+		return time.Time{}, 0, false
+	}
+	if seq := blk.base + int64(atomic.AddUint64(&blk.next, 1)) - 1; seq < blk.end {
+		return blk.now, seq, true
+	}
+	return time.Time{}, 0, false
+}
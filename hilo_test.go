@@ -0,0 +1,58 @@
+package tsid
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSequenceBlock(t *testing.T) {
+	opt := *Config(1, 2, Sequence(12), Env(4, "TSID_TEST_NODE", 0), Timestamp(41, TimestampMilliseconds))
+	opt.SequenceBlock = 256
+	b, e := Make(opt)
+	if e != nil || b.LockFree() || !b.blocks {
+		t.Fatalf("want: a locked builder with blocks, got: error %v", e)
+		return
+	}
+	const workers, count = 8, 5000
+	results := make([][]ID, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < count; i++ {
+				id, e := b.TryNext()
+				if e != nil {
+					t.Errorf("want: an ID, got: error %s", e)
+					return
+				}
+				results[w] = append(results[w], *id)
+			}
+		}(w)
+	}
+	wg.Wait()
+	seen := map[ID]bool{}
+	for _, ids := range results {
+		for _, id := range ids {
+			if seen[id] {
+				t.Fatalf("want: unique IDs, got: duplicated %s", &id)
+				return
+			}
+			seen[id] = true
+		}
+	}
+	// the other methods continue after the reserved block
+	id, _ := b.TryNext()
+	batch := b.NextBatch(10)
+	for _, x := range batch {
+		if seen[x] || x == *id {
+			t.Fatalf("want: unique IDs, got: duplicated %s", &x)
+			return
+		}
+	}
+	opt = lockFreeOptions()
+	opt.SequenceBlock = 16
+	if b, _ = Make(opt); b.blocks {
+		t.Error("want: the lock-free builders ignore the blocks, got: blocks")
+	}
+}
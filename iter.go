@@ -0,0 +1,46 @@
+//go:build go1.23
+
+package tsid
+
+import (
+	"context"
+	"iter"
+)
+
+// All returns an iterator over the next n IDs, which stops at the first error
+// of the generation, use Iter to obtain it
+//
+//	for id := range b.All(1000) {
+//	  fmt.Println(id.String())
+//	}
+func (b *Builder) All(n int, argv ...int64) iter.Seq[ID] {
+	return func(yield func(ID) bool) {
+		for i := 0; i < n; i++ {
+			id, err := b.TryNext(argv...)
+			if err != nil || !yield(*id) {
+				return
+			}
+		}
+	}
+}
+
+// Iter returns an iterator over the IDs until ctx is done or the generation
+// fails, the last pair yielded carries the error of ctx or of TryNext.
+func (b *Builder) Iter(ctx context.Context, argv ...int64) iter.Seq2[ID, error] {
+	return func(yield func(ID, error) bool) {
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(ID{}, err)
+				return
+			}
+			id, err := b.TryNext(argv...)
+			if err != nil {
+				yield(ID{}, err)
+				return
+			}
+			if !yield(*id, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+//go:build go1.23
+
+package tsid
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAllIter(t *testing.T) {
+	b, e := Make(Default())
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	c := 0
+	p := ID{}
+	for id := range b.All(100) {
+		if id.Main <= p.Main {
+			t.Error("the IDs are not incremental")
+		}
+		p = id
+		c++
+	}
+	if c != 100 {
+		t.Errorf("want: 100 IDs, got: %d", c)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c = 0
+	for _, e := range b.Iter(ctx) {
+		if e != nil {
+			if e != context.Canceled {
+				t.Errorf("want: error(%s), got: %s", context.Canceled, e)
+			}
+			break
+		}
+		if c++; c == 10 {
+			cancel()
+		}
+	}
+	if c != 10 {
+		t.Errorf("want: 10 IDs, got: %d", c)
+	}
+
+	opt := *Config(1, 2, Sequence(8), Timestamp(41, TimestampMilliseconds))
+	opt.OnExhausted = ExhaustionError
+	b, _ = Make(opt)
+	b.WithClock(NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 0))
+	c = 0
+	for _, e := range b.Iter(context.Background()) {
+		if e != nil {
+			if e != ErrSequenceExhausted {
+				t.Errorf("want: error(%s), got: %s", ErrSequenceExhausted, e)
+			}
+			break
+		}
+		c++
+	}
+	if c != 256 {
+		t.Errorf("want: 256 IDs, got: %d", c)
+	}
+}
@@ -0,0 +1,283 @@
+package tsid
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrJournalMalformed indicates that a record of the journal is malformed
+	ErrJournalMalformed = errors.New("tsid: the journal record is malformed")
+	// ErrJournalChain indicates that the hash chain of the journal is broken,
+	// the records are modified, removed or reordered
+	ErrJournalChain = errors.New("tsid: the hash chain of the journal is broken")
+	// ErrJournalDuplicate indicates that an ID is issued twice
+	ErrJournalDuplicate = errors.New("tsid: the journal has duplicated IDs")
+	// ErrJournalKey indicates that the key of the journal is empty
+	ErrJournalKey = errors.New("tsid: the journal key must not be empty")
+	// ErrJournalTruncated indicates that the journal ends before its head,
+	// the latest records are removed
+	ErrJournalTruncated = errors.New("tsid: the journal ends before its head")
+)
+
+// Journal is a write-ahead, tamper-evident issuance log. Every record is
+// "<number> <main> <ext> <chain>\n", where the chain is the hex HMAC-SHA256 by
+// the key of the previous chain and the record, so modifying, removing or
+// reordering the records breaks the chain, and it cannot be recomputed without
+// the key. Removing the latest records is detected by the Head kept aside.
+// See VerifyJournal.
+type Journal struct {
+	// SyncEvery is the number of the records between the syncs of the writer
+	// (if it has a Sync method, e.g. *os.File), 0 means never, 1 means every record
+	SyncEvery int
+
+	mu      sync.Mutex
+	w       io.Writer
+	key     []byte
+	number  uint64
+	chain   [sha256.Size]byte
+	pending int
+}
+
+// NewJournal returns a journal writing to w, which starts a new chain by the
+// secret key, e.g. 32 random bytes kept out of the journal
+func NewJournal(w io.Writer, key []byte) (*Journal, error) {
+	if len(key) == 0 {
+		return nil, ErrJournalKey
+	}
+	return &Journal{w: w, key: append([]byte{}, key...)}, nil
+}
+
+// JournalHead is the number and the chain of the latest record, which is kept
+// out of the journal, e.g. in a database, to detect the removal of the latest
+// records, see VerifyJournal
+type JournalHead struct {
+	Records uint64
+	// Chain is the hex chain of the record
+	Chain string
+}
+
+// String returns "<records>:<chain>"
+func (h JournalHead) String() string {
+	return strconv.FormatUint(h.Records, 10) + ":" + h.Chain
+}
+
+// Head returns the head of the records written
+func (j *Journal) Head() JournalHead {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return JournalHead{Records: j.number, Chain: hex.EncodeToString(j.chain[:])}
+}
+
+// Resume verifies the records written before up to the head if not nil (see
+// VerifyJournal), and continues their chain.
+func (j *Journal) Resume(r io.Reader, head *JournalHead) error {
+	s, err := verifyJournal(r, j.key, head)
+	if err != nil {
+		return err
+	}
+	j.mu.Lock()
+	j.number, j.chain = s.number, s.chain
+	j.mu.Unlock()
+	return nil
+}
+
+// append writes the record of the ID
+func (j *Journal) append(main, ext int64) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	number := j.number + 1
+	chain := journalChain(j.key, &j.chain, number, main, ext)
+	line := strconv.FormatUint(number, 10) + " " +
+		strconv.FormatInt(main, 10) + " " +
+		strconv.FormatInt(ext, 10) + " " +
+		hex.EncodeToString(chain[:]) + "\n"
+	if _, err := io.WriteString(j.w, line); err != nil {
+		return err
+	}
+	j.number, j.chain = number, chain
+	if j.SyncEvery > 0 {
+		j.pending++
+		if j.pending >= j.SyncEvery {
+			j.pending = 0
+			if s, ok := j.w.(interface{ Sync() error }); ok {
+				return s.Sync()
+			}
+		}
+	}
+	return nil
+}
+
+// sync syncs the writer if it has a Sync method
+func (j *Journal) sync() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.pending = 0
+	if s, ok := j.w.(interface{ Sync() error }); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+func journalChain(key []byte, prev *[sha256.Size]byte, number uint64, main, ext int64) (chain [sha256.Size]byte) {
+	var buf [sha256.Size + 24]byte
+	copy(buf[:], prev[:])
+	binary.BigEndian.PutUint64(buf[sha256.Size:], number)
+	binary.BigEndian.PutUint64(buf[sha256.Size+8:], uint64(main))
+	binary.BigEndian.PutUint64(buf[sha256.Size+16:], uint64(ext))
+	m := hmac.New(sha256.New, key)
+	m.Write(buf[:])
+	copy(chain[:], m.Sum(nil))
+	return chain
+}
+
+// JournalSummary is the result of VerifyJournal
+type JournalSummary struct {
+	// Records is the number of the records
+	Records int
+	// Unordered is the number of the IDs less than the previous one, which is
+	// zero for the time-ordered layouts
+	Unordered int
+	number    uint64
+	chain     [sha256.Size]byte
+}
+
+// VerifyJournal replays the journal, checks the hash chain by the key, the
+// numbering and the uniqueness of the IDs, and counts the IDs out of the numeric
+// order. The journal MUST reach the head if it is not nil, see Journal.Head.
+// The segmented files are verified by concatenating them in order,
+// see SegmentedFile.Segments.
+func VerifyJournal(r io.Reader, key []byte, head *JournalHead) (JournalSummary, error) {
+	if len(key) == 0 {
+		return JournalSummary{}, ErrJournalKey
+	}
+	return verifyJournal(r, key, head)
+}
+
+func verifyJournal(r io.Reader, key []byte, head *JournalHead) (s JournalSummary, err error) {
+	seen := map[[2]int64]bool{}
+	var prev ID
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		fs := strings.Fields(sc.Text())
+		if len(fs) != 4 {
+			return s, fmt.Errorf("%w: line %d", ErrJournalMalformed, s.Records+1)
+		}
+		number, e1 := strconv.ParseUint(fs[0], 10, 64)
+		main, e2 := strconv.ParseInt(fs[1], 10, 64)
+		ext, e3 := strconv.ParseInt(fs[2], 10, 64)
+		chain, e4 := hex.DecodeString(fs[3])
+		if e1 != nil || e2 != nil || e3 != nil || e4 != nil || len(chain) != sha256.Size {
+			return s, fmt.Errorf("%w: line %d", ErrJournalMalformed, s.Records+1)
+		}
+		if number != s.number+1 {
+			return s, fmt.Errorf("%w: record %d follows %d", ErrJournalChain, number, s.number)
+		}
+		want := journalChain(key, &s.chain, number, main, ext)
+		if !hmac.Equal(want[:], chain) || head != nil && number == head.Records && fs[3] != head.Chain {
+			return s, fmt.Errorf("%w: record %d", ErrJournalChain, number)
+		}
+		id := ID{Main: main, Ext: ext}
+		if seen[id.Key()] {
+			return s, fmt.Errorf("%w: record %d", ErrJournalDuplicate, number)
+		}
+		seen[id.Key()] = true
+		if s.Records > 0 && id.Less(&prev) {
+			s.Unordered++
+		}
+		prev = id
+		s.Records++
+		s.number, s.chain = number, want
+	}
+	if err = sc.Err(); err == nil && head != nil && s.number < head.Records {
+		err = fmt.Errorf("%w: record %d of %d", ErrJournalTruncated, s.number, head.Records)
+	}
+	return s, err
+}
+
+// SegmentedFile is a journal writer of the files of at most MaxBytes in a
+// directory, named journal-000001.log, journal-000002.log, ...
+type SegmentedFile struct {
+	dir      string
+	maxBytes int64
+	f        *os.File
+	size     int64
+	index    int
+}
+
+const segmentPattern = "journal-*.log"
+
+// OpenSegmentedFile opens the last segment in dir to append, or creates the first
+func OpenSegmentedFile(dir string, maxBytes int64) (*SegmentedFile, error) {
+	s := &SegmentedFile{dir: dir, maxBytes: maxBytes, index: 1}
+	files, err := s.Segments()
+	if err != nil {
+		return nil, err
+	}
+	if n := len(files); n > 0 {
+		name := filepath.Base(files[n-1])
+		s.index, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "journal-"), ".log"))
+	}
+	return s, s.open()
+}
+
+func (s *SegmentedFile) open() error {
+	name := filepath.Join(s.dir, fmt.Sprintf("journal-%06d.log", s.index))
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.f, s.size = f, info.Size()
+	return nil
+}
+
+// Segments returns the paths of the segments in order
+func (s *SegmentedFile) Segments() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, segmentPattern))
+	sort.Strings(files)
+	return files, err
+}
+
+// Write appends p to the current segment, it starts the next segment
+// if p does not fit in the current one.
+func (s *SegmentedFile) Write(p []byte) (int, error) {
+	if s.maxBytes > 0 && s.size > 0 && s.size+int64(len(p)) > s.maxBytes {
+		if err := s.f.Close(); err != nil {
+			return 0, err
+		}
+		s.index++
+		if err := s.open(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := s.f.Write(p)
+	s.size += int64(n)
+	return n, err
+}
+
+// Sync commits the current segment to the stable storage
+func (s *SegmentedFile) Sync() error {
+	return s.f.Sync()
+}
+
+// Close closes the current segment
+func (s *SegmentedFile) Close() error {
+	return s.f.Close()
+}
@@ -0,0 +1,129 @@
+package tsid
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+type failWriter struct{}
+
+func (failWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+// journalKey is the key of the test journals
+var journalKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestJournal(t *testing.T) {
+	var buf bytes.Buffer
+	if _, e := NewJournal(&buf, nil); e != ErrJournalKey {
+		t.Errorf("want: error(%s), got: %v", ErrJournalKey, e)
+	}
+	opt := Default()
+	opt.Journal, _ = NewJournal(&buf, journalKey)
+	b, e := Make(opt)
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	if b.LockFree() {
+		t.Error("want: locked, got: lock-free")
+	}
+	for i := 0; i < 100; i++ {
+		b.Next()
+	}
+	b.NextBatch(10)
+	head := opt.Journal.Head()
+	s, e := VerifyJournal(bytes.NewReader(buf.Bytes()), journalKey, &head)
+	if e != nil || s.Records != 110 || s.Unordered != 0 {
+		t.Errorf("want: 110 ordered records, got: %+v, error %v", s, e)
+	}
+	if _, e = VerifyJournal(bytes.NewReader(buf.Bytes()), []byte("other"), nil); !errors.Is(e, ErrJournalChain) {
+		t.Errorf("want: error(%s), got: %v", ErrJournalChain, e)
+	}
+
+	lines := strings.SplitAfter(buf.String(), "\n")
+	truncated := strings.Join(lines[:100], "")
+	if _, e = VerifyJournal(strings.NewReader(truncated), journalKey, nil); e != nil {
+		t.Errorf("want: nothing, got: error %s", e)
+	}
+	if _, e = VerifyJournal(strings.NewReader(truncated), journalKey, &head); !errors.Is(e, ErrJournalTruncated) {
+		t.Errorf("want: error(%s), got: %v", ErrJournalTruncated, e)
+	}
+	tampered := strings.Join(append(append([]string{}, lines[:5]...), lines[6:]...), "")
+	if _, e = VerifyJournal(strings.NewReader(tampered), journalKey, nil); !errors.Is(e, ErrJournalChain) {
+		t.Errorf("want: error(%s), got: %v", ErrJournalChain, e)
+	}
+	fs := strings.Fields(lines[3])
+	fs[2] = "1"
+	tampered = strings.Join(lines[:3], "") + strings.Join(fs, " ") + "\n" + strings.Join(lines[4:], "")
+	if _, e = VerifyJournal(strings.NewReader(tampered), journalKey, nil); !errors.Is(e, ErrJournalChain) {
+		t.Errorf("want: error(%s), got: %v", ErrJournalChain, e)
+	}
+	if _, e = VerifyJournal(strings.NewReader("1 2 3\n"), journalKey, nil); !errors.Is(e, ErrJournalMalformed) {
+		t.Errorf("want: error(%s), got: %v", ErrJournalMalformed, e)
+	}
+
+	j, _ := NewJournal(&buf, journalKey)
+	if e = j.Resume(bytes.NewReader(buf.Bytes()), &head); e != nil {
+		t.Fatalf("want: resumed, got: error %s", e)
+		return
+	}
+	_ = j.append(1, 0)
+	_ = j.append(1, 0)
+	if _, e = VerifyJournal(bytes.NewReader(buf.Bytes()), journalKey, nil); !errors.Is(e, ErrJournalDuplicate) {
+		t.Errorf("want: error(%s), got: %v", ErrJournalDuplicate, e)
+	}
+
+	opt.Journal, _ = NewJournal(failWriter{}, journalKey)
+	b, _ = Make(opt)
+	if _, e = b.TryNext(); e == nil || b.Stats().Generated != 0 {
+		t.Errorf("want: error of the journal, got: %v", e)
+	}
+}
+
+func TestSegmentedFile(t *testing.T) {
+	dir := t.TempDir()
+	f, e := OpenSegmentedFile(dir, 512)
+	if e != nil {
+		t.Fatalf("want: a file, got: error %s", e)
+		return
+	}
+	opt := Default()
+	opt.Journal, _ = NewJournal(f, journalKey)
+	opt.Journal.SyncEvery = 10
+	b, _ := Make(opt)
+	for i := 0; i < 50; i++ {
+		if _, e = b.TryNext(); e != nil {
+			t.Fatalf("want: an ID, got: error %s", e)
+			return
+		}
+	}
+	_ = f.Close()
+	if f, e = OpenSegmentedFile(dir, 512); e != nil {
+		t.Fatalf("want: a file, got: error %s", e)
+		return
+	}
+	defer f.Close()
+	paths, _ := f.Segments()
+	if len(paths) < 2 {
+		t.Errorf("want: segments, got: %v", paths)
+	}
+	var rs []io.Reader
+	for _, p := range paths {
+		r, e := os.Open(p)
+		if e != nil {
+			t.Fatalf("want: a segment, got: error %s", e)
+			return
+		}
+		defer r.Close()
+		rs = append(rs, r)
+	}
+	if s, e := VerifyJournal(io.MultiReader(rs...), journalKey, nil); e != nil || s.Records != 50 {
+		t.Errorf("want: 50 records, got: %+v, error %v", s, e)
+	}
+}
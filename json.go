@@ -0,0 +1,175 @@
+package tsid
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// DefaultEncoder is used to marshal the IDs as strings (JSON, text, SQL),
+// nil means the base-36 form of ID.String. Set it to the Encoder of the builder
+// to round-trip the strings made by Builder.NextString. It is not synchronized,
+// so set it once at the start, before any ID is marshaled or scanned.
+var DefaultEncoder Encoder
+
+// ParseID parses the base-36 form made by ID.String, the sign marker '-' or '!'
+func ParseID(s string) (*ID, error) {
+	return parseID(s, defaultSignMarker)
+}
+
+// parseID parses the base-36 form with the sign marker '-' or sign
+func parseID(s string, sign byte) (*ID, error) {
+	no := s
+	id := &ID{}
+	if no != "" && (no[0] == sign || no[0] == '-') {
+		id.Signed = true
+		no = no[1:]
+	}
+	if i := strings.IndexByte(no, '.'); i >= 0 {
+		ext, err := parseBase36(s, no[:i])
+		if err != nil {
+			return nil, err
+		}
+		id.Ext = ext
+		no = no[i+1:]
+	}
+	main, err := parseBase36(s, no)
+	if err != nil {
+		return nil, err
+	}
+	id.Main = main
+	return id, nil
+}
+
+// Base36 is the Encoder of the base-36 form of ID.String and ParseID
+type Base36 struct {
+	// Strict is used to reject the strings other than the ones of Encode on
+	// Decode, e.g. the ones without the paddings of 13 digits
+	Strict bool
+	// Sign is the prefix of the signed IDs, including zero, e.g. '!' of Base64.
+	// Zero means ID.String: '-' for the signed IDs except zero. Decode accepts
+	// '-' too unless Strict.
+	Sign byte
+}
+
+// base36Digits are the digits and the separator of the base-36 form
+const base36Digits = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ+-."
+
+func (e *Base36) Encode(id *ID) string {
+	if e.Sign == 0 || !id.Signed {
+		return id.String()
+	}
+	v := *id
+	v.Signed = false
+	return string(e.Sign) + v.String()
+}
+
+func (e *Base36) Decode(no string) (*ID, error) {
+	sign := signOf(e.Sign)
+	if e.Sign != 0 && !validSign(sign, base36Digits) {
+		return nil, signError(no)
+	}
+	if e.Strict {
+		if err := checkSign(no, sign); err != nil {
+			return nil, err
+		}
+	}
+	id, err := parseID(no, sign)
+	if err == nil && e.Strict {
+		if err = checkCanonical(no, e.Encode(id)); err != nil {
+			return nil, err
+		}
+	}
+	return id, err
+}
+
+func parseBase36(s, part string) (int64, error) {
+	if part == "" {
+		return 0, decodeError(s, DecodeErrorEmpty)
+	}
+	if part[0] == '-' || part[0] == '+' {
+		return 0, decodeError(s, DecodeErrorInvalidDigit)
+	}
+	v, err := strconv.ParseInt(part, 36, 64)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return 0, decodeError(s, DecodeErrorOverflow)
+		}
+		return 0, decodeError(s, DecodeErrorInvalidDigit)
+	}
+	return v, nil
+}
+
+// encodeString encodes the ID by DefaultEncoder
+func (id *ID) encodeString() string {
+	if DefaultEncoder != nil {
+		return DefaultEncoder.Encode(id)
+	}
+	return id.String()
+}
+
+// decodeString decodes the string made by encodeString into the ID
+func (id *ID) decodeString(s string) error {
+	var v *ID
+	var err error
+	if DefaultEncoder != nil {
+		v, err = DefaultEncoder.Decode(s)
+	} else {
+		v, err = ParseID(s)
+	}
+	if err != nil {
+		return err
+	}
+	*id = *v
+	return nil
+}
+
+// IsCanonical reports whether s is the string form of an ID made by DefaultEncoder,
+// or by ID.String if it is nil. The systems deduplicating by the strings SHOULD
+// reject the other forms which decode to the same ID, e.g. the padded ones.
+func IsCanonical(s string) bool {
+	var id ID
+	return id.decodeString(s) == nil && id.encodeString() == s
+}
+
+// MarshalJSON encodes the ID as a JSON string by DefaultEncoder,
+// which keeps the precision of the 126 bits IDs.
+func (id ID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(id.encodeString())
+}
+
+// UnmarshalJSON decodes the JSON string made by MarshalJSON, a JSON number
+// which is not negative is accepted as the main part, and null is ignored
+// like encoding/json does.
+func (id *ID) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) > 0 && data[0] != '"' {
+		var v int64
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		if v < 0 {
+			return decodeError(string(data), DecodeErrorOutOfRange)
+		}
+		*id = ID{Main: v}
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return id.decodeString(s)
+}
+
+// MarshalText encodes the ID by DefaultEncoder, which implements encoding.TextMarshaler
+func (id ID) MarshalText() ([]byte, error) {
+	return []byte(id.encodeString()), nil
+}
+
+// UnmarshalText decodes the text made by MarshalText, which implements encoding.TextUnmarshaler
+func (id *ID) UnmarshalText(text []byte) error {
+	return id.decodeString(string(text))
+}
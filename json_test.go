@@ -0,0 +1,87 @@
+package tsid
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseID(t *testing.T) {
+	b, e := Make(Shuffle())
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	for i := 0; i < 10; i++ {
+		id := b.Next()
+		id.Signed = i%2 == 0
+		if got, e := ParseID(id.String()); e != nil || !got.Equal(id) {
+			t.Errorf("want: %s, got: %v, error %v", id, got, e)
+		}
+	}
+	for _, s := range []string{"", "-", ".", "0.", "--1", "0.-1", "zzzzzzzzzzzzzzz", "!"} {
+		if _, e := ParseID(s); e == nil {
+			t.Errorf("%q want: error, got: nothing", s)
+		}
+	}
+}
+
+func TestIDJSON(t *testing.T) {
+	type payload struct {
+		ID  ID  `json:"id"`
+		Ref *ID `json:"ref"`
+	}
+	id := &ID{Main: 1<<62 + 1, Ext: 1<<62 + 3}
+	for _, en := range []Encoder{nil, &Base64{}} {
+		DefaultEncoder = en
+		buf, e := json.Marshal(payload{ID: *id, Ref: id})
+		if e != nil {
+			t.Fatalf("want: JSON, got: error %s", e)
+			return
+		}
+		var p payload
+		if e = json.Unmarshal(buf, &p); e != nil || !p.ID.Equal(id) || !p.Ref.Equal(id) {
+			t.Errorf("want: %s, got: %s, error %v", id, buf, e)
+		}
+	}
+	DefaultEncoder = nil
+	var n ID
+	if e := json.Unmarshal([]byte("12345"), &n); e != nil || n.Main != 12345 {
+		t.Errorf("want: 12345, got: %d, error %v", n.Main, e)
+	}
+	if e := json.Unmarshal([]byte(`"!"`), &n); e == nil {
+		t.Error("want: error, got: nothing")
+	}
+	if e := json.Unmarshal([]byte("-5"), &n); e == nil || n.Main != 12345 {
+		t.Errorf("want: error, got: %d, error %v", n.Main, e)
+	}
+	if e := json.Unmarshal([]byte("null"), &n); e != nil || n.Main != 12345 {
+		t.Errorf("want: unchanged 12345, got: %d, error %v", n.Main, e)
+	}
+	var p struct{ ID *ID }
+	if e := json.Unmarshal([]byte(`{"ID": null}`), &p); e != nil || p.ID != nil {
+		t.Errorf("want: nil, got: %v, error %v", p.ID, e)
+	}
+}
+
+func TestIDText(t *testing.T) {
+	id := ID{Main: 1<<62 + 1, Ext: 1<<62 + 3}
+	for _, en := range []Encoder{nil, &Base64{}} {
+		DefaultEncoder = en
+		m := map[ID]int{id: 1}
+		buf, e := json.Marshal(m)
+		if e != nil {
+			t.Fatalf("want: JSON, got: error %s", e)
+			return
+		}
+		got := map[ID]int{}
+		if e = json.Unmarshal(buf, &got); e != nil || got[id] != 1 {
+			t.Errorf("want: %v, got: %v, error %v", m, got, e)
+		}
+		var v ID
+		text, _ := id.MarshalText()
+		if e = v.UnmarshalText(text); e != nil || !v.Equal(&id) {
+			t.Errorf("want: %s, got: %s, error %v", &id, &v, e)
+		}
+	}
+	DefaultEncoder = nil
+}
@@ -0,0 +1,122 @@
+package tsid
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	// KSUIDEpochMS is the epoch of the KSUIDs, 2014-05-13T16:53:20Z
+	KSUIDEpochMS = 1_400_000_000_000
+	// KSUIDLength is the length of the base62 string of a KSUID
+	KSUIDLength = 27
+
+	base62Digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	// ksuidPayloadWidth is the width of the payload held by the IDs, the top
+	// 34 bits of the 128 bits KSUID payload are zero
+	ksuidPayloadWidth = 94
+)
+
+var (
+	// ErrKSUIDFormat indicates that the string is not a base62 KSUID
+	ErrKSUIDFormat = errors.New("tsid: the KSUID must be 27 base62 characters")
+	// ErrKSUIDRange indicates that the payload of the KSUID exceeds the 94 bits
+	// of the IDs, which happens for the KSUIDs not generated by the layout
+	// "ksuid-shaped", see ParseKSUID
+	ErrKSUIDRange = errors.New("tsid: the KSUID payload exceeds 94 bits")
+)
+
+// KSUID is the Encoder of the 27 characters base62 strings of the layout
+// "ksuid-shaped": a 32 bits timestamp in seconds since KSUIDEpochMS and a 128
+// bits payload. They are shaped like the KSUIDs, but the IDs hold 126 bits, so
+// the top 34 bits of the payload are always zero. It is NOT compatible with the
+// KSUIDs of the other generators, whose 160 bits Decode rejects by
+// ErrKSUIDRange; ParseKSUID reads any KSUID.
+type KSUID struct{}
+
+func (e *KSUID) Encode(id *ID) string {
+	v := id.Uint128()
+	var a [20]byte
+	binary.BigEndian.PutUint32(a[:4], uint32(v[0]>>(ksuidPayloadWidth-64)))
+	binary.BigEndian.PutUint64(a[4:12], v[0]&(1<<(ksuidPayloadWidth-64)-1))
+	binary.BigEndian.PutUint64(a[12:], v[1])
+	return formatKSUID(a)
+}
+
+func (e *KSUID) Decode(no string) (*ID, error) {
+	a, err := parseKSUID(no)
+	if err != nil {
+		return nil, err
+	}
+	hi := binary.BigEndian.Uint64(a[4:12])
+	if hi>>(ksuidPayloadWidth-64) != 0 {
+		return nil, ErrKSUIDRange
+	}
+	return FromUint128([2]uint64{
+		uint64(binary.BigEndian.Uint32(a[:4]))<<(ksuidPayloadWidth-64) | hi,
+		binary.BigEndian.Uint64(a[12:]),
+	})
+}
+
+// ParseKSUID returns the time and the payload of any KSUID, including the ones
+// which do not fit the IDs
+func ParseKSUID(s string) (time.Time, [16]byte, error) {
+	var payload [16]byte
+	a, err := parseKSUID(s)
+	if err != nil {
+		return time.Time{}, payload, err
+	}
+	copy(payload[:], a[4:])
+	ts := int64(binary.BigEndian.Uint32(a[:4]))
+	return time.Unix(ts+KSUIDEpochMS/msPerSecond, 0), payload, nil
+}
+
+// formatKSUID returns the base62 string of the 20 bytes, padded with '0'
+func formatKSUID(a [20]byte) string {
+	v := new(big.Int).SetBytes(a[:])
+	buf := []byte(v.Text(62))
+	for i, c := range buf {
+		buf[i] = base62Digits[digitOf(c)]
+	}
+	for len(buf) < KSUIDLength {
+		buf = append([]byte{'0'}, buf...)
+	}
+	return string(buf)
+}
+
+// parseKSUID returns the 20 bytes of the base62 string
+func parseKSUID(s string) (a [20]byte, err error) {
+	if len(s) != KSUIDLength {
+		return a, ErrKSUIDFormat
+	}
+	v := new(big.Int)
+	d := big.NewInt(62)
+	for i := 0; i < len(s); i++ {
+		n := strings.IndexByte(base62Digits, s[i])
+		if n < 0 {
+			return a, ErrKSUIDFormat
+		}
+		v.Mul(v, d).Add(v, big.NewInt(int64(n)))
+	}
+	if v.BitLen() > 160 {
+		return a, ErrKSUIDFormat
+	}
+	v.FillBytes(a[:])
+	return a, nil
+}
+
+// digitOf returns the value of the digit of big.Int.Text(62),
+// which orders the lower case letters before the upper case ones
+func digitOf(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'z':
+		return int(c-'a') + 10
+	default:
+		return int(c-'A') + 36
+	}
+}
@@ -0,0 +1,59 @@
+package tsid
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKSUID(t *testing.T) {
+	if _, found := Predefined("KSUID"); found {
+		t.Error("want: not found, got: ksuid")
+	}
+	opt, found := Predefined("KSUID-Shaped")
+	if !found {
+		t.Fatal("want: ksuid-shaped, got: not found")
+		return
+	}
+	b, err := Make(opt)
+	if err != nil {
+		t.Fatalf("want: builder, got: error %s", err)
+		return
+	}
+	e := &KSUID{}
+	b.Encoder = e
+	prev := ""
+	for i := 0; i < 100; i++ {
+		s := b.NextString()
+		if len(s) != KSUIDLength || s <= prev {
+			t.Errorf("want: ordered KSUID, got: %s after %s", s, prev)
+		}
+		prev = s
+		ts, _, err := ParseKSUID(s)
+		if err != nil || time.Since(ts) > 2*time.Second {
+			t.Errorf("want: now, got: %s, error %v", ts, err)
+		}
+		id, err := e.Decode(s)
+		if err != nil || e.Encode(id) != s {
+			t.Errorf("want: %s, got: %v, error %v", s, id, err)
+		}
+	}
+
+	const s = "0ujtsYcgvSTl8PAuAdqWYSMnLOv"
+	ts, payload, err := ParseKSUID(s)
+	if err != nil || !ts.Equal(time.Unix(1507608047, 0)) {
+		t.Errorf("want: 2017-10-10T04:00:47Z, got: %s, error %v", ts.UTC(), err)
+	}
+	if h := strings.ToUpper(hex.EncodeToString(payload[:])); h != "B5A1CD34B5F99D1154FB6853345C9735" {
+		t.Errorf("want: B5A1CD34B5F99D1154FB6853345C9735, got: %s", h)
+	}
+	if _, err := e.Decode(s); err != ErrKSUIDRange {
+		t.Errorf("want: %s, got: %v", ErrKSUIDRange, err)
+	}
+	for _, s := range []string{"", "0ujtsYcgvSTl8PAuAdqWYSMnLO", "0ujtsYcgvSTl8PAuAdqWYSMnLO-", "zzzzzzzzzzzzzzzzzzzzzzzzzzz"} {
+		if _, _, err := ParseKSUID(s); err != ErrKSUIDFormat {
+			t.Errorf("%q want: %s, got: %v", s, ErrKSUIDFormat, err)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package tsid
+
+import "strings"
+
+// Overlay modifies the base layout for an environment, the zero fields keep
+// the values inherited.
+type Overlay struct {
+	// Inherit is the environment whose overlay is applied before this one, "" means the base
+	Inherit string
+	// EpochMS replaces the start timestamp
+	EpochMS int64
+	// Settings are set into the settings of the options
+	Settings map[string]int64
+	// Widths replaces the widths of the segments, keyed by the segment Key
+	Widths map[string]byte
+	// Encoder replaces the encoder
+	Encoder Encoder
+}
+
+// Layouts is a base layout plus the overlays of the environments
+// (dev, staging, prod, ...), which keeps their definitions from drifting.
+type Layouts struct {
+	base     Options
+	encoder  Encoder
+	overlays map[string]Overlay
+}
+
+// NewLayouts returns the Layouts of the base options and encoder
+func NewLayouts(base Options, encoder Encoder) *Layouts {
+	return &Layouts{
+		base:     base.clone(),
+		encoder:  encoder,
+		overlays: map[string]Overlay{},
+	}
+}
+
+// Overlay to set the overlay of the environment(case-insensitive)
+func (l *Layouts) Overlay(env string, o Overlay) *Layouts {
+	l.overlays[strings.ToLower(env)] = o
+	return l
+}
+
+// ResolveLayout returns the options and the encoder of the environment(case-insensitive),
+// which applies the overlays inherited, from the base to the environment.
+func (l *Layouts) ResolveLayout(env string) (Options, Encoder, error) {
+	var chain []Overlay
+	visited := map[string]bool{}
+	for e := strings.ToLower(env); e != ""; {
+		if visited[e] {
+			return Options{}, nil, invalidOption("Overlay", errorOverlayCycle, e)
+		}
+		visited[e] = true
+		o, found := l.overlays[e]
+		if !found {
+			return Options{}, nil, invalidOption("Overlay", errorOverlayNotFound, e)
+		}
+		chain = append(chain, o)
+		e = strings.ToLower(o.Inherit)
+	}
+	opt := l.base.clone()
+	encoder := l.encoder
+	for i := len(chain) - 1; i >= 0; i-- {
+		o := chain[i]
+		if o.EpochMS > 0 {
+			opt.EpochMS = o.EpochMS
+		}
+		for k, v := range o.Settings {
+			opt.Set(k, v)
+		}
+		for k, w := range o.Widths {
+			found := false
+			for j := range opt.segments {
+				if opt.segments[j].Key == k {
+					opt.segments[j].Width = w
+					opt.segments[j].mask = int64(-1 ^ (-1 << w))
+					found = true
+				}
+			}
+			if !found {
+				return Options{}, nil, invalidOption("Overlay.Widths", errorOverlayNotFound, k)
+			}
+		}
+		if o.Encoder != nil {
+			encoder = o.Encoder
+		}
+	}
+	return opt, encoder, nil
+}
@@ -0,0 +1,36 @@
+package tsid
+
+import "testing"
+
+func TestResolveLayout(t *testing.T) {
+	l := NewLayouts(*Config(1, 2,
+		Sequence(12),
+		Node(4, 0),
+		Host(6, 0),
+		Timestamp(41, TimestampMilliseconds),
+	), nil).
+		Overlay("prod", Overlay{EpochMS: EpochMS + 1, Encoder: &Base64{}}).
+		Overlay("staging", Overlay{Inherit: "prod", Settings: map[string]int64{"Node": 9}, Widths: map[string]byte{"Node": 5}}).
+		Overlay("dev", Overlay{Widths: map[string]byte{"Missing": 5}}).
+		Overlay("a", Overlay{Inherit: "b"}).
+		Overlay("b", Overlay{Inherit: "a"})
+	opt, en, e := l.ResolveLayout("Staging")
+	if e != nil {
+		t.Fatalf("want: options, got: error %s", e)
+		return
+	}
+	if opt.EpochMS != EpochMS+1 || en == nil || opt.settings["Node"] != 9 || opt.settings["Host"] != 1 || opt.segments[1].Width != 5 {
+		t.Errorf("want: the overlays applied, got: %+v", opt)
+	}
+	if _, e = Make(opt); e != nil {
+		t.Errorf("want: a builder instance, got: error %s", e)
+	}
+	if base, _, _ := l.ResolveLayout("prod"); base.segments[1].Width != 4 || base.settings["Node"] != 2 {
+		t.Errorf("want: the base unchanged, got: %+v", base)
+	}
+	for _, env := range []string{"dev", "a", "unknown"} {
+		if _, _, e = l.ResolveLayout(env); e == nil {
+			t.Errorf("%s want: error, got: options", env)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package tsid
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// LockFreeSequenceWidth is the maximum sequence width of the lock-free layouts,
+// the claimed millisecond (since the epoch of the builder, 44 bits) and the
+// sequence are packed in a 64 bits word.
+const LockFreeSequenceWidth = 20
+
+// lockFree reports whether the layout can generate the IDs without the lock:
+// the values of the segments are computed locally (no OS or Provider segments),
+// the sequence is not shared and fits in the packed word, the layout is not
+// LowVolume, and there is no journal.
+func lockFree(opt *Options, sequenceWidth byte) bool {
+	// the journal keeps the creation order under the lock
+	if opt.Shared != nil || opt.Journal != nil || opt.LowVolume || sequenceWidth > LockFreeSequenceWidth {
+		return false
+	}
+	for _, segment := range opt.segments {
+		if segment.Source == OS || segment.Source == Provider {
+			return false
+		}
+	}
+	return true
+}
+
+// LockFree reports whether Next generates the IDs without the lock, see Make
+func (b *Builder) LockFree() bool {
+	return b.lockFree
+}
+
+// claim reserves the next (millisecond, sequence) pair by CAS on the packed word,
+// and returns the time of the millisecond with the sequence. The millisecond
+// never goes backwards: if the clock does, it is handled by OnClockBackwards
+// where ClockIgnore borrows the sequence of the latest millisecond. It spins
+// to the next millisecond if the sequence is exhausted.
+func (b *Builder) claim() (time.Time, int64, error) {
+	w := b.sequenceWidth
+	var spin time.Time
+	for {
+		n := b.timeNow()
+		ms := n.UnixMilli() - b.clockEpoch
+		if ms < 0 {
+			ms = 0
+		}
+		old := atomic.LoadUint64(&b.clock)
+		last := int64(old >> w)
+		// the reserved milliseconds are not claimed, waiting for them is not backwards
+		if ms < last && uint64(last) > atomic.LoadUint64(&b.fence) {
+			var borrow bool
+			var err error
+			if n, borrow, err = b.backwards(n, last+b.clockEpoch, true); err != nil {
+				return n, 0, err
+			}
+			if !borrow {
+				continue
+			}
+		}
+		var seq int64
+		if ms <= last {
+			seq = (int64(old) + 1) & b.sequenceMask
+			if seq == 0 {
+				// exhausted, or reserved by Preallocate
+				if uint64(last) > atomic.LoadUint64(&b.fence) || b.options.OnExhausted == OverflowIntoTimestamp {
+					switch b.options.OnExhausted {
+					case ExhaustionError:
+						return n, 0, ErrSequenceExhausted
+					case OverflowIntoTimestamp:
+						next := uint64(last + 1)
+						for {
+							f := atomic.LoadUint64(&b.fence)
+							if f >= next || atomic.CompareAndSwapUint64(&b.fence, f, next) {
+								break
+							}
+						}
+						if atomic.CompareAndSwapUint64(&b.clock, old, next<<w) {
+							return time.UnixMilli(last + 1 + b.clockEpoch), 0, nil
+						}
+						continue
+					}
+					if spin.IsZero() {
+						spin = time.Now()
+					}
+					b.pause(ms, last+1)
+				} else {
+					// sleep through the milliseconds reserved by Preallocate
+					b.sleepUntil(time.UnixMilli(int64(atomic.LoadUint64(&b.fence))+1+b.clockEpoch), 0)
+				}
+				continue
+			}
+			if ms < last {
+				n = time.UnixMilli(last + b.clockEpoch)
+			}
+			ms = last
+		}
+		if atomic.CompareAndSwapUint64(&b.clock, old, uint64(ms)<<w|uint64(seq)) {
+			if !spin.IsZero() {
+				b.spun(spin)
+			}
+			b.mark(seq)
+			return n, seq, nil
+		}
+	}
+}
+
+// reserveUntil marks the milliseconds before t as exhausted,
+// so claim does not issue IDs in them.
+func (b *Builder) reserveUntil(t time.Time) {
+	w := b.sequenceWidth
+	ms := t.UnixMilli() - 1 - b.clockEpoch
+	v := uint64(ms)<<w | uint64(b.sequenceMask)
+	for {
+		old := atomic.LoadUint64(&b.fence)
+		if old >= uint64(ms) || atomic.CompareAndSwapUint64(&b.fence, old, uint64(ms)) {
+			break
+		}
+	}
+	for {
+		old := atomic.LoadUint64(&b.clock)
+		if old >= v || atomic.CompareAndSwapUint64(&b.clock, old, v) {
+			return
+		}
+	}
+}
+
+// claimed returns the time of the latest claimed millisecond, nil if none
+func (b *Builder) claimed() *time.Time {
+	v := atomic.LoadUint64(&b.clock)
+	if v == 0 {
+		return nil
+	}
+	t := time.UnixMilli(int64(v>>b.sequenceWidth) + b.clockEpoch)
+	return &t
+}
@@ -0,0 +1,121 @@
+package tsid
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func lockFreeOptions() Options {
+	return *Config(1, 2, Sequence(12), Host(6, 0), Node(4, 0), Timestamp(41, TimestampMilliseconds))
+}
+
+func TestLockFree(t *testing.T) {
+	b, e := Make(lockFreeOptions())
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	if !b.LockFree() {
+		t.Fatal("want: lock-free, got: locked")
+		return
+	}
+	const workers, count = 8, 5000
+	results := make([][]ID, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < count; i++ {
+				if w%2 == 0 {
+					results[w] = append(results[w], *b.Next())
+				} else {
+					results[w] = append(results[w], b.NextBatch(1)...)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	seen := map[ID]bool{}
+	for _, ids := range results {
+		for i := range ids {
+			if seen[ids[i]] {
+				t.Fatalf("want: unique IDs, got: duplicated %s", &ids[i])
+				return
+			}
+			seen[ids[i]] = true
+			if i > 0 && !ids[i-1].Less(&ids[i]) {
+				t.Fatalf("want: incremental IDs, got: %s after %s", &ids[i], &ids[i-1])
+				return
+			}
+		}
+	}
+	for _, opt := range []*Options{
+		Segments(Sequence(12), Env(4, "TSID_LOCK_FREE", 0), Timestamp(41, TimestampMilliseconds)),
+		Segments(Sequence(12), Data(4, "test_lock_free", 0), Timestamp(41, TimestampMilliseconds)),
+		Segments(Sequence(21), Timestamp(41, TimestampMilliseconds)),
+		{segments: Default().segments},
+	} {
+		if c, _ := Make(*opt); c.LockFree() {
+			t.Errorf("want: locked, got: lock-free %v", opt.segments)
+		}
+	}
+}
+
+func BenchmarkNextParallel(b *testing.B) {
+	c, e := Make(lockFreeOptions())
+	if e != nil {
+		b.Fatal(e)
+		return
+	}
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c.Next()
+		}
+	})
+}
+
+func TestLockFreePreallocate(t *testing.T) {
+	b, _ := Make(lockFreeOptions())
+	b.Next()
+	blocks, e := b.Preallocate(5 * time.Millisecond)
+	if e != nil || len(blocks) != 1 {
+		t.Fatalf("want: 1 block, got: %v, error %v", blocks, e)
+		return
+	}
+	got, _ := b.TimeOf(b.Next())
+	if got.Before(blocks[0].To) {
+		t.Errorf("want: after %s, got: %s", blocks[0].To, got)
+	}
+	if r := b.SelfCheck(context.Background()); !r.OK() {
+		t.Errorf("want: passed, got: error %s", r.Err())
+	}
+}
+
+func TestLockFreeEpoch(t *testing.T) {
+	opt := lockFreeOptions()
+	opt.EpochMS = EpochUnix
+	opt.OnClockBackwards = ClockError
+	b, e := Make(opt)
+	if e != nil || !b.LockFree() {
+		t.Fatalf("want: a lock-free builder, got: error %v", e)
+		return
+	}
+	// before EpochMS, which is after the epoch of the builder
+	start := time.Date(2015, 1, 1, 0, 0, 1, 0, time.UTC)
+	c := NewManualClock(start, 0)
+	b.WithClock(c)
+	id, e := b.TryNext()
+	if got, _ := b.TimeOf(id); e != nil || !got.Equal(start) {
+		t.Errorf("want: %s, got: %s, error %v", start, got, e)
+	}
+	if last := b.claimed(); last == nil || !last.Equal(start) {
+		t.Errorf("want: claimed %s, got: %v", start, last)
+	}
+	c.Add(-time.Second)
+	if _, e = b.TryNext(); e != ErrClockBackwards {
+		t.Errorf("want: error(%s), got: %v", ErrClockBackwards, e)
+	}
+}
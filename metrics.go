@@ -0,0 +1,78 @@
+package tsid
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Metrics receives the events of the generation, see Options.Metrics.
+// The methods are invoked synchronously and MUST NOT block.
+type Metrics interface {
+	// Generated is called for every issued ID
+	Generated()
+	// Rollover is called when the sequence is exhausted within a millisecond
+	Rollover()
+	// Spin is called with the time waited for the next millisecond after a rollover
+	Spin(wait time.Duration)
+	// ProviderError is called when the data provider fails, the fallback value is used
+	ProviderError(name string, err error)
+}
+
+// Counters is a Metrics which counts the events atomically
+type Counters struct {
+	generated, rollovers, spins, spinNanos, providerErrors, randFailures uint64
+}
+
+// Generated implements Metrics
+func (c *Counters) Generated() { atomic.AddUint64(&c.generated, 1) }
+
+// Rollover implements Metrics
+func (c *Counters) Rollover() { atomic.AddUint64(&c.rollovers, 1) }
+
+// Spin implements Metrics
+func (c *Counters) Spin(wait time.Duration) {
+	atomic.AddUint64(&c.spins, 1)
+	atomic.AddUint64(&c.spinNanos, uint64(wait))
+}
+
+// ProviderError implements Metrics
+func (c *Counters) ProviderError(string, error) { atomic.AddUint64(&c.providerErrors, 1) }
+
+// CountersSnapshot is the values of the Counters at a moment
+type CountersSnapshot struct {
+	Generated, Rollovers, Spins, ProviderErrors, RandFailures uint64
+	// SpinTime is the total time waited for the next millisecond
+	SpinTime time.Duration
+}
+
+// Snapshot returns the current values of the counters
+func (c *Counters) Snapshot() CountersSnapshot {
+	return CountersSnapshot{
+		Generated:      atomic.LoadUint64(&c.generated),
+		Rollovers:      atomic.LoadUint64(&c.rollovers),
+		Spins:          atomic.LoadUint64(&c.spins),
+		ProviderErrors: atomic.LoadUint64(&c.providerErrors),
+		RandFailures:   atomic.LoadUint64(&c.randFailures),
+		SpinTime:       time.Duration(atomic.LoadUint64(&c.spinNanos)),
+	}
+}
+
+// spun reports a rollover and the wait since start to the metrics
+func (b *Builder) spun(start time.Time) {
+	d := time.Since(start)
+	atomic.AddUint64(&b.waited, uint64(d))
+	if m := b.options.Metrics; m != nil {
+		m.Rollover()
+		m.Spin(d)
+	}
+}
+
+// mark records the sequence as the high-water mark if it is the largest
+func (b *Builder) mark(seq int64) {
+	for {
+		old := atomic.LoadUint64(&b.highWater)
+		if uint64(seq) <= old || atomic.CompareAndSwapUint64(&b.highWater, old, uint64(seq)) {
+			return
+		}
+	}
+}
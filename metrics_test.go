@@ -0,0 +1,41 @@
+package tsid
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, lockFree := range []bool{true, false} {
+		opt := *Config(1, 2, Sequence(8), Node(4, 0), Timestamp(41, TimestampMilliseconds))
+		if !lockFree {
+			Register("test_metrics", &flakySource{failures: 1000})
+			opt = *Config(1, 2, Sequence(8), Data(4, "test_metrics", 3), Timestamp(41, TimestampMilliseconds))
+		}
+		c := &Counters{}
+		opt.Metrics = c
+		b, e := Make(opt)
+		if e != nil || b.LockFree() != lockFree {
+			t.Fatalf("want: a builder instance, got: error %v", e)
+			return
+		}
+		b.WithClock(NewManualClock(start, time.Microsecond))
+		for i := 0; i < 600; i++ {
+			if _, e = b.TryNext(); e != nil {
+				t.Fatalf("want: an ID, got: error %s", e)
+				return
+			}
+		}
+		s := c.Snapshot()
+		if s.Generated != 600 || s.Rollovers < 2 || s.Spins != s.Rollovers {
+			t.Errorf("want: 600 generated with rollovers, got: %+v", s)
+		}
+		if st := b.Stats(); st.SequenceHighWater != 255 {
+			t.Errorf("want: high-water mark 255, got: %d", st.SequenceHighWater)
+		}
+		if !lockFree && s.ProviderErrors != 600 {
+			t.Errorf("want: 600 provider errors, got: %d", s.ProviderErrors)
+		}
+	}
+}
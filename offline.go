@@ -0,0 +1,117 @@
+package tsid
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrBlocksExhausted indicates that all the preallocated blocks are used up
+var ErrBlocksExhausted = errors.New("tsid: the preallocated blocks are exhausted")
+
+// OfflineGenerator generates IDs in the preallocated blocks by a device-local
+// counter, without reading the clock, so it works on devices without connectivity.
+type OfflineGenerator struct {
+	sync.Mutex
+
+	builder  *Builder
+	blocks   []Block
+	now      time.Time
+	sequence int64
+}
+
+// Offline returns an OfflineGenerator which generates IDs of the layout of b
+// in the blocks, which SHOULD be made by b.Preallocate.
+func (b *Builder) Offline(blocks []Block) (*OfflineGenerator, error) {
+	if !b.isReady() {
+		return nil, ErrNotReady
+	}
+	opt := b.Options()
+	opt.sink = nil
+	m, err := Make(opt)
+	if err != nil {
+		return nil, err
+	}
+	g := &OfflineGenerator{
+		builder:  m,
+		blocks:   append([]Block(nil), blocks...),
+		sequence: -1,
+	}
+	if len(g.blocks) > 0 {
+		g.now = g.blocks[0].From
+	}
+	return g, nil
+}
+
+// Next returns the next ID in the blocks, or ErrBlocksExhausted
+func (g *OfflineGenerator) Next(argv ...int64) (*ID, error) {
+	g.Lock()
+	defer g.Unlock()
+	b := g.builder
+	for len(g.blocks) > 0 {
+		k := &g.blocks[0]
+		g.sequence++
+		if g.sequence >= k.Sequences || g.sequence > b.sequenceMask {
+			g.sequence = 0
+			g.now = g.now.Add(time.Millisecond)
+		}
+		if !k.Contains(g.now) {
+			g.blocks = g.blocks[1:]
+			g.sequence = -1
+			if len(g.blocks) > 0 {
+				g.now = g.blocks[0].From
+			}
+			continue
+		}
+		b.Lock()
+		main, ext, err := b.compose(&g.now, g.sequence, 0, -1, argv, nil)
+		b.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		return &ID{
+			Main:   main,
+			Ext:    ext,
+			Signed: b.options.Signed,
+		}, nil
+	}
+	return nil, ErrBlocksExhausted
+}
+
+// Overlap describes two blocks of different devices which overlap
+type Overlap struct {
+	Device, Other string
+	Block, With   Block
+}
+
+// Reconcile verifies that the blocks assigned to the devices do not overlap,
+// returns the overlaps found, which is empty if the devices can sync safely.
+func Reconcile(devices map[string][]Block) (overlaps []Overlap) {
+	type owned struct {
+		device string
+		block  Block
+	}
+	var all []owned
+	for d, blocks := range devices {
+		for _, k := range blocks {
+			all = append(all, owned{d, k})
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].block.From.Before(all[j].block.From)
+	})
+	for i := range all {
+		for j := i + 1; j < len(all) && all[j].block.From.Before(all[i].block.To); j++ {
+			if all[i].device != all[j].device {
+				overlaps = append(overlaps, Overlap{
+					Device: all[i].device,
+					Other:  all[j].device,
+					Block:  all[i].block,
+					With:   all[j].block,
+				})
+			}
+		}
+	}
+	return overlaps
+}
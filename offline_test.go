@@ -0,0 +1,64 @@
+package tsid
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOfflineGenerator(t *testing.T) {
+	opt := Options{
+		segments: []Bits{
+			Sequence(8),
+			Timestamp(41, TimestampMilliseconds),
+		},
+	}
+	b, e := Make(opt)
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	blocks, e := b.Preallocate(2 * time.Millisecond)
+	if e != nil {
+		t.Fatalf("want: blocks, got: error %s", e)
+		return
+	}
+	g, e := b.Offline(blocks)
+	if e != nil {
+		t.Fatalf("want: a generator, got: error %s", e)
+		return
+	}
+	d := b.Decoder()
+	p := &ID{}
+	for i := 0; i < 2*256; i++ {
+		id, e := g.Next()
+		if e != nil {
+			t.Fatalf("want: an ID, got: error %s", e)
+			return
+		}
+		if id.Main <= p.Main {
+			t.Fatal("the IDs generated offline are not incremental")
+			return
+		}
+		if at, _ := d.Time(id); !blocks[0].Contains(at) {
+			t.Fatalf("want: time in %v, got: %s", blocks[0], at)
+			return
+		}
+		p = id
+	}
+	if _, e = g.Next(); e != ErrBlocksExhausted {
+		t.Errorf("want: error(%s), got: %v", ErrBlocksExhausted, e)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	now := time.Now()
+	a := Block{From: now, To: now.Add(time.Second)}
+	b := Block{From: now.Add(time.Second), To: now.Add(2 * time.Second)}
+	c := Block{From: now.Add(1500 * time.Millisecond), To: now.Add(3 * time.Second)}
+	if o := Reconcile(map[string][]Block{"a": {a}, "b": {b}}); len(o) != 0 {
+		t.Errorf("want: no overlaps, got: %v", o)
+	}
+	if o := Reconcile(map[string][]Block{"a": {a, b}, "c": {c}}); len(o) != 1 {
+		t.Errorf("want: 1 overlap, got: %v", o)
+	}
+}
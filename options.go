@@ -7,6 +7,9 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/StarryLab/tsid.go/tsidapi"
 )
 
 const (
@@ -18,28 +21,44 @@ const (
 	// measured in milliseconds starting
 	// at midnight on December 12, 2022
 	EpochMS = 1_670_774_400_000
+	// EpochUnix is the EpochMS of the layouts measured since 1970-01-01,
+	// e.g. UUIDv7, a zero EpochMS means the default EpochMS. It is the only
+	// valid negative EpochMS
+	EpochUnix = -1
 	// The maximum width of the bit-segment
 	bitsMaxWidth = 63
 )
 
 // internal error string
 const (
-	errorSegmentMiss     = "required bit-segments(Timestamp and Sequence)is missing"
-	errorSegmentsTooMany = "bit-segments too many"
-	errorSegmentsEmpty   = "bit-segments is empty"
+	errorSegmentMiss      = "required bit-segments(Timestamp and Sequence)is missing"
+	errorSegmentsTooMany  = "bit-segments too many"
+	errorSegmentsEmpty    = "bit-segments is empty"
+	errorSegmentDuplicate = "bit-segments are redundant"
+	errorSegmentConflict  = "bit-segments bound to the same key have different fallbacks"
 
 	errorEpochTooSmall = "the EpochMS must be later than 1970-1-1T00:00:00"
 	errorEpochTooLarge = "the EpochMS must be earlier than now"
 
 	errorWidthInvalid  = "the width of bit-segment is incorrect"
 	errorWidthTooLarge = "the width of bit-segment is too large"
+	errorWidthOver63   = "the total width of bit-segments exceeds 63 bits"
+	errorWidthTooSmall = "the width of bit-segment is too small for the maximum value of the data provider"
 
-	errorInvalidValue = "invalid value"
+	errorInvalidValue  = "invalid value"
+	errorReservedValue = "the value of reserved bit-segment must be 0"
 
 	errorInvalidType = "invalid data source type"
 
-	errorTooPoor = "the end date has been reached and there are not enough identifiers"
-	errorTooSlow = "the sequence width is too small and the time to generate identifiers is too slow"
+	errorSceneNotFound   = "the predefined options is not found"
+	errorOverlayNotFound = "the overlay is not found"
+	errorOverlayCycle    = "the overlays inherit from each other"
+
+	errorTooPoor     = "the end date has been reached and there are not enough identifiers"
+	errorClockCoarse = "the clock is coarser than the unit of the timestamp, the throughput is reduced"
+	errorTooSlow     = "the sequence width is too small and the time to generate identifiers is too slow"
+
+	errorLowVolumeShared = "the low-volume layouts do not support the shared sequences"
 )
 
 type OptionsError struct {
@@ -98,6 +117,8 @@ const (
 	TimeYearDay
 	TimeWeekday
 	TimeWeekNumber
+	// TimestampMinutes is the minutes since EpochMS, for the compact layouts
+	TimestampMinutes
 )
 
 var datetimeNames = []string{
@@ -117,6 +138,7 @@ var datetimeNames = []string{
 	"Time.YearDay",
 	"Time.Weekday",
 	"Time.WeekNumber",
+	"Timestamp.Minutes",
 }
 
 func (d DateTimeType) String() string {
@@ -126,6 +148,11 @@ func (d DateTimeType) String() string {
 	return "Undefined"
 }
 
+// timestamp reports whether the type is a timestamp since EpochMS
+func (d DateTimeType) timestamp() bool {
+	return d <= TimestampSeconds || d == TimestampMinutes
+}
+
 const (
 	// HostWidth is the default width of the bit-segment,
 	// value range [0, 63]
@@ -161,6 +188,13 @@ const (
 	RandomID
 	// Provider indicates that the value is from data provider
 	Provider
+	// Backfilled indicates that the value is 1 if the ID is generated by NextAt, otherwise 0
+	Backfilled
+	// ReservedBits indicates that the value is always 0, reserved for future features
+	ReservedBits
+	// GroupKind indicates that the value is the kind of the ID generated by NextGroup,
+	// otherwise the fallback value
+	GroupKind
 )
 
 var dataSourceTypeNames = []string{
@@ -172,6 +206,9 @@ var dataSourceTypeNames = []string{
 	"DateTime",
 	"RandomID",
 	"Provider",
+	"Backfilled",
+	"ReservedBits",
+	"GroupKind",
 }
 
 func (d DataSourceType) String() string {
@@ -181,8 +218,14 @@ func (d DataSourceType) String() string {
 	return "Undefined"
 }
 
-type DataProvider interface {
-	Read(query ...interface{}) (int64, error)
+// DataProvider reads the values of the Provider segments, see Register
+type DataProvider = tsidapi.DataProvider
+
+// BoundedProvider is a DataProvider which reports the maximum value it reads,
+// Make validates (or sizes, see Options.AutoSize) the width of its segments.
+type BoundedProvider interface {
+	DataProvider
+	MaxValue() int64
 }
 
 type Bits struct {
@@ -194,11 +237,54 @@ type Bits struct {
 	Key string
 	// Index indicates the data source index
 	Index int
+	// Private indicates that the value is redacted by the public decoders
+	Private bool
+	// Name is the user-visible name of the segment, see Bits.Label
+	Name string
 
 	mask  int64
 	query []interface{}
 }
 
+// Named returns a copy of the bit-segment with the name
+func (b Bits) Named(name string) Bits {
+	b.Name = name
+	return b
+}
+
+// Label returns the name of the segment, or its key, or the name of its source
+func (b Bits) Label() string {
+	if b.Name != "" {
+		return b.Name
+	}
+	if b.Key != "" {
+		return b.Key
+	}
+	return b.Source.String()
+}
+
+// labels returns the labels of the segments, the later duplicates are suffixed
+// by "#" and their positions, e.g. "Static#2"
+func labels(segments []Bits) []string {
+	r := make([]string, len(segments))
+	seen := make(map[string]bool, len(segments))
+	for i, b := range segments {
+		l := b.Label()
+		if seen[l] {
+			l += "#" + strconv.Itoa(i)
+		}
+		seen[l] = true
+		r[i] = l
+	}
+	return r
+}
+
+// Hide returns a copy of the bit-segment marked as private
+func (b Bits) Hide() Bits {
+	b.Private = true
+	return b
+}
+
 // Host to make the bit-segment of data center id, which value from settings
 func Host(width byte, fallback int64) Bits {
 	return Bits{
@@ -282,6 +368,17 @@ func Arg(width byte, index int, fallback int64) Bits {
 	}
 }
 
+// NamedArg to make a bit-segment, which value from the argument of the name
+// passed to Builder.NextWith, or the positional argument like Arg
+func NamedArg(width byte, name string, fallback int64) Bits {
+	return Bits{
+		Source: Args,
+		Width:  width,
+		Key:    name,
+		Value:  fallback,
+	}
+}
+
 // Option to make a bit-segment, which value from settings in options
 func Option(width byte, key string, fallback int64) Bits {
 	return Bits{
@@ -293,6 +390,37 @@ func Option(width byte, key string, fallback int64) Bits {
 	}
 }
 
+// Backfill to make a bit-segment, which value is 1 if the ID is generated by NextAt.
+// It separates the backfilled IDs from the live-issued IDs.
+func Backfill(width byte) Bits {
+	return Bits{
+		Source: Backfilled,
+		Width:  width,
+	}
+}
+
+// Reserved to make a bit-segment, which value is always 0. Future features (flags,
+// versions) can claim the bits without breaking the decoding of historical IDs.
+func Reserved(width byte) Bits {
+	return Bits{
+		Source: ReservedBits,
+		Width:  width,
+		Key:    "Reserved",
+	}
+}
+
+// Kind to make a bit-segment, which value is the kind of the ID generated by NextGroup,
+// otherwise the fallback value. Place it below the timestamp and sequence segments
+// to keep the IDs of a group adjacent.
+func Kind(width byte, fallback int64) Bits {
+	return Bits{
+		Source: GroupKind,
+		Width:  width,
+		Key:    "Kind",
+		Value:  fallback,
+	}
+}
+
 // Data to make a bit-segment, which value from data provider
 func Data(width byte, source string, fallback int64, query ...interface{}) Bits {
 	return Bits{
@@ -310,13 +438,101 @@ func Data(width byte, source string, fallback int64, query ...interface{}) Bits
 type Options struct {
 	// ReservedDays indicates the minimum days approaching the end
 	ReservedDays,
-	// EpochMS is the start timestamp
+	// EpochMS is the start timestamp, zero means the default EpochMS and
+	// EpochUnix means 1970-01-01, the other negative values are invalid
 	EpochMS int64
 	// Signed is used to on/off the sign bit
 	Signed bool
+	// Max63Bits is used to reject the layouts exceeding 63 bits,
+	// which guarantees the IDs have no extension part
+	Max63Bits bool
+	// AutoSize is used to widen the segments of the BoundedProvider to fit
+	// their maximum values, instead of failing
+	AutoSize bool
+	// Duplicates indicates how Make handles the redundant or conflicting segments
+	Duplicates DuplicatePolicy
+	// Shared is the sequence shared by the builders of the processes on the host,
+	// nil means the builder uses its own sequence
+	Shared SharedSequence
+	// BackfillWindow is the maximum age of the time accepted by NextAt,
+	// zero means NextAt is disabled
+	BackfillWindow time.Duration
+	// OnClockBackwards indicates how the builder handles the clock moving backwards
+	OnClockBackwards ClockPolicy
+	// ClockTolerance is the maximum backwards step handled by ClockWait and
+	// ClockBorrow, and the maximum wait of ClockWait, larger steps fail with
+	// ErrClockBackwards. Zero means DefaultClockTolerance.
+	ClockTolerance time.Duration
+	// Monotonic is used to derive the timestamps from the monotonic clock anchored
+	// at Make (start wall time + time.Since(start)) instead of the wall clock, so
+	// the IDs keep increasing even when the wall clock is adjusted, at the cost of
+	// drifting from the adjusted wall clock until the builder is made again.
+	Monotonic bool
+	// Journal is the write-ahead issuance log of the builder, every ID is
+	// appended to it in the creation order before being returned
+	Journal *Journal
+	// RandPool is the tuning of the random pool of the RandomID segments,
+	// nil means reading the random source per value
+	RandPool *RandPool
+	// ClockCheck is used to measure the granularity of the clock in Make, and
+	// record a warning if it is too coarse for the timestamp, see Builder.Warnings
+	ClockCheck bool
+	// Metrics receives the events of the generation, e.g. the sequence rollovers
+	// which indicate that the sequence is too narrow for the load, nil means none
+	Metrics Metrics
+	// Environment is the time, random and OS sources of the builder,
+	// nil means the OS-backed ones
+	Environment *Environment
+	// OnExhausted indicates how the builder handles the sequence overflowing
+	// within a tick
+	OnExhausted ExhaustionPolicy
+	// OnRandFailure indicates how the builder handles the failures of the random
+	// source of the RandomID segments
+	OnRandFailure RandPolicy
+	// SequenceBlock is the number of the sequences reserved per lock acquisition
+	// and handed out by atomic increments within the tick, which cuts the contention
+	// of the locked builders (see Builder.LockFree). Zero disables it, it is ignored
+	// by the lock-free builders and the Shared sequences.
+	SequenceBlock int
+	// LowVolume is used for the compact layouts issuing few IDs, e.g. the short
+	// IDs of the URL shorteners: the sequence restarts per tick of the timestamp
+	// (e.g. per minute) instead of per millisecond, and it may be narrower than
+	// 8 bits. It is not compatible with the Shared sequences.
+	LowVolume bool
 
 	segments []Bits
 	settings map[string]int64
+	sink     func(*ID)
+	// nearing is the callback of OnEpochNearExhaustion
+	nearing func(time.Duration)
+}
+
+// Clone returns a deep copy of the options, see clone
+func (o *Options) Clone() *Options {
+	c := o.clone()
+	return &c
+}
+
+// clone returns a deep copy of the options, the segments and settings
+// of the copy do not share memory with the original.
+func (o *Options) clone() Options {
+	c := *o
+	if o.segments != nil {
+		c.segments = make([]Bits, len(o.segments))
+		for i, b := range o.segments {
+			if b.query != nil {
+				b.query = append([]interface{}(nil), b.query...)
+			}
+			c.segments[i] = b
+		}
+	}
+	if o.settings != nil {
+		c.settings = make(map[string]int64, len(o.settings))
+		for k, v := range o.settings {
+			c.settings[k] = v
+		}
+	}
+	return c
 }
 
 // Set to set the settings key and value
@@ -352,6 +568,46 @@ func (o *Options) Patch(offset byte, key string, index int, fallback int64) *Opt
 	return o
 }
 
+// RemoveAt removes the segment at the index i, out of range is ignored
+func (o *Options) RemoveAt(i int) *Options {
+	if i >= 0 && i < len(o.segments) {
+		segments := make([]Bits, 0, len(o.segments)-1)
+		segments = append(segments, o.segments[:i]...)
+		o.segments = append(segments, o.segments[i+1:]...)
+	}
+	return o
+}
+
+// Replace replaces the segment at the index i by b, out of range is ignored
+func (o *Options) Replace(i int, b Bits) *Options {
+	if i >= 0 && i < len(o.segments) {
+		b.mask = int64(-1 ^ (-1 << b.Width))
+		segments := append([]Bits(nil), o.segments...)
+		segments[i] = b
+		o.segments = segments
+	}
+	return o
+}
+
+// InsertAt inserts b before the segment at the index i, i == len(segments)
+// appends it like Add, out of range is ignored
+func (o *Options) InsertAt(i int, b Bits) *Options {
+	if i >= 0 && i <= len(o.segments) {
+		b.mask = int64(-1 ^ (-1 << b.Width))
+		segments := make([]Bits, 0, len(o.segments)+1)
+		segments = append(segments, o.segments[:i]...)
+		segments = append(segments, b)
+		o.segments = append(segments, o.segments[i:]...)
+	}
+	return o
+}
+
+// Segments returns a copy of the segments of the options, in the order of Add
+func (o *Options) Segments() []Bits {
+	c := o.clone()
+	return c.segments
+}
+
 // O is a shortcut for make Options
 func O(segments ...Bits) (o *Options) {
 	return Segments(segments...)
@@ -458,6 +714,80 @@ var (
 				Timestamp(10, TimeMillisecond),     // 10 bits
 			},
 		},
+		// RFC 9562 UUIDv7, 126 bits, the top 2 bits of unix_ts_ms are zero until
+		// the year 4199, and rand_a is the counter of the method 1 (6.2)
+		"uuidv7": {
+			EpochMS: EpochUnix,
+			segments: []Bits{
+				Random(62).Named("rand_b"),
+				Fixed(2, 2).Named("var"),
+				Sequence(12).Named("rand_a"),
+				Fixed(4, 7).Named("ver"),
+				Timestamp(46, TimestampMilliseconds).Named("unix_ts_ms"),
+			},
+		},
+		// KSUID-shaped, 126 bits, the top 34 bits of the 128 bits payload are
+		// zero, and the milliseconds and the sequence keep the order within a
+		// second. It is not KSUID-compatible: see KSUID
+		"ksuid-shaped": {
+			EpochMS: KSUIDEpochMS,
+			segments: []Bits{
+				Random(63),
+				Random(9),
+				Sequence(12),
+				Timestamp(10, TimeMillisecond),
+				Timestamp(32, TimestampSeconds),
+			},
+		},
+		// Instagram, 63 bits of the 64, the shard is the setting ShardKey, see Instagram
+		"instagram": {
+			EpochMS: InstagramEpochMS,
+			segments: []Bits{
+				Sequence(10),
+				Shard(13),
+				Timestamp(40, TimestampMilliseconds),
+			},
+		},
+		// Discord, 63 bits of the 64, the worker and the process are the settings,
+		// see Discord
+		"discord": {
+			EpochMS: DiscordEpochMS,
+			segments: []Bits{
+				Sequence(12).Named("increment"),
+				Option(5, "Process", 0),
+				Option(5, "Worker", 0),
+				Timestamp(41, TimestampMilliseconds),
+			},
+		},
+		// Twitter, 63 bits, the machine is the setting, see Twitter
+		"twitter": {
+			EpochMS: TwitterEpochMS,
+			segments: []Bits{
+				Sequence(12),
+				Option(10, "Machine", 0),
+				Timestamp(41, TimestampMilliseconds),
+			},
+		},
+		// 32 bits, 128 IDs per minute for 63 years, for the URL shorteners
+		"short32": {
+			EpochMS:     EpochMS,
+			LowVolume:   true,
+			OnExhausted: SleepWait,
+			segments: []Bits{
+				Sequence(7),
+				Timestamp(25, TimestampMinutes),
+			},
+		},
+		// 48 bits, 16384 IDs per second for 544 years
+		"short48": {
+			EpochMS:     EpochMS,
+			LowVolume:   true,
+			OnExhausted: SleepWait,
+			segments: []Bits{
+				Sequence(14),
+				Timestamp(34, TimestampSeconds),
+			},
+		},
 		// TODO: auto-increment
 	}
 	aliases = map[string]string{
@@ -467,6 +797,8 @@ var (
 		"snowflake":  "default",
 		"shuffle":    "random",
 		"testing":    "test",
+		"uuid7":      "uuidv7",
+		"short":      "short32",
 		// TODO: auto-increment
 		// "increment":      "sequence",
 		// "auto-increment": "sequence",
@@ -474,11 +806,14 @@ var (
 )
 
 func init() {
-	// reset EpochMS in all predefined options
+	// reset EpochMS in the predefined options, except the standard schemes
+	// with their own epochs, e.g. UUIDv7
 	if s, f := os.LookupEnv(EnvTimeEpoch); f {
 		if v, e := strconv.ParseInt(s, 10, 64); e == nil {
-			for k := range predefined {
-				predefined[k].EpochMS = v
+			for k, o := range predefined {
+				if o.EpochMS == 0 || o.EpochMS == EpochMS {
+					predefined[k].EpochMS = v
+				}
 			}
 		}
 	}
@@ -487,36 +822,42 @@ func init() {
 // Predefined obtains the predefined options specified by scope(case-insensitive),
 // which includes "Default"(aliases: classic, snowflake), "Random"(aliases: shuffle),
 // "OpenId", "SequenceId"(aliases: seq, seqid, increment, auto-increment),
-// "Test"(aliases: testing) ... etc
+// "Test"(aliases: testing), "UUIDv7"(aliases: uuid7), "KSUID-Shaped", "Instagram",
+// "Discord", "Twitter", "Short32"(aliases: short), "Short48" ... etc
 func Predefined(scene string) (Options, bool) {
 	scene = strings.ToLower(scene)
 	if a, f := aliases[scene]; f {
 		scene = a
 	}
 	if o, f := predefined[scene]; f {
-		return *o, true
+		return o.clone(), true
 	}
 	return Options{}, false
 }
 
 // Shuffle return predefined options "shuffle"(alias: random), 126 bits
 func Shuffle() Options {
-	return *predefined["random"]
+	return predefined["random"].clone()
 }
 
 // Default is a shortcut for make Options, which is the classic snowflake algorithm
 func Default() Options {
-	return *predefined["default"]
+	return predefined["default"].clone()
 }
 
 // OpenID is a shortcut for make Options, 126 bits
 func OpenID() Options {
-	return *predefined["openid"]
+	return predefined["openid"].clone()
 }
 
 // SeqId is a shortcut for make Options
 func SeqId() Options {
-	return *predefined["sequence"]
+	return predefined["sequence"].clone()
+}
+
+// UUIDv7 is a shortcut for make Options of the RFC 9562 UUIDv7, see UUID
+func UUIDv7() Options {
+	return predefined["uuidv7"].clone()
 }
 
 // TODO: auto-increment
@@ -534,6 +875,7 @@ func Define(scene string, options Options) bool {
 	if _, f := predefined[scene]; f {
 		return false
 	}
+	options = options.clone()
 	predefined[scene] = &options
 	return true
 }
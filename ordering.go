@@ -0,0 +1,79 @@
+package tsid
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrTokenInvalid indicates that the ordering token is malformed
+var ErrTokenInvalid = errors.New("tsid: the ordering token is malformed")
+
+// OrderingToken returns a compact token of the timestamp and sequence of the ID,
+// the other segments (host, node, ...) are stripped. Clients compare the tokens
+// by CompareTokens to enforce read-your-writes against the replicas without
+// knowing the layout. The tokens are comparable within the same layout only.
+func (d *Decoder) OrderingToken(id *ID) (string, error) {
+	vs, err := d.Decompose(id)
+	if err != nil {
+		return "", err
+	}
+	ts, seq := int64(-1), int64(0)
+	for i, segment := range d.options.segments {
+		switch {
+		case segment.Source == DateTime && DateTimeType(segment.Index).timestamp() && ts < 0:
+			ts = vs[i]
+		case segment.Source == SequenceID:
+			seq = vs[i]
+		default:
+			continue
+		}
+		if vs[i] == Redacted && d.public && segment.Private {
+			return "", ErrRedacted
+		}
+	}
+	if ts < 0 {
+		return "", ErrNoTimestamp
+	}
+	return strconv.FormatInt(ts, 36) + "." + strconv.FormatInt(seq, 36), nil
+}
+
+// OrderingToken returns the ordering token of the ID generated by the builder,
+// see Decoder.OrderingToken
+func (b *Builder) OrderingToken(id *ID) (string, error) {
+	if !b.isReady() {
+		return "", ErrNotReady
+	}
+	return b.Decoder().OrderingToken(id)
+}
+
+// CompareTokens returns -1, 0 or +1 as the ID of the token a was generated
+// before, at the same time as, or after the ID of the token b.
+func CompareTokens(a, b string) (int, error) {
+	x, err := parseToken(a)
+	if err != nil {
+		return 0, err
+	}
+	y, err := parseToken(b)
+	if err != nil {
+		return 0, err
+	}
+	return (&ID{Ext: x[0], Main: x[1]}).Compare(&ID{Ext: y[0], Main: y[1]}), nil
+}
+
+// parseToken returns the timestamp and sequence of the ordering token
+func parseToken(s string) (v [2]int64, err error) {
+	i := strings.IndexByte(s, '.')
+	if i < 0 {
+		return v, ErrTokenInvalid
+	}
+	for k, part := range []string{s[:i], s[i+1:]} {
+		if part == "" || part[0] == '-' || part[0] == '+' {
+			return v, ErrTokenInvalid
+		}
+		if v[k], err = strconv.ParseInt(part, 36, 64); err != nil {
+			return v, ErrTokenInvalid
+		}
+	}
+	return v, nil
+}
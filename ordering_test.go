@@ -0,0 +1,42 @@
+package tsid
+
+import "testing"
+
+func TestOrderingToken(t *testing.T) {
+	b, e := Make(*Config(9, 3, Sequence(12), Host(6, 0), Node(4, 0), Timestamp(41, TimestampMilliseconds)))
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	c, _ := Make(*Config(1, 1, Sequence(12), Host(6, 0), Node(4, 0), Timestamp(41, TimestampMilliseconds)))
+	x, y := b.Next(), c.Next()
+	z := b.Next()
+	tx, _ := b.OrderingToken(x)
+	ty, _ := c.OrderingToken(y)
+	tz, e := b.OrderingToken(z)
+	if e != nil {
+		t.Fatalf("want: a token, got: error %s", e)
+		return
+	}
+	if v, e := CompareTokens(tx, tz); e != nil || v != -1 {
+		t.Errorf("want: -1, got: %d, error %v", v, e)
+	}
+	if v, _ := CompareTokens(tz, tx); v != 1 {
+		t.Errorf("want: 1, got: %d", v)
+	}
+	if v, _ := CompareTokens(tx, tx); v != 0 {
+		t.Errorf("want: 0, got: %d", v)
+	}
+	if v, _ := CompareTokens(ty, tz); v > 0 {
+		t.Errorf("want: not after across the nodes, got: %d (%s, %s)", v, ty, tz)
+	}
+	for _, s := range []string{"", "1", "1.", ".1", "-1.1", "1.!"} {
+		if _, e = CompareTokens(s, tx); e != ErrTokenInvalid {
+			t.Errorf("%q want: error(%s), got: %v", s, ErrTokenInvalid, e)
+		}
+	}
+	d, _ := NewDecoder(*Segments(Sequence(12), Timestamp(41, TimestampMilliseconds).Hide()))
+	if _, e = d.Public().OrderingToken(&ID{Main: 1 << 12}); e != ErrRedacted {
+		t.Errorf("want: error(%s), got: %v", ErrRedacted, e)
+	}
+}
@@ -0,0 +1,76 @@
+package tsid
+
+import (
+	"errors"
+	"math/bits"
+	"sync/atomic"
+)
+
+// ShardKey is the settings key of the Shard segment
+const ShardKey = "Shard"
+
+// ErrShardWidth indicates that the layout has no Shard segment,
+// or it is too narrow for the number of the shards
+var ErrShardWidth = errors.New("tsid: the Shard segment is missing or too narrow")
+
+// Shard to make a bit-segment, which value is the index of the builder in the BuilderPool
+func Shard(width byte) Bits {
+	return Option(width, ShardKey, 0)
+}
+
+// BuilderPool spreads the generation across the builders differentiated by the
+// Shard segment, so the callers on many cores do not contend for one lock.
+type BuilderPool struct {
+	next     uint64
+	builders []*Builder
+}
+
+// NewBuilderPool returns a pool of n builders of the layout, which MUST have
+// a Shard segment wide enough for n.
+func NewBuilderPool(opt Options, n int) (*BuilderPool, error) {
+	if n < 1 {
+		n = 1
+	}
+	width := byte(0)
+	for _, segment := range opt.segments {
+		if segment.Source == Settings && segment.Key == ShardKey {
+			width = segment.Width
+			break
+		}
+	}
+	if width == 0 || bits.Len(uint(n-1)) > int(width) {
+		return nil, ErrShardWidth
+	}
+	p := &BuilderPool{builders: make([]*Builder, n)}
+	for i := range p.builders {
+		o := opt.clone()
+		o.Set(ShardKey, int64(i))
+		b, err := Make(o)
+		if err != nil {
+			return nil, err
+		}
+		p.builders[i] = b
+	}
+	return p, nil
+}
+
+// Builders returns the builders of the pool, in the order of the shards
+func (p *BuilderPool) Builders() []*Builder {
+	return append([]*Builder(nil), p.builders...)
+}
+
+// builder returns the builder of the next shard in round-robin
+func (p *BuilderPool) builder() *Builder {
+	i := atomic.AddUint64(&p.next, 1)
+	return p.builders[i%uint64(len(p.builders))]
+}
+
+// Next returns the next ID of a builder of the pool, see Builder.Next
+func (p *BuilderPool) Next(argv ...int64) *ID {
+	return p.builder().Next(argv...)
+}
+
+// TryNext returns the next ID of a builder of the pool, see Builder.TryNext
+func (p *BuilderPool) TryNext(argv ...int64) (*ID, error) {
+	return p.builder().TryNext(argv...)
+}
@@ -0,0 +1,52 @@
+package tsid
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestBuilderPool(t *testing.T) {
+	opt := Segments(Sequence(12), Shard(2), Env(4, "TSID_POOL", 0), Timestamp(41, TimestampMilliseconds))
+	if _, e := NewBuilderPool(*opt, 5); e != ErrShardWidth {
+		t.Errorf("want: error(%s), got: %v", ErrShardWidth, e)
+	}
+	if _, e := NewBuilderPool(Default(), 2); e != ErrShardWidth {
+		t.Errorf("want: error(%s), got: %v", ErrShardWidth, e)
+	}
+	p, e := NewBuilderPool(*opt, 4)
+	if e != nil {
+		t.Fatalf("want: a pool, got: error %s", e)
+		return
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	seen := map[ID]bool{}
+	shards := map[int64]bool{}
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				id, e := p.TryNext()
+				if e != nil {
+					t.Errorf("want: an ID, got: error %s", e)
+					return
+				}
+				mu.Lock()
+				if seen[*id] {
+					t.Errorf("want: unique IDs, got: duplicated %s", id)
+				}
+				seen[*id] = true
+				shards[id.Main>>12&3] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(shards) != 4 || len(p.Builders()) != 4 {
+		t.Errorf("want: 4 shards, got: %v", shards)
+	}
+	if p.Next() == nil {
+		t.Error("want: an ID, got: nil")
+	}
+}
@@ -0,0 +1,124 @@
+package tsid
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExceeded indicates that the identity has used up its issuance quota
+var ErrQuotaExceeded = errors.New("tsid: the quota is exceeded")
+
+// QuotaStore is the storage of the issuance quotas, which MUST be safe for concurrent use
+type QuotaStore interface {
+	// Take consumes n from the quota of the identity,
+	// returns false if the remaining quota is not enough.
+	Take(identity string, n int64) (bool, error)
+}
+
+// QuotaRefunder is implemented by the QuotaStores which give back the quota
+// taken for the IDs failed to generate, see Quota.Next
+type QuotaRefunder interface {
+	// Refund gives n back to the quota of the identity
+	Refund(identity string, n int64) error
+}
+
+// Quota generates IDs on behalf of the identities (API key, tenant, ...),
+// enforcing the issuance quota of each identity.
+type Quota struct {
+	builder *Builder
+	store   QuotaStore
+}
+
+// NewQuota returns a Quota which generates IDs by b and consumes the quotas in s
+func NewQuota(b *Builder, s QuotaStore) *Quota {
+	return &Quota{builder: b, store: s}
+}
+
+// Next returns the next ID issued to the identity, or ErrQuotaExceeded. The
+// quota is taken before the ID is generated, so the rejected calls do not use
+// up the sequence, and it is refunded if the generation fails and the store
+// is a QuotaRefunder.
+func (q *Quota) Next(identity string, argv ...int64) (*ID, error) {
+	ok, err := q.store.Take(identity, 1)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrQuotaExceeded
+	}
+	id, err := q.builder.TryNext(argv...)
+	if err != nil {
+		if r, ok := q.store.(QuotaRefunder); ok {
+			_ = r.Refund(identity, 1)
+		}
+		return nil, err
+	}
+	return id, nil
+}
+
+// MemoryQuota is an in-process QuotaStore of fixed windows
+type MemoryQuota struct {
+	sync.Mutex
+
+	limit  int64
+	window time.Duration
+	limits map[string]int64
+	usages map[string]*quotaUsage
+}
+
+type quotaUsage struct {
+	start time.Time
+	used  int64
+}
+
+// NewMemoryQuota returns a MemoryQuota which allows every identity
+// to take limit IDs per window.
+func NewMemoryQuota(limit int64, window time.Duration) *MemoryQuota {
+	return &MemoryQuota{
+		limit:  limit,
+		window: window,
+		limits: map[string]int64{},
+		usages: map[string]*quotaUsage{},
+	}
+}
+
+// Set to set the limit of the identity
+func (m *MemoryQuota) Set(identity string, limit int64) *MemoryQuota {
+	m.Lock()
+	defer m.Unlock()
+	m.limits[identity] = limit
+	return m
+}
+
+func (m *MemoryQuota) Take(identity string, n int64) (bool, error) {
+	m.Lock()
+	defer m.Unlock()
+	limit, found := m.limits[identity]
+	if !found {
+		limit = m.limit
+	}
+	now := time.Now()
+	u, found := m.usages[identity]
+	if !found || now.Sub(u.start) >= m.window {
+		u = &quotaUsage{start: now}
+		m.usages[identity] = u
+	}
+	if u.used+n > limit {
+		return false, nil
+	}
+	u.used += n
+	return true, nil
+}
+
+func (m *MemoryQuota) Refund(identity string, n int64) error {
+	m.Lock()
+	defer m.Unlock()
+	if u, found := m.usages[identity]; found {
+		u.used -= n
+		if u.used < 0 {
+			u.used = 0
+		}
+	}
+	return nil
+}
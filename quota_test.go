@@ -0,0 +1,56 @@
+package tsid
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuota(t *testing.T) {
+	b, e := Make(Default())
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	q := NewQuota(b, NewMemoryQuota(3, time.Hour).Set("vip", 5))
+	for _, o := range []struct {
+		identity string
+		limit    int
+	}{
+		{"guest", 3},
+		{"vip", 5},
+	} {
+		for i := 0; i < o.limit; i++ {
+			if _, e = q.Next(o.identity); e != nil {
+				t.Errorf("%s want: an ID, got: error %s", o.identity, e)
+			}
+		}
+		if _, e = q.Next(o.identity); e != ErrQuotaExceeded {
+			t.Errorf("%s want: error(%s), got: %v", o.identity, ErrQuotaExceeded, e)
+		}
+	}
+	// the rejected calls do not generate
+	if n := b.Stats().Generated; n != 8 {
+		t.Errorf("want: 8 generated, got: %d", n)
+	}
+	r := NewQuota(b, NewMemoryQuota(1, time.Millisecond))
+	_, _ = r.Next("guest")
+	time.Sleep(2 * time.Millisecond)
+	if _, e = r.Next("guest"); e != nil {
+		t.Errorf("want: the quota is reset, got: error %s", e)
+	}
+
+	opt := *Config(1, 2, Sequence(8), Timestamp(41, TimestampMilliseconds))
+	opt.OnExhausted = ExhaustionError
+	x, _ := Make(opt)
+	x.WithClock(NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 0))
+	q = NewQuota(x, NewMemoryQuota(257, time.Hour))
+	for i := 0; i < 256; i++ {
+		_, _ = q.Next("guest")
+	}
+	if _, e = q.Next("guest"); e != ErrSequenceExhausted {
+		t.Errorf("want: error(%s), got: %v", ErrSequenceExhausted, e)
+	}
+	if ok, _ := q.store.Take("guest", 1); !ok {
+		t.Error("want: the quota is not taken by the failure, got: exceeded")
+	}
+}
@@ -0,0 +1,123 @@
+package tsid
+
+import (
+	"encoding/binary"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// RandPool is the tuning of the random pool of a builder, which reads the
+// random bytes of the RandomID segments in chunks rather than per value.
+type RandPool struct {
+	// ChunkSize is the number of the bytes read per refill, default 4096
+	ChunkSize int
+	// Threshold is the number of the remaining bytes which triggers the
+	// background refill, default a quarter of ChunkSize
+	Threshold int
+	// Background is used to refill by a dedicated goroutine ahead of use,
+	// instead of inline when the pool is empty
+	Background bool
+}
+
+// randPool is the random pool of a builder
+type randPool struct {
+	// misses is accessed atomically, keep it 64-bit aligned
+	misses uint64
+
+	mu        sync.Mutex
+	buf       []byte
+	off       int
+	chunk     int
+	threshold int
+	src       io.Reader
+	spare     chan []byte
+	want      chan struct{}
+}
+
+func newRandPool(cfg RandPool, src io.Reader) *randPool {
+	p := &randPool{chunk: cfg.ChunkSize, threshold: cfg.Threshold, src: src}
+	if p.chunk < 8 {
+		p.chunk = 4096
+	}
+	if p.threshold <= 0 || p.threshold > p.chunk {
+		p.threshold = p.chunk / 4
+	}
+	if cfg.Background {
+		p.spare = make(chan []byte, 1)
+		p.want = make(chan struct{}, 1)
+		go func(chunk int, src io.Reader, want <-chan struct{}, spare chan<- []byte) {
+			for range want {
+				buf := make([]byte, chunk)
+				if _, err := io.ReadFull(src, buf); err != nil {
+					continue
+				}
+				spare <- buf
+			}
+		}(p.chunk, src, p.want, p.spare)
+		p.want <- struct{}{}
+	}
+	return p
+}
+
+// read returns a random number of the width w, see Rand
+func (p *randPool) read(w byte) (int64, error) {
+	if w < 1 || w > 63 {
+		return 0, nil
+	}
+	n := int(w+7) / 8
+	p.mu.Lock()
+	var err error
+	if len(p.buf)-p.off < n {
+		err = p.refill()
+	}
+	var a [8]byte
+	if err == nil {
+		copy(a[:], p.buf[p.off:p.off+n])
+		p.off += n
+	}
+	if p.want != nil && len(p.buf)-p.off < p.threshold {
+		select {
+		case p.want <- struct{}{}:
+		default:
+		}
+	}
+	p.mu.Unlock()
+	return int64(binary.LittleEndian.Uint64(a[:]) & (1<<w - 1)), err
+}
+
+// close stops the background refill
+func (p *randPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.want != nil {
+		close(p.want)
+		p.want = nil
+		// unblocks the goroutine sending a chunk to the full spare
+		select {
+		case <-p.spare:
+		default:
+		}
+	}
+}
+
+// refill replaces the buffer by the spare chunk if ready, otherwise reads a chunk
+// inline, which is counted as a miss. The caller MUST hold the lock.
+func (p *randPool) refill() error {
+	if p.spare != nil {
+		select {
+		case buf := <-p.spare:
+			p.buf, p.off = buf, 0
+			return nil
+		default:
+		}
+	}
+	atomic.AddUint64(&p.misses, 1)
+	buf := make([]byte, p.chunk)
+	if _, err := io.ReadFull(p.src, buf); err != nil {
+		p.buf, p.off = nil, 0
+		return err
+	}
+	p.buf, p.off = buf, 0
+	return nil
+}
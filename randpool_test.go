@@ -0,0 +1,39 @@
+package tsid
+
+import (
+	cr "crypto/rand"
+	"testing"
+)
+
+func TestRandPool(t *testing.T) {
+	p := newRandPool(RandPool{ChunkSize: 64}, cr.Reader)
+	seen := map[int64]bool{}
+	for i := 0; i < 64; i++ {
+		v, _ := p.read(16)
+		if v < 0 || v >= 1<<16 {
+			t.Errorf("want: 16 bits, got: %d", v)
+		}
+		seen[v] = true
+	}
+	// 64 values of 2 bytes from the chunks of 64 bytes
+	if p.misses != 2 || len(seen) < 32 {
+		t.Errorf("want: 2 misses, random values, got: %d, %d", p.misses, len(seen))
+	}
+	if v, _ := p.read(0); v != 0 {
+		t.Errorf("want: 0, got: %d", v)
+	}
+
+	opt := *Segments(Sequence(12), Random(40), Timestamp(41, TimestampMilliseconds))
+	opt.RandPool = &RandPool{ChunkSize: 1 << 12, Background: true}
+	b, e := Make(opt)
+	if e != nil || b.rand == nil {
+		t.Fatalf("want: a builder with the pool, got: error %v", e)
+		return
+	}
+	for i := 0; i < 10000; i++ {
+		b.Next()
+	}
+	if s := b.Stats(); s.RandMisses > 20 {
+		t.Errorf("want: few misses, got: %d", s.RandMisses)
+	}
+}
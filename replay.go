@@ -0,0 +1,221 @@
+package tsid
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrReplayDiverged indicates that the replay reads more inputs than recorded
+var ErrReplayDiverged = errors.New("tsid: the replay diverged from the recording")
+
+// Recording is the inputs read by a builder made by Record: the clock, the
+// random stream, the values of the providers, the environment variables and
+// the host name, in JSON for the cross-language compatibility suites.
+type Recording struct {
+	mu sync.Mutex
+	// Clock is the Unix nanoseconds read from the clock, in order
+	Clock []int64 `json:"clock"`
+	// Random is the random stream
+	Random []byte `json:"random"`
+	// Providers is the values read from the providers by name, in order
+	Providers map[string][]ProviderRead `json:"providers,omitempty"`
+	// Env is the environment variables looked up, unset ones are empty
+	Env      map[string]string `json:"env,omitempty"`
+	Hostname string            `json:"hostname,omitempty"`
+}
+
+// ProviderRead is a value read from a provider
+type ProviderRead struct {
+	Value int64 `json:"value"`
+	// Err is the message of the error, empty if the read succeeded
+	Err string `json:"err,omitempty"`
+}
+
+// Record makes a builder of the options which records its inputs, so Replay
+// can regenerate the same IDs byte for byte. It records the sources of
+// Options.Environment, or the OS-backed ones. Generate the IDs from one
+// goroutine, and do not replace the clock by Builder.WithClock.
+func Record(opt Options) (*Builder, *Recording, error) {
+	rec := &Recording{Providers: map[string][]ProviderRead{}, Env: map[string]string{}}
+	base := opt.Environment
+	var now func() time.Time
+	switch {
+	case base != nil && base.Clock != nil:
+		now = base.Clock.Now
+	case opt.Monotonic:
+		now = monotonic()
+	default:
+		now = time.Now
+	}
+	getenv := os.Getenv
+	if base != nil && base.Getenv != nil {
+		getenv = base.Getenv
+	}
+	env := &Environment{
+		Clock: clockFunc(func() time.Time {
+			t := now()
+			rec.mu.Lock()
+			rec.Clock = append(rec.Clock, t.UnixNano())
+			rec.mu.Unlock()
+			return t
+		}),
+		Rand: &recordingReader{r: base.random(), rec: rec},
+		Getenv: func(key string) string {
+			v := getenv(key)
+			rec.mu.Lock()
+			rec.Env[key] = v
+			rec.mu.Unlock()
+			return v
+		},
+		Hostname: func() (string, error) {
+			hostname := os.Hostname
+			if base != nil && base.Hostname != nil {
+				hostname = base.Hostname
+			}
+			s, err := hostname()
+			rec.mu.Lock()
+			rec.Hostname = s
+			rec.mu.Unlock()
+			return s, err
+		},
+		Providers: map[string]DataProvider{},
+	}
+	for _, segment := range opt.segments {
+		if segment.Source != Provider {
+			continue
+		}
+		if p, found := base.provider(segment.Key); found {
+			env.Providers[segment.Key] = &recordingProvider{p: p, name: segment.Key, rec: rec}
+		}
+	}
+	opt.Environment = env
+	b, err := Make(opt)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b, rec, nil
+}
+
+// Replay makes a builder of the options which reads the inputs of the recording,
+// it generates the same IDs as the recorded builder when called the same way.
+// The clock advances by a millisecond per read after the recorded times run out,
+// and the other inputs fail with ErrReplayDiverged.
+func Replay(opt Options, rec *Recording) (*Builder, error) {
+	p := &replayer{rec: rec, providers: map[string]int{}}
+	env := &Environment{
+		Clock: clockFunc(p.now),
+		Rand:  p,
+		Getenv: func(key string) string {
+			return rec.Env[key]
+		},
+		Hostname: func() (string, error) {
+			if rec.Hostname == "" {
+				return "", ErrReplayDiverged
+			}
+			return rec.Hostname, nil
+		},
+		Providers: map[string]DataProvider{},
+	}
+	for name := range rec.Providers {
+		env.Providers[name] = &replayProvider{p: p, name: name}
+	}
+	opt.Environment = env
+	// the fallback keystream is not replayable
+	opt.OnRandFailure = RandError
+	return Make(opt)
+}
+
+// clockFunc adapts a function to Clock
+type clockFunc func() time.Time
+
+func (f clockFunc) Now() time.Time { return f() }
+
+// recordingReader records the bytes read from r
+type recordingReader struct {
+	r   io.Reader
+	rec *Recording
+}
+
+func (r *recordingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.rec.mu.Lock()
+	r.rec.Random = append(r.rec.Random, p[:n]...)
+	r.rec.mu.Unlock()
+	return n, err
+}
+
+// recordingProvider records the values read from p
+type recordingProvider struct {
+	p    DataProvider
+	name string
+	rec  *Recording
+}
+
+func (r *recordingProvider) Read(query ...interface{}) (int64, error) {
+	v, err := r.p.Read(query...)
+	read := ProviderRead{Value: v}
+	if err != nil {
+		read.Err = err.Error()
+	}
+	r.rec.mu.Lock()
+	r.rec.Providers[r.name] = append(r.rec.Providers[r.name], read)
+	r.rec.mu.Unlock()
+	return v, err
+}
+
+// replayer reads the inputs of a recording in order
+type replayer struct {
+	mu        sync.Mutex
+	rec       *Recording
+	clock     int
+	last      time.Time
+	random    int
+	providers map[string]int
+}
+
+func (p *replayer) now() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.clock < len(p.rec.Clock) {
+		p.last = time.Unix(0, p.rec.Clock[p.clock])
+		p.clock++
+	} else {
+		p.last = p.last.Add(time.Millisecond)
+	}
+	return p.last
+}
+
+func (p *replayer) Read(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.random >= len(p.rec.Random) && len(b) > 0 {
+		return 0, ErrReplayDiverged
+	}
+	n := copy(b, p.rec.Random[p.random:])
+	p.random += n
+	return n, nil
+}
+
+// replayProvider reads the recorded values of a provider
+type replayProvider struct {
+	p    *replayer
+	name string
+}
+
+func (r *replayProvider) Read(...interface{}) (int64, error) {
+	r.p.mu.Lock()
+	defer r.p.mu.Unlock()
+	i := r.p.providers[r.name]
+	reads := r.p.rec.Providers[r.name]
+	if i >= len(reads) {
+		return 0, ErrReplayDiverged
+	}
+	r.p.providers[r.name] = i + 1
+	if reads[i].Err != "" {
+		return reads[i].Value, errors.New(reads[i].Err)
+	}
+	return reads[i].Value, nil
+}
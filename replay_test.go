@@ -0,0 +1,55 @@
+package tsid
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestReplay(t *testing.T) {
+	Register("test_replay", &flakySource{failures: 3})
+	opt := *Config(1, 2,
+		Sequence(8),
+		Random(12),
+		Data(4, "test_replay", 1),
+		Env(4, "TSID_TEST_REPLAY", 2),
+		Env(6, EnvHostname, 0),
+		Timestamp(41, TimestampMilliseconds))
+	b, rec, e := Record(opt)
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	var want []ID
+	for i := 0; i < 600; i++ {
+		id, e := b.TryNext()
+		if e != nil {
+			t.Fatalf("want: an ID, got: error %s", e)
+			return
+		}
+		want = append(want, *id)
+	}
+	data, e := json.Marshal(rec)
+	if e != nil {
+		t.Fatalf("want: JSON, got: error %s", e)
+		return
+	}
+	var loaded Recording
+	if e = json.Unmarshal(data, &loaded); e != nil {
+		t.Fatalf("want: a recording, got: error %s", e)
+		return
+	}
+	r, e := Replay(opt, &loaded)
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	for i, w := range want {
+		if id, e := r.TryNext(); e != nil || *id != w {
+			t.Fatalf("want: %s at %d, got: %v, error %v", &w, i, id, e)
+			return
+		}
+	}
+	if _, e = r.TryNext(); e != ErrRandUnavailable {
+		t.Errorf("want: %s after the recording, got: %v", ErrRandUnavailable, e)
+	}
+}
@@ -0,0 +1,88 @@
+package tsid
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen indicates that the circuit breaker of the provider is open
+var ErrCircuitOpen = errors.New("tsid: the circuit breaker of the provider is open")
+
+// RetryPolicy is the retry and circuit breaker policy of a provider, see Retry
+type RetryPolicy struct {
+	// Attempts is the maximum number of reads per value, at least 1
+	Attempts int
+	// Backoff is the delay before the second read, doubled per read
+	Backoff,
+	// MaxBackoff limits the delay, 0 means no limit
+	MaxBackoff time.Duration
+	// Threshold is the number of consecutive failed values which opens
+	// the breaker, 0 disables the breaker
+	Threshold int
+	// Cooldown is the time the breaker stays open, after which one value
+	// is read to close it again
+	Cooldown time.Duration
+}
+
+// retrying is the provider decorated by Retry
+type retrying struct {
+	sync.Mutex
+	provider DataProvider
+	policy   RetryPolicy
+	sleep    func(time.Duration)
+	failures int
+	open     time.Time
+}
+
+// Retry returns a provider which retries the failed reads of p with exponential
+// backoff, and stops reading it for a while after too many consecutive failures,
+// register it under its own name to configure it per segment:
+//
+//	tsid.Register("region.retry", tsid.Retry(region, tsid.RetryPolicy{Attempts: 3, Backoff: time.Millisecond}))
+//	opt.Add(tsid.Data(8, "region.retry", 0))
+//
+// The builder holds its lock while reading, keep the delays short.
+// The returned provider is bounded if p is.
+func Retry(p DataProvider, policy RetryPolicy) DataProvider {
+	if policy.Attempts < 1 {
+		policy.Attempts = 1
+	}
+	return &retrying{provider: p, policy: policy, sleep: time.Sleep}
+}
+
+func (r *retrying) Read(query ...interface{}) (v int64, err error) {
+	r.Lock()
+	defer r.Unlock()
+	if !r.open.IsZero() && time.Now().Before(r.open) {
+		return 0, ErrCircuitOpen
+	}
+	delay := r.policy.Backoff
+	for i := 0; i < r.policy.Attempts; i++ {
+		if i > 0 {
+			r.sleep(delay)
+			delay *= 2
+			if r.policy.MaxBackoff > 0 && delay > r.policy.MaxBackoff {
+				delay = r.policy.MaxBackoff
+			}
+		}
+		if v, err = r.provider.Read(query...); err == nil {
+			r.failures = 0
+			r.open = time.Time{}
+			return v, nil
+		}
+	}
+	r.failures++
+	if r.policy.Threshold > 0 && r.failures >= r.policy.Threshold {
+		r.open = time.Now().Add(r.policy.Cooldown)
+	}
+	return 0, err
+}
+
+// MaxValue returns the maximum value of the decorated provider, 0 if unbounded
+func (r *retrying) MaxValue() int64 {
+	if p, ok := r.provider.(BoundedProvider); ok {
+		return p.MaxValue()
+	}
+	return 0
+}
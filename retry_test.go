@@ -0,0 +1,55 @@
+package tsid
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type flakySource struct {
+	reads, failures int
+}
+
+func (f *flakySource) Read(query ...interface{}) (int64, error) {
+	f.reads++
+	if f.reads <= f.failures {
+		return 0, errors.New("blip")
+	}
+	return 7, nil
+}
+
+func TestRetry(t *testing.T) {
+	f := &flakySource{failures: 2}
+	r := Retry(f, RetryPolicy{Attempts: 3, Backoff: time.Millisecond, MaxBackoff: time.Millisecond}).(*retrying)
+	var delays []time.Duration
+	r.sleep = func(d time.Duration) { delays = append(delays, d) }
+	if v, e := r.Read(); e != nil || v != 7 || len(delays) != 2 {
+		t.Errorf("want: 7 after 2 retries, got: %d, error %v, delays %v", v, e, delays)
+	}
+
+	f = &flakySource{failures: 100}
+	r = Retry(f, RetryPolicy{Attempts: 2, Threshold: 2, Cooldown: time.Hour}).(*retrying)
+	r.sleep = func(time.Duration) {}
+	for i := 0; i < 2; i++ {
+		if _, e := r.Read(); e == nil || e == ErrCircuitOpen {
+			t.Errorf("want: error of the provider, got: %v", e)
+		}
+	}
+	if _, e := r.Read(); e != ErrCircuitOpen || f.reads != 4 {
+		t.Errorf("want: error(%s) after 4 reads, got: %v, %d reads", ErrCircuitOpen, e, f.reads)
+	}
+	r.open = time.Now().Add(-time.Second)
+	f.failures = 0
+	if v, e := r.Read(); e != nil || v != 7 || r.failures != 0 {
+		t.Errorf("want: closed breaker, got: %d, error %v", v, e)
+	}
+
+	if m := Retry(&testBoundedSource{max: 40}, RetryPolicy{}).(BoundedProvider).MaxValue(); m != 40 {
+		t.Errorf("want: 40, got: %d", m)
+	}
+	Register("test_retry", Retry(&flakySource{failures: 1}, RetryPolicy{Attempts: 2}))
+	b, _ := Make(*Segments(Sequence(12), Data(4, "test_retry", 0), Timestamp(41, TimestampMilliseconds)))
+	if vs, e := b.Parse(b.Next()); e != nil || vs[1].Value != 7 {
+		t.Errorf("want: 7, got: %v, error %v", vs, e)
+	}
+}
@@ -0,0 +1,144 @@
+package tsid
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+const (
+	// RotatePeriod is the default period of the alphabet rotation
+	RotatePeriod = 24 * time.Hour
+	// RotateWindows is the default number of the windows accepted by Rotating.Decode
+	RotateWindows = 2
+	// rotateCheckWidth is the number of the check digits
+	rotateCheckWidth = 2
+)
+
+var (
+	// ErrRotateMismatch indicates that no key and window can decode the string
+	ErrRotateMismatch = errors.New("tsid: no key or time window matches the encoded string")
+	// ErrRotateKeys indicates that the Rotating encoder has no key or an empty key
+	ErrRotateKeys = errors.New("tsid: the rotating encoder requires the non-empty keys")
+)
+
+// Rotating is a Base64 encoder whose alphabet permutation rotates every Period,
+// derived from a shared secret, which makes sequential enumeration of the public
+// IDs impractical, see NewRotating. Two check digits of the HMAC are appended to
+// recognize the key and window: they are 12 bits, so a mistyped or forged string
+// is accepted with the probability of about len(Keys) * Windows / 4096, and no
+// class of errors is always detected. They are not an authentication tag.
+type Rotating struct {
+	// Keys are the shared secrets, the first key is used to encode and all keys
+	// are tried to decode, prepend a new key to rotate keys. It MUST NOT be empty,
+	// Encode returns "" and Decode fails with ErrRotateKeys otherwise
+	Keys [][]byte
+	// Period is the duration of a time window, default is RotatePeriod
+	Period time.Duration
+	// Windows is the number of the latest windows accepted by Decode, default is RotateWindows
+	Windows int
+	// Aligned is the same as Base64.Aligned
+	Aligned bool
+}
+
+// NewRotating returns the Rotating encoder of the keys, the first one encodes
+func NewRotating(keys ...[]byte) (*Rotating, error) {
+	r := &Rotating{Keys: keys}
+	if !r.valid() {
+		return nil, ErrRotateKeys
+	}
+	return r, nil
+}
+
+// valid reports whether the encoder has the keys, all non-empty
+func (r *Rotating) valid() bool {
+	for _, key := range r.Keys {
+		if len(key) == 0 {
+			return false
+		}
+	}
+	return len(r.Keys) > 0
+}
+
+func (r *Rotating) window(t time.Time) int64 {
+	p := r.Period
+	if p <= 0 {
+		p = RotatePeriod
+	}
+	return t.UnixNano() / int64(p)
+}
+
+// alphabet returns the permutation of base64Digits of the key in the window
+func alphabet(key []byte, window int64) string {
+	buf := []byte(base64Digits)
+	var seed [8]byte
+	binary.BigEndian.PutUint64(seed[:], uint64(window))
+	m := hmac.New(sha256.New, key)
+	m.Write(seed[:])
+	stream := m.Sum(nil)
+	for i := len(buf) - 1; i > 0; i-- {
+		if len(stream) < 2 {
+			m.Write(stream)
+			stream = m.Sum(nil)
+		}
+		j := int(binary.BigEndian.Uint16(stream)) % (i + 1)
+		stream = stream[2:]
+		buf[i], buf[j] = buf[j], buf[i]
+	}
+	return string(buf)
+}
+
+// check returns the check digits of the encoded string
+func check(key []byte, window int64, digits, no string) string {
+	var seed [8]byte
+	binary.BigEndian.PutUint64(seed[:], uint64(window))
+	m := hmac.New(sha256.New, key)
+	m.Write(seed[:])
+	m.Write([]byte(no))
+	sum := m.Sum(nil)
+	c := make([]byte, rotateCheckWidth)
+	for i := range c {
+		c[i] = digits[sum[i]&63]
+	}
+	return string(c)
+}
+
+func (r *Rotating) encodeAt(id *ID, window int64) string {
+	key := r.Keys[0]
+	digits := alphabet(key, window)
+	no := encodeBase64(id, digits, defaultSignMarker, 0, r.Aligned)
+	return no + check(key, window, digits, no)
+}
+
+func (r *Rotating) Encode(id *ID) string {
+	if !r.valid() {
+		return ""
+	}
+	return r.encodeAt(id, r.window(time.Now()))
+}
+
+func (r *Rotating) Decode(no string) (id *ID, err error) {
+	if !r.valid() {
+		return nil, ErrRotateKeys
+	}
+	if len(no) <= rotateCheckWidth {
+		return nil, decodeError(no, DecodeErrorEmpty)
+	}
+	n := r.Windows
+	if n <= 0 {
+		n = RotateWindows
+	}
+	body, sum := no[:len(no)-rotateCheckWidth], no[len(no)-rotateCheckWidth:]
+	current := r.window(time.Now())
+	for w := current; w > current-int64(n); w-- {
+		for _, key := range r.Keys {
+			digits := alphabet(key, w)
+			if check(key, w, digits, body) == sum {
+				return decodeBase64(body, digits, defaultSignMarker)
+			}
+		}
+	}
+	return nil, ErrRotateMismatch
+}
@@ -0,0 +1,51 @@
+package tsid
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRotating(t *testing.T) {
+	r := &Rotating{Keys: [][]byte{[]byte("secret")}, Aligned: true}
+	id := &ID{Main: time.Now().UnixNano(), Ext: 99}
+	no := r.Encode(id)
+	if no[:len(no)-rotateCheckWidth] == (&Base64{Aligned: true}).Encode(id) {
+		t.Error("want: a permuted alphabet, got: base64Digits")
+	}
+	if d, e := r.Decode(no); e != nil || !d.Equal(id) {
+		t.Errorf("want: %s, got: %v, error %v", id, d, e)
+	}
+	w := r.window(time.Now())
+	if alphabet(r.Keys[0], w) == alphabet(r.Keys[0], w-1) {
+		t.Error("want: the alphabet rotates, got: the same alphabet")
+	}
+	// the previous window is accepted, the older one is not
+	if d, e := r.Decode(r.encodeAt(id, w-1)); e != nil || !d.Equal(id) {
+		t.Errorf("want: %s, got: %v, error %v", id, d, e)
+	}
+	if _, e := r.Decode(r.encodeAt(id, w-2)); e != ErrRotateMismatch {
+		t.Errorf("want: error(%s), got: %v", ErrRotateMismatch, e)
+	}
+	// key rotation
+	n := &Rotating{Keys: [][]byte{[]byte("new"), []byte("secret")}}
+	if d, e := n.Decode(no); e != nil || !d.Equal(id) {
+		t.Errorf("want: %s, got: %v, error %v", id, d, e)
+	}
+	for _, keys := range [][][]byte{nil, {[]byte("new"), nil}} {
+		if _, e := NewRotating(keys...); e != ErrRotateKeys {
+			t.Errorf("want: error(%s), got: %v", ErrRotateKeys, e)
+		}
+	}
+	z := &Rotating{}
+	if s := z.Encode(id); s != "" {
+		t.Errorf("want: empty, got: %s", s)
+	}
+	if _, e := z.Decode(no); e != ErrRotateKeys {
+		t.Errorf("want: error(%s), got: %v", ErrRotateKeys, e)
+	}
+	if r, e := NewRotating([]byte("secret")); e != nil {
+		t.Errorf("want: an encoder, got: error %s", e)
+	} else if d, e := r.Decode(no); e != nil || !d.Equal(id) {
+		t.Errorf("want: %s, got: %v, error %v", id, d, e)
+	}
+}
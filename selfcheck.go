@@ -0,0 +1,178 @@
+package tsid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrClockInvalid indicates that the clock is earlier than the epoch or goes backwards
+	ErrClockInvalid = errors.New("tsid: the clock is earlier than the epoch or goes backwards")
+	// ErrRollover indicates that the IDs around the sequence rollover are duplicated
+	ErrRollover = errors.New("tsid: duplicated IDs around the sequence rollover")
+	// ErrRoundTrip indicates that the decoded ID differs from the encoded one
+	ErrRoundTrip = errors.New("tsid: the decoded ID differs from the encoded one")
+)
+
+// CheckResult is the result of a step of SelfCheck
+type CheckResult struct {
+	Name    string
+	Err     error
+	Elapsed time.Duration
+}
+
+// SelfCheckReport is the results of the steps of SelfCheck, in order
+type SelfCheckReport struct {
+	Checks []CheckResult
+}
+
+// OK reports whether all the steps passed
+func (r *SelfCheckReport) OK() bool {
+	return r.Err() == nil
+}
+
+// Err returns the error of the first failed step, or nil
+func (r *SelfCheckReport) Err() error {
+	for _, c := range r.Checks {
+		if c.Err != nil {
+			return fmt.Errorf("tsid: self check %q: %w", c.Name, c.Err)
+		}
+	}
+	return nil
+}
+
+// SelfCheck performs a short burn-in of the builder: the clock sanity, the
+// resolution of the environment variables and data providers, the sequence
+// rollover and the round trip of the encoder. It does not consume the sequence
+// of the builder, and is intended to run in the readiness probes.
+func (b *Builder) SelfCheck(ctx context.Context) *SelfCheckReport {
+	r := &SelfCheckReport{}
+	if !b.isReady() {
+		r.Checks = append(r.Checks, CheckResult{Name: "ready", Err: ErrNotReady})
+		return r
+	}
+	opt := b.Options()
+	for _, step := range []struct {
+		name string
+		run  func(*Options) error
+	}{
+		{"clock", b.checkClock},
+		{"sources", b.checkSources},
+		{"rollover", b.checkRollover},
+		{"encoder", b.checkEncoder},
+	} {
+		if err := ctx.Err(); err != nil {
+			r.Checks = append(r.Checks, CheckResult{Name: step.name, Err: err})
+			break
+		}
+		start := time.Now()
+		err := step.run(&opt)
+		r.Checks = append(r.Checks, CheckResult{
+			Name:    step.name,
+			Err:     err,
+			Elapsed: time.Since(start),
+		})
+	}
+	return r
+}
+
+func (b *Builder) checkClock(opt *Options) error {
+	a := time.Now()
+	z := time.Now()
+	if a.UnixMilli() < epoch(opt.EpochMS) || z.Before(a) || z.UnixMilli() < a.UnixMilli() {
+		return ErrClockInvalid
+	}
+	return nil
+}
+
+func (b *Builder) checkSources(opt *Options) error {
+	for i, segment := range opt.segments {
+		index := strconv.Itoa(i)
+		switch segment.Source {
+		case OS:
+			v, found, err := opt.Environment.lookupEnv(segment.Key, segment.mask)
+			if !found {
+				continue
+			}
+			if err != nil || v < 0 || v > segment.mask {
+				return invalidOption(segment.Key, errorInvalidValue, index)
+			}
+		case Provider:
+			v, err := b.data(segment.Key, &segment.query)
+			if err != nil {
+				return invalidOption(segment.Key, err.Error(), index)
+			}
+			if v < 0 || v > segment.mask {
+				return invalidOption(segment.Key, errorInvalidValue, index)
+			}
+		}
+	}
+	return nil
+}
+
+// checkRollover generates the IDs around the sequence rollover by a private
+// builder of the same layout, and checks that they are unique.
+func (b *Builder) checkRollover(opt *Options) error {
+	o := opt.clone()
+	o.Shared = nil
+	o.ClockCheck = false
+	o.sink = nil
+	o.Journal = nil
+	o.Metrics = nil
+	p, err := Make(o)
+	if err != nil {
+		return err
+	}
+	n := p.timeNow()
+	p.now = &n
+	p.sequence = p.sequenceMask - 2
+	if p.lockFree {
+		p.clock = uint64(n.UnixMilli()-p.clockEpoch)<<p.sequenceWidth | uint64(p.sequence)
+	}
+	seen := map[[16]byte]bool{}
+	for i := 0; i < 8; i++ {
+		id, err := p.TryNext()
+		if err != nil {
+			return err
+		}
+		k := id.Array16()
+		if seen[k] {
+			return ErrRollover
+		}
+		seen[k] = true
+	}
+	return nil
+}
+
+func (b *Builder) checkEncoder(opt *Options) error {
+	o := opt.clone()
+	o.Shared = nil
+	o.ClockCheck = false
+	o.sink = nil
+	o.Journal = nil
+	o.Metrics = nil
+	p, err := Make(o)
+	if err != nil {
+		return err
+	}
+	id, err := p.TryNext()
+	if err != nil {
+		return err
+	}
+	var got *ID
+	if b.Encoder != nil {
+		got, err = b.Encoder.Decode(b.Encoder.Encode(id))
+	} else {
+		got, err = ParseID(id.String())
+	}
+	if err != nil {
+		return err
+	}
+	if got.Main != id.Main || got.Ext != id.Ext {
+		return ErrRoundTrip
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package tsid
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSelfCheck(t *testing.T) {
+	b, _ := Make(Default())
+	b.Encoder = &Base64{}
+	r := b.SelfCheck(context.Background())
+	if !r.OK() || len(r.Checks) != 4 {
+		t.Errorf("want: 4 passed checks, got: %v, error %v", r.Checks, r.Err())
+	}
+	if b.sequence != 0 || b.now != nil {
+		t.Errorf("want: untouched builder, got: sequence %d", b.sequence)
+	}
+	if r = (&Builder{}).SelfCheck(context.Background()); r.OK() {
+		t.Error("want: error, got: nothing")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if r = b.SelfCheck(ctx); r.OK() || len(r.Checks) != 1 {
+		t.Errorf("want: canceled, got: %v", r.Checks)
+	}
+	t.Setenv("TSID_SELF_CHECK", "99")
+	b, _ = Make(*Segments(Sequence(12), Env(4, "TSID_SELF_CHECK", 0), Timestamp(41, TimestampMilliseconds)))
+	if r = b.SelfCheck(context.Background()); r.OK() || r.Checks[1].Err == nil {
+		t.Errorf("want: error of the sources, got: %v", r.Checks)
+	}
+}
@@ -0,0 +1,11 @@
+package tsid
+
+import "time"
+
+// SharedSequence is the sequence shared by the builders of several processes
+// on one host, so they can share a single (host, node) pair without collisions.
+type SharedSequence interface {
+	// Tick returns the time and the sequence of the next ID, the sequence restarts
+	// from zero every millisecond and MUST NOT exceed mask.
+	Tick(mask int64) (time.Time, int64, error)
+}
@@ -0,0 +1,68 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package tsid
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// FileSequence is a SharedSequence stored in a file, which is locked by flock
+// while the processes take the sequence.
+type FileSequence struct {
+	sync.Mutex
+	f *os.File
+}
+
+// OpenFileSequence opens (or creates) the file of the shared sequence,
+// all the processes on the host MUST open the same path.
+func OpenFileSequence(path string) (*FileSequence, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSequence{f: f}, nil
+}
+
+// Close closes the file
+func (s *FileSequence) Close() error {
+	return s.f.Close()
+}
+
+func (s *FileSequence) Tick(mask int64) (now time.Time, sequence int64, err error) {
+	s.Lock()
+	defer s.Unlock()
+	fd := int(s.f.Fd())
+	if err = syscall.Flock(fd, syscall.LOCK_EX); err != nil {
+		return
+	}
+	defer func() {
+		_ = syscall.Flock(fd, syscall.LOCK_UN)
+	}()
+	// the file stores the last millisecond and sequence
+	var buf [16]byte
+	if _, err = s.f.ReadAt(buf[:], 0); err != nil && err != io.EOF {
+		return
+	}
+	last := int64(binary.BigEndian.Uint64(buf[:8]))
+	now = time.Now()
+	ms := now.UnixMilli()
+	if ms <= last {
+		sequence = (int64(binary.BigEndian.Uint64(buf[8:])) + 1) & mask
+		if sequence == 0 || ms < last {
+			for ms <= last {
+				now = time.Now()
+				ms = now.UnixMilli()
+			}
+			sequence = 0
+		}
+	}
+	binary.BigEndian.PutUint64(buf[:8], uint64(ms))
+	binary.BigEndian.PutUint64(buf[8:], uint64(sequence))
+	_, err = s.f.WriteAt(buf[:], 0)
+	return now, sequence, err
+}
@@ -0,0 +1,48 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package tsid
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestFileSequence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sequence")
+	const workers, count = 4, 2000
+	ids := make([][]ID, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		s, e := OpenFileSequence(path)
+		if e != nil {
+			t.Fatalf("want: a shared sequence, got: error %s", e)
+			return
+		}
+		defer s.Close()
+		opt := Default()
+		opt.Shared = s
+		b, e := Make(opt)
+		if e != nil {
+			t.Fatalf("want: a builder instance, got: error %s", e)
+			return
+		}
+		ids[i] = make([]ID, count)
+		wg.Add(1)
+		go func(b *Builder, dst []ID) {
+			defer wg.Done()
+			b.NextBatchInto(dst)
+		}(b, ids[i])
+	}
+	wg.Wait()
+	seen := map[ID]bool{}
+	for _, s := range ids {
+		for _, id := range s {
+			if seen[id] {
+				t.Fatalf("want: unique IDs, got: duplicate %s", &id)
+				return
+			}
+			seen[id] = true
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package tsid
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+)
+
+const (
+	// ShortCodeLength is the length of the short codes of NextWithShortCode
+	ShortCodeLength = 8
+	// ShortCodeWindow is the number of the latest short codes of a builder
+	// which are guaranteed to be unique
+	ShortCodeWindow = 1 << 16
+)
+
+// shortCodeDigits is the Crockford's base32 alphabet without the ambiguous I, L, O and U
+const shortCodeDigits = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ShortCode returns a human-friendly reference of the ID, derived from the
+// truncated SHA-256 of its canonical form, length is in [6, 8].
+// Different IDs may have the same short code, see Builder.NextWithShortCode.
+func ShortCode(id *ID, length int) string {
+	if length < 6 {
+		length = 6
+	} else if length > 8 {
+		length = 8
+	}
+	a := id.Array16()
+	h := sha256.Sum256(a[:])
+	v := binary.BigEndian.Uint64(h[:8])
+	buf := make([]byte, length)
+	for i := range buf {
+		buf[i] = shortCodeDigits[v>>59]
+		v <<= 5
+	}
+	return string(buf)
+}
+
+// shortCodes is the window of the latest short codes of a builder
+type shortCodes struct {
+	mu   sync.Mutex
+	ring []string
+	set  map[string]bool
+	pos  int
+}
+
+// add records the code, it returns false if the code is in the window
+func (s *shortCodes) add(code string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.set == nil {
+		s.ring = make([]string, ShortCodeWindow)
+		s.set = make(map[string]bool, ShortCodeWindow)
+	}
+	if s.set[code] {
+		return false
+	}
+	if old := s.ring[s.pos]; old != "" {
+		delete(s.set, old)
+	}
+	s.ring[s.pos] = code
+	s.set[code] = true
+	s.pos = (s.pos + 1) % len(s.ring)
+	return true
+}
+
+// NextWithShortCode returns the next ID with its ShortCode. The short code is
+// unique among the latest ShortCodeWindow codes of the builder: the IDs whose
+// codes collide in the window are skipped.
+func (b *Builder) NextWithShortCode(argv ...int64) (*ID, string, error) {
+	for {
+		id, err := b.TryNext(argv...)
+		if err != nil {
+			return nil, "", err
+		}
+		code := ShortCode(id, ShortCodeLength)
+		if b.codes.add(code) {
+			return id, code, nil
+		}
+	}
+}
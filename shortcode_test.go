@@ -0,0 +1,34 @@
+package tsid
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestShortCode(t *testing.T) {
+	id := &ID{Main: 12345}
+	if a, b := ShortCode(id, 8), ShortCode(&ID{Main: 12345}, 8); a != b || len(a) != 8 {
+		t.Errorf("want: deterministic 8 characters, got: %s, %s", a, b)
+	}
+	if s := ShortCode(id, 3); len(s) != 6 || !strings.HasPrefix(ShortCode(id, 8), s) {
+		t.Errorf("want: 6 characters prefix, got: %s", s)
+	}
+	if s := ShortCode(id, 20); len(s) != 8 || strings.ContainsAny(s, "ILOU") {
+		t.Errorf("want: 8 characters, got: %s", s)
+	}
+	b, _ := Make(Default())
+	seen := map[string]bool{}
+	for i := 0; i < 5000; i++ {
+		id, code, e := b.NextWithShortCode()
+		if e != nil || code != ShortCode(id, ShortCodeLength) || seen[code] {
+			t.Fatalf("want: unique code of the ID, got: %s, %s, error %v", id, code, e)
+			return
+		}
+		seen[code] = true
+	}
+	// the collisions in the window are skipped
+	var c shortCodes
+	if !c.add("AAAAAAAA") || c.add("AAAAAAAA") {
+		t.Error("want: collision, got: added")
+	}
+}
@@ -0,0 +1,31 @@
+package tsid
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrSignMarker indicates that the sign marker of an encoder is invalid
+var ErrSignMarker = errors.New("tsid: the sign marker must be a printable ASCII character other than the digits")
+
+// defaultSignMarker is the prefix of the signed IDs of the encoders by default
+const defaultSignMarker = '!'
+
+// signOf returns the sign marker c, the default one if zero
+func signOf(c byte) byte {
+	if c == 0 {
+		return defaultSignMarker
+	}
+	return c
+}
+
+// validSign reports whether c is a valid sign marker of the digits, which is a
+// printable ASCII character other than the space and the digits
+func validSign(c byte, digits string) bool {
+	return c > ' ' && c <= '~' && strings.IndexByte(digits, c) < 0
+}
+
+// signError returns the error of Decode of an encoder with the invalid marker
+func signError(no string) *DecodeError {
+	return &DecodeError{No: no, Type: DecodeErrorSign, Err: ErrSignMarker}
+}
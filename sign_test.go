@@ -0,0 +1,58 @@
+package tsid
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSignMarker(t *testing.T) {
+	// ID.String keeps '-', and no marker for a signed zero
+	if s := (&ID{Main: 1, Signed: true}).String(); s != "-0000000000001" {
+		t.Errorf("want: -0000000000001, got: %s", s)
+	}
+	if s := (&ID{Signed: true}).String(); s != "0000000000000" {
+		t.Errorf("want: 0000000000000, got: %s", s)
+	}
+	for _, e := range []Encoder{&Base64{Sign: '-'}, &SortableBase64{Sign: '_'}, &Base36{Sign: 'a'}, &Base64{Sign: ' '}} {
+		if _, err := e.Decode("1"); !errors.Is(err, ErrSignMarker) {
+			t.Errorf("%T want: %s, got: %v", e, ErrSignMarker, err)
+		}
+	}
+	base, _ := NewBaseEncoder(Base32Crockford, BaseChecksum, BaseCaseInsensitive)
+	for _, c := range []byte{0, ' ', '0', 'a', 'Z', 0x7f} {
+		if _, err := base.WithSign(c); err != ErrSignMarker {
+			t.Errorf("%q want: %s, got: %v", c, ErrSignMarker, err)
+		}
+	}
+	tilde, err := base.WithSign('~')
+	if err != nil {
+		t.Fatalf("want: nothing, got: error %s", err)
+		return
+	}
+	encoders := []Encoder{&Base64{Sign: '~'}, &SortableBase64{Checksum: true, Sign: '~'}, &Base36{Strict: true, Sign: '~'}, tilde}
+	for _, id := range []*ID{{Signed: true}, {Main: 1, Ext: 2, Signed: true}} {
+		for _, e := range encoders {
+			s := e.Encode(id)
+			if !strings.HasPrefix(s, "~") {
+				t.Errorf("%T want: ~, got: %s", e, s)
+			}
+			if got, err := e.Decode(s); err != nil || !got.Equal(id) || !got.Signed {
+				t.Errorf("%T want: %v, got: %v, error %v", e, id, got, err)
+			}
+		}
+		// the default marker of the other encoders is unchanged
+		if s := (&Base64{}).Encode(id); s[0] != '!' {
+			t.Errorf("want: !, got: %s", s)
+		}
+		if s := base.Encode(id); s[0] != '!' {
+			t.Errorf("want: !, got: %s", s)
+		}
+	}
+	if got, err := ParseID("-1"); err != nil || !got.Signed || got.Main != 1 {
+		t.Errorf("want: -1, got: %v, error %v", got, err)
+	}
+	if got, err := ParseID("!1"); err != nil || !got.Signed || got.Main != 1 {
+		t.Errorf("want: !1, got: %v, error %v", got, err)
+	}
+}
@@ -0,0 +1,54 @@
+package tsid
+
+import "sync/atomic"
+
+// SinkQueueSize is the capacity of the queue between the builder and the sink,
+// IDs generated while the queue is full are dropped.
+const SinkQueueSize = 1024
+
+// Sink to set a function that receives a copy of every generated ID,
+// which is invoked asynchronously and MUST NOT block for a long time.
+func (o *Options) Sink(f func(*ID)) *Options {
+	o.sink = f
+	return o
+}
+
+// SinkDropped returns the number of IDs dropped because the sink queue was full
+func (b *Builder) SinkDropped() uint64 {
+	return atomic.LoadUint64(&b.dropped)
+}
+
+// startSink starts the goroutine which feeds the sink
+func (b *Builder) startSink(f func(*ID)) {
+	b.sink = make(chan *ID, SinkQueueSize)
+	b.sinkDone = make(chan struct{})
+	go func(c <-chan *ID, done chan<- struct{}) {
+		for id := range c {
+			f(id)
+		}
+		close(done)
+	}(b.sink, b.sinkDone)
+}
+
+// emit issues the ID: writes it to the journal, counts it and sends a copy of it
+// to the sink without blocking. The ID MUST NOT be returned if it fails.
+func (b *Builder) emit(main, ext int64) error {
+	if b.journal != nil {
+		if err := b.journal.append(main, ext); err != nil {
+			return err
+		}
+	}
+	atomic.AddUint64(&b.generated, 1)
+	if b.options.Metrics != nil {
+		b.options.Metrics.Generated()
+	}
+	if b.sink == nil {
+		return nil
+	}
+	select {
+	case b.sink <- &ID{Main: main, Ext: ext, Signed: b.options.Signed}:
+	default:
+		atomic.AddUint64(&b.dropped, 1)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package tsid
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSink(t *testing.T) {
+	c := make(chan *ID, SinkQueueSize)
+	opt := Default()
+	opt.Sink(func(id *ID) {
+		c <- id
+	})
+	b, e := Make(opt)
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	want := b.Next()
+	select {
+	case got := <-c:
+		if got == want || !got.Equal(want) {
+			t.Errorf("want: a copy of %s, got: %s", want, got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("want: the sink is invoked, got: timeout")
+	}
+	if b.SinkDropped() != 0 {
+		t.Errorf("want: no dropped IDs, got: %d", b.SinkDropped())
+	}
+}
+
+func TestSinkDropped(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	opt := Default()
+	opt.Sink(func(id *ID) {
+		<-block
+	})
+	b, e := Make(opt)
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	for i := 0; i < SinkQueueSize+10; i++ {
+		b.Next()
+	}
+	if b.SinkDropped() == 0 {
+		t.Error("want: dropped IDs, got: nothing")
+	}
+}
@@ -22,6 +22,70 @@ func Snowflake(host, node int64) (*Builder, error) {
 	return Make(opt)
 }
 
+const (
+	// InstagramEpochMS is the epoch of the Instagram IDs, 2011-08-24T21:07:01.721Z
+	InstagramEpochMS = 1_314_220_021_721
+	// InstagramShards is the number of the logical shards of the Instagram scheme
+	InstagramShards = 2000
+)
+
+// Instagram implements the Instagram scheme: a 41 bits timestamp in milliseconds,
+// a 13 bits logical shard and a 10 bits sequence. The value range of shard is
+// [0, 8191], see InstagramShard. The layout keeps 40 bits of the timestamp to fit
+// in 63 bits, since the top bit is the sign of the bigint of Postgres: the Main
+// of the IDs is the ID of Instagram until 2046, when the epoch is exhausted.
+func Instagram(shard int64) (*Builder, error) {
+	if shard < 0 || shard >= 1<<13 {
+		return nil, invalidOption("Segments", errorInvalidValue)
+	}
+	opt, _ := Predefined("instagram")
+	opt.Set(ShardKey, shard)
+	return Make(opt)
+}
+
+// InstagramShard returns the logical shard of the user or tenant key, the key
+// modulo the number of the shards as Instagram does. The shards is [1, 8192],
+// otherwise InstagramShards.
+func InstagramShard(key int64, shards int) int64 {
+	if shards < 1 || shards > 1<<13 {
+		shards = InstagramShards
+	}
+	return int64(uint64(key) % uint64(shards))
+}
+
+// DiscordEpochMS is the epoch of the Discord snowflakes, 2015-01-01T00:00:00Z
+const DiscordEpochMS = 1_420_070_400_000
+
+// Discord implements the Discord snowflakes: a 42 bits timestamp in milliseconds,
+// a 5 bits worker, a 5 bits process and a 12 bits increment. The value range of
+// worker and process is [0, 31]. The layout keeps 41 bits of the timestamp to fit
+// in 63 bits: the Main of the IDs is the Discord snowflake until 2084, when the
+// epoch is exhausted and the top bit of the snowflakes is set.
+func Discord(worker, process int64) (*Builder, error) {
+	if worker < 0 || worker > 31 || process < 0 || process > 31 {
+		return nil, invalidOption("Segments", errorInvalidValue)
+	}
+	opt, _ := Predefined("discord")
+	opt.Set("Worker", worker).Set("Process", process)
+	return Make(opt)
+}
+
+// TwitterEpochMS is the epoch of the Twitter snowflakes, 2010-11-04T01:42:54.657Z
+const TwitterEpochMS = 1_288_834_974_657
+
+// Twitter implements the Twitter snowflakes: a 41 bits timestamp in milliseconds,
+// a 10 bits machine and a 12 bits sequence, so the Main of the IDs is the Twitter
+// ID and Builder.TimeOf returns the time of the tweets. The value range of machine
+// is [0, 1023].
+func Twitter(machine int64) (*Builder, error) {
+	if machine < 0 || machine > 1023 {
+		return nil, invalidOption("Segments", errorInvalidValue)
+	}
+	opt, _ := Predefined("twitter")
+	opt.Set("Machine", machine)
+	return Make(opt)
+}
+
 // Simple implements a classic snowflake algorithm(fixed width and position).
 // The value range of server is [0, 1023].
 //
@@ -2,6 +2,7 @@ package tsid
 
 import (
 	"testing"
+	"time"
 )
 
 func TestSnowflake(t *testing.T) {
@@ -79,3 +80,91 @@ func BenchmarkSimple(b *testing.B) {
 		c()
 	}
 }
+
+func TestInstagram(t *testing.T) {
+	if _, e := Instagram(1 << 13); e == nil {
+		t.Error("want: error, got: an instance")
+	}
+	shard := InstagramShard(31341, 0)
+	if shard != 1341 {
+		t.Errorf("want: 1341, got: %d", shard)
+	}
+	if s := InstagramShard(-1, 1<<13); s != 1<<13-1 {
+		t.Errorf("want: 8191, got: %d", s)
+	}
+	b, e := Instagram(shard)
+	if e != nil {
+		t.Fatalf("want: an instance, got: error(%s)", e)
+		return
+	}
+	id := b.Next()
+	if id.Ext != 0 || (id.Main>>10)&(1<<13-1) != shard {
+		t.Errorf("want: shard %d, got: %d", shard, id.Main)
+	}
+	if ms := id.Main>>23 + InstagramEpochMS; time.Since(time.UnixMilli(ms)) > time.Second {
+		t.Errorf("want: now, got: %s", time.UnixMilli(ms))
+	}
+	buf := make([]byte, 16)
+	if n, _ := b.NextBytes(buf); n != 8 {
+		t.Errorf("want: 8 bytes, got: %d", n)
+	}
+	opt, _ := Predefined("instagram")
+	opt.Max63Bits = true
+	if _, e = Make(opt); e != nil {
+		t.Errorf("want: 63 bits, got: error %s", e)
+	}
+}
+
+func TestDiscord(t *testing.T) {
+	if _, e := Discord(32, 0); e == nil {
+		t.Error("want: error, got: an instance")
+	}
+	opt, _ := Predefined("discord")
+	d, _ := NewDecoder(opt)
+	// the snowflake of the Discord documentation
+	id := &ID{Main: 175928847299117063}
+	if ts, e := d.Time(id); e != nil || !ts.Equal(time.UnixMilli(1462015105796)) {
+		t.Errorf("want: 2016-04-30T11:18:25.796Z, got: %s, error %v", ts.UTC(), e)
+	}
+	if vs, _ := d.Decompose(id); vs[0] != 7 || vs[1] != 0 || vs[2] != 1 {
+		t.Errorf("want: [7 0 1], got: %v", vs[:3])
+	}
+	b, e := Discord(1, 2)
+	if e != nil {
+		t.Fatalf("want: an instance, got: error(%s)", e)
+		return
+	}
+	id = b.Next()
+	if id.Ext != 0 || id.Main>>12&31 != 2 || id.Main>>17&31 != 1 {
+		t.Errorf("want: worker 1, process 2, got: %d", id.Main)
+	}
+	if ts, _ := d.Time(id); time.Since(ts) > time.Second {
+		t.Errorf("want: now, got: %s", ts)
+	}
+	opt.Max63Bits = true
+	if _, e = Make(opt); e != nil {
+		t.Errorf("want: 63 bits, got: error %s", e)
+	}
+}
+
+func TestTwitter(t *testing.T) {
+	if _, e := Twitter(1024); e == nil {
+		t.Error("want: error, got: an instance")
+	}
+	b, e := Twitter(327)
+	if e != nil {
+		t.Fatalf("want: an instance, got: error(%s)", e)
+		return
+	}
+	id := &ID{Main: 1212092628029698048}
+	if ts, e := b.TimeOf(id); e != nil || !ts.Equal(time.UnixMilli(1577820376771)) {
+		t.Errorf("want: 2019-12-31T19:26:16.771Z, got: %s, error %v", ts.UTC(), e)
+	}
+	id = b.Next()
+	if id.Ext != 0 || id.Main>>12&1023 != 327 {
+		t.Errorf("want: machine 327, got: %d", id.Main)
+	}
+	if ts, _ := b.TimeOf(id); time.Since(ts) > time.Second {
+		t.Errorf("want: now, got: %s", ts)
+	}
+}
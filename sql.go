@@ -0,0 +1,41 @@
+package tsid
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// Value implements driver.Valuer, the ID is stored as an int64 if it has no
+// extension part and sign, otherwise as a string encoded by DefaultEncoder.
+func (id ID) Value() (driver.Value, error) {
+	if id.Ext == 0 && !id.Signed {
+		return id.Main, nil
+	}
+	return id.encodeString(), nil
+}
+
+// Scan implements sql.Scanner, which accepts int64 (the main part), string
+// (encoded by DefaultEncoder), and []byte by the length: the 16 bytes of the
+// Binary encoder, otherwise the string.
+func (id *ID) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*id = ID{}
+	case int64:
+		*id = ID{Main: v}
+	case string:
+		return id.decodeString(v)
+	case []byte:
+		if len(v) != 16 {
+			return id.decodeString(string(v))
+		}
+		x, err := (&Binary{}).Unmarshal(v)
+		if err != nil {
+			return err
+		}
+		*id = *x
+	default:
+		return fmt.Errorf("tsid: cannot scan %T into ID", src)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package tsid
+
+import (
+	"database/sql/driver"
+	"testing"
+)
+
+func TestIDSQL(t *testing.T) {
+	short := ID{Main: 1234567}
+	long := ID{Main: 1<<62 + 1, Ext: 99}
+	if v, e := short.Value(); e != nil || v != driver.Value(int64(1234567)) {
+		t.Errorf("want: int64, got: %v, error %v", v, e)
+	}
+	if v, e := long.Value(); e != nil || v != driver.Value(long.String()) {
+		t.Errorf("want: string, got: %v, error %v", v, e)
+	}
+	a := long.Array16()
+	signed := ID{Main: 7, Ext: 1, Signed: true}
+	tests := []struct {
+		src  interface{}
+		want ID
+	}{
+		{nil, ID{}},
+		{int64(1234567), short},
+		{long.String(), long},
+		{[]byte(long.String()), long},
+		{a[:], long},
+		{(&Binary{}).Marshal(&signed), signed},
+		// the 16 bytes are the binary form even if they are valid text
+		{[]byte("0000000000000001"), ID{Main: 0x3030303030303031, Ext: 0x3030303030303030}},
+	}
+	for _, o := range tests {
+		var id ID
+		if e := id.Scan(o.src); e != nil || !id.Equal(&o.want) {
+			t.Errorf("%v want: %s, got: %s, error %v", o.src, &o.want, &id, e)
+		}
+	}
+	var id ID
+	for _, src := range []interface{}{1.5, "!", []byte("!")} {
+		if e := id.Scan(src); e == nil {
+			t.Errorf("%v want: error, got: nothing", src)
+		}
+	}
+}
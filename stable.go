@@ -0,0 +1,70 @@
+package tsid
+
+import "github.com/StarryLab/tsid.go/tsidapi"
+
+// stableGenerator adapts the builder to tsidapi.Generator
+type stableGenerator struct {
+	b *Builder
+}
+
+func (g stableGenerator) Next() (tsidapi.ID, error) {
+	id, err := g.b.TryNext()
+	if err != nil {
+		return tsidapi.ID{}, err
+	}
+	return tsidapi.ID(*id), nil
+}
+
+// Generator returns the builder as the stable tsidapi.Generator
+func (b *Builder) Generator() tsidapi.Generator {
+	return stableGenerator{b: b}
+}
+
+// stableEncoder adapts an Encoder to tsidapi.Encoder
+type stableEncoder struct {
+	e Encoder
+}
+
+func (s stableEncoder) Encode(id tsidapi.ID) string {
+	v := ID(id)
+	return s.e.Encode(&v)
+}
+
+func (s stableEncoder) Decode(no string) (tsidapi.ID, error) {
+	id, err := s.e.Decode(no)
+	if err != nil {
+		return tsidapi.ID{}, err
+	}
+	return tsidapi.ID(*id), nil
+}
+
+// StableEncoder returns the encoder as the stable tsidapi.Encoder
+func StableEncoder(e Encoder) tsidapi.Encoder {
+	return stableEncoder{e: e}
+}
+
+// unstableEncoder adapts a tsidapi.Encoder to Encoder
+type unstableEncoder struct {
+	e tsidapi.Encoder
+}
+
+func (u unstableEncoder) Encode(id *ID) string {
+	return u.e.Encode(tsidapi.ID(*id))
+}
+
+func (u unstableEncoder) Decode(no string) (*ID, error) {
+	v, err := u.e.Decode(no)
+	if err != nil {
+		return nil, err
+	}
+	id := ID(v)
+	return &id, nil
+}
+
+// FromStableEncoder returns the tsidapi.Encoder as an Encoder, e.g. for Builder.Encoder
+func FromStableEncoder(e tsidapi.Encoder) Encoder {
+	if s, ok := e.(stableEncoder); ok {
+		return s.e
+	}
+	return unstableEncoder{e: e}
+}
@@ -0,0 +1,43 @@
+package tsid
+
+import (
+	"testing"
+	"time"
+
+	"github.com/StarryLab/tsid.go/tsidapi"
+)
+
+func TestStable(t *testing.T) {
+	b, _ := Make(Default())
+	var g tsidapi.Generator = b.Generator()
+	v, e := g.Next()
+	if e != nil || v.Main == 0 {
+		t.Fatalf("want: an ID, got: %v, error %v", v, e)
+		return
+	}
+	e64 := &Base64{}
+	se := StableEncoder(e64)
+	s := se.Encode(v)
+	id := ID(v)
+	if s != e64.Encode(&id) {
+		t.Errorf("want: %s, got: %s", e64.Encode(&id), s)
+	}
+	if got, e := se.Decode(s); e != nil || got != v {
+		t.Errorf("want: %v, got: %v, error %v", v, got, e)
+	}
+	if FromStableEncoder(se) != Encoder(e64) {
+		t.Error("want: the original encoder, got: an adapter")
+	}
+	u := FromStableEncoder(wrappedEncoder{se})
+	if got, e := u.Decode(u.Encode(&id)); e != nil || !got.Equal(&id) {
+		t.Errorf("want: %s, got: %v, error %v", &id, got, e)
+	}
+	// the stable interfaces are interchangeable with the ones of the package
+	var _ tsidapi.Clock = NewManualClock(time.Now(), 0)
+	var _ DataProvider = tsidapi.DataProvider(&flakySource{})
+}
+
+// wrappedEncoder hides the stableEncoder from FromStableEncoder
+type wrappedEncoder struct {
+	tsidapi.Encoder
+}
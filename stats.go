@@ -0,0 +1,142 @@
+package tsid
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Stats is the statistics of a builder, see Builder.Stats
+type Stats struct {
+	// Generated is the number of IDs generated by the builder
+	Generated uint64 `json:"generated"`
+	// Dropped is the number of IDs dropped by the sink queue
+	Dropped uint64 `json:"dropped"`
+	// RandMisses is the number of the random values which waited for
+	// reading the random source, see RandPool
+	RandMisses uint64 `json:"rand_misses"`
+	// Width is the total width of the layout
+	Width byte `json:"width"`
+	// SequenceCapacity is the number of IDs per tick of the timestamp
+	SequenceCapacity int64 `json:"sequence_capacity"`
+	// SequenceHighWater is the largest sequence issued in any tick since the
+	// builder is made, a value close to SequenceCapacity indicates that the
+	// sequence has been saturated
+	SequenceHighWater int64 `json:"sequence_high_water"`
+	// ClockWait is the total time waited for the clock, after the sequence
+	// rollovers and the clock moving backwards
+	ClockWait time.Duration `json:"clock_wait_ns"`
+	// Epoch is the start time of the timestamp
+	Epoch time.Time `json:"epoch"`
+	// Expires is the time when the timestamp overflows, nil if never
+	Expires *time.Time `json:"expires,omitempty"`
+	// HeadroomSeconds is the number of seconds before Expires by the clock of
+	// the builder, 0 once exhausted, -1 if never
+	HeadroomSeconds int64 `json:"headroom_seconds"`
+}
+
+// Stats returns the statistics of the builder
+func (b *Builder) Stats() Stats {
+	if !b.isReady() {
+		return Stats{HeadroomSeconds: -1}
+	}
+	b.Lock()
+	opt := b.options.clone()
+	b.Unlock()
+	s := Stats{
+		Generated:         atomic.LoadUint64(&b.generated),
+		Dropped:           atomic.LoadUint64(&b.dropped),
+		Width:             b.width,
+		SequenceCapacity:  b.sequenceMask + 1,
+		SequenceHighWater: int64(atomic.LoadUint64(&b.highWater)),
+		ClockWait:         time.Duration(atomic.LoadUint64(&b.waited)),
+		Epoch:             time.UnixMilli(epoch(opt.EpochMS)).UTC(),
+		HeadroomSeconds:   -1,
+	}
+	if b.rand != nil {
+		s.RandMisses = atomic.LoadUint64(&b.rand.misses)
+	}
+	if t, found := opt.expiry(); found {
+		t = t.UTC()
+		s.Expires = &t
+		s.HeadroomSeconds = int64(t.Sub(b.timeNow()) / time.Second)
+		if s.HeadroomSeconds < 0 {
+			s.HeadroomSeconds = 0
+		}
+	}
+	return s
+}
+
+// Lifespan returns the time remaining until the timestamp of the layout overflows
+// and the time it does, computed from the width and the unit of the timestamp
+// segment and EpochMS. The remaining is 0 once exhausted, and math.MaxInt64
+// with the zero time if the layout has no timestamp or it lasts more than 292 years.
+func (b *Builder) Lifespan() (remaining time.Duration, exhaustedAt time.Time) {
+	b.Lock()
+	t, found := b.options.expiry()
+	b.Unlock()
+	if !found {
+		return math.MaxInt64, time.Time{}
+	}
+	remaining = t.Sub(b.timeNow())
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining, t
+}
+
+// expiry returns the time when the first timestamp segment overflows,
+// false if the layout has no timestamp or it lasts more than 292 years.
+func (o *Options) expiry() (time.Time, bool) {
+	for _, segment := range o.segments {
+		if segment.Source != DateTime {
+			continue
+		}
+		u := timestampUnit([]Bits{segment})
+		if u == 0 {
+			continue
+		}
+		mask := int64(-1 ^ (-1 << segment.Width))
+		if mask > math.MaxInt64/int64(u) {
+			return time.Time{}, false
+		}
+		return time.UnixMilli(epoch(o.EpochMS)).Add(time.Duration(mask) * u), true
+	}
+	return time.Time{}, false
+}
+
+// statsEntry is the JSON shape of a builder served by StatsHandler
+type statsEntry struct {
+	Stats  Stats       `json:"stats"`
+	Layout OptionsSpec `json:"layout"`
+}
+
+// StatsHandler returns a handler which serves the statistics and the layouts
+// of the builders in JSON, mount it at "/stats" of the embedded HTTP service:
+//
+//	{"builders": {"<name>": {"stats": {...}, "layout": {...}}}}
+//
+// See examples/grafana/stats-dashboard.json for a dashboard of the endpoint.
+func StatsHandler(builders map[string]*Builder) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		doc := struct {
+			Builders map[string]statsEntry `json:"builders"`
+		}{Builders: make(map[string]statsEntry, len(builders))}
+		for name, b := range builders {
+			if !b.isReady() {
+				continue
+			}
+			opt := b.Options()
+			doc.Builders[name] = statsEntry{Stats: b.Stats(), Layout: opt.Spec()}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(doc)
+	})
+}
@@ -0,0 +1,89 @@
+package tsid
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	b, _ := Make(Default())
+	for i := 0; i < 5; i++ {
+		b.Next()
+	}
+	s := b.Stats()
+	if s.Generated != 5 || s.SequenceCapacity != 1<<12 || s.Expires == nil || s.HeadroomSeconds <= 0 {
+		t.Errorf("want: 5 generated, got: %+v", s)
+	}
+	if s := (&Builder{}).Stats(); s.Generated != 0 || s.HeadroomSeconds != -1 {
+		t.Errorf("want: empty stats, got: %+v", s)
+	}
+	exp := *s.Expires
+	b.WithClock(NewManualClock(exp.Add(-time.Hour), 0))
+	if s := b.Stats(); s.HeadroomSeconds != 3600 {
+		t.Errorf("want: 3600 by the builder clock, got: %d", s.HeadroomSeconds)
+	}
+	b.WithClock(NewManualClock(exp.Add(time.Hour), 0))
+	if s := b.Stats(); s.HeadroomSeconds != 0 {
+		t.Errorf("want: exhausted, got: %d", s.HeadroomSeconds)
+	}
+	o := Segments(Sequence(12), Timestamp(51, TimestampSeconds))
+	if _, found := o.expiry(); found {
+		t.Error("want: no expiry, got: found")
+	}
+	o = Segments(Sequence(12), Timestamp(10, TimestampSeconds))
+	o.EpochMS = 1000
+	if got, _ := o.expiry(); !got.Equal(time.Unix(1024, 0)) {
+		t.Errorf("want: %s, got: %s", time.Unix(1024, 0), got)
+	}
+
+	h := StatsHandler(map[string]*Builder{"default": b})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stats", nil))
+	var doc struct {
+		Builders map[string]struct {
+			Stats  Stats `json:"stats"`
+			Layout struct {
+				Segments []struct {
+					Source string `json:"source"`
+				} `json:"segments"`
+			} `json:"layout"`
+		} `json:"builders"`
+	}
+	if e := json.Unmarshal(w.Body.Bytes(), &doc); e != nil {
+		t.Fatalf("want: JSON, got: error %s", e)
+		return
+	}
+	got := doc.Builders["default"]
+	if got.Stats.Generated != 5 || len(got.Layout.Segments) == 0 {
+		t.Errorf("want: stats of the builder, got: %s", w.Body)
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/stats", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("want: %d, got: %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
+
+func TestLifespan(t *testing.T) {
+	opt := Default()
+	b, _ := Make(opt)
+	want, _ := opt.expiry()
+	c := NewManualClock(want.Add(-time.Hour), 0)
+	b.WithClock(c)
+	if r, at := b.Lifespan(); r != time.Hour || !at.Equal(want) {
+		t.Errorf("want: 1h until %s, got: %s until %s", want, r, at)
+	}
+	c.Add(2 * time.Hour)
+	if r, _ := b.Lifespan(); r != 0 {
+		t.Errorf("want: 0, got: %s", r)
+	}
+	opt = *Segments(Sequence(12), Timestamp(51, TimestampSeconds))
+	b, _ = Make(opt)
+	if r, at := b.Lifespan(); r != math.MaxInt64 || !at.IsZero() {
+		t.Errorf("want: never, got: %s until %s", r, at)
+	}
+}
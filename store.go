@@ -0,0 +1,268 @@
+package tsid
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LayoutStore is a backing store (file directory, etcd, ...) of the
+// custom scene definitions shared by the services.
+type LayoutStore interface {
+	// Load returns all the scenes and their options in the store
+	Load() (map[string]Options, error)
+	// Save stores the options of the scene
+	Save(scene string, opt Options) error
+}
+
+// LoadLayouts defines all the scenes in the store by Define,
+// returns the scenes which are already defined and skipped.
+func LoadLayouts(s LayoutStore) (skipped []string, err error) {
+	scenes, err := s.Load()
+	if err != nil {
+		return nil, err
+	}
+	for scene, opt := range scenes {
+		if !Define(scene, opt) {
+			skipped = append(skipped, scene)
+		}
+	}
+	return skipped, nil
+}
+
+// SaveLayout stores the predefined options of the scene into the store
+func SaveLayout(s LayoutStore, scene string) error {
+	opt, found := Predefined(scene)
+	if !found {
+		return invalidOption("Scene", errorSceneNotFound, scene)
+	}
+	return s.Save(strings.ToLower(scene), opt)
+}
+
+// DirStore is a LayoutStore of a file directory, one JSON file per scene
+type DirStore string
+
+const dirStoreExt = ".json"
+
+func (d DirStore) Load() (map[string]Options, error) {
+	files, err := filepath.Glob(filepath.Join(string(d), "*"+dirStoreExt))
+	if err != nil {
+		return nil, err
+	}
+	scenes := map[string]Options{}
+	for _, f := range files {
+		buf, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		var spec OptionsSpec
+		if err = json.Unmarshal(buf, &spec); err != nil {
+			return nil, err
+		}
+		opt, err := spec.options()
+		if err != nil {
+			return nil, err
+		}
+		scenes[strings.TrimSuffix(filepath.Base(f), dirStoreExt)] = opt
+	}
+	return scenes, nil
+}
+
+func (d DirStore) Save(scene string, opt Options) error {
+	buf, err := json.MarshalIndent(opt.Spec(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(string(d), scene+dirStoreExt), buf, 0o644)
+}
+
+// OptionsSpec is the serializable form of Options, tagged for JSON, YAML and
+// TOML, so the layouts can be decoded from any configuration format:
+//
+//	var spec tsid.OptionsSpec
+//	err := yaml.Unmarshal(data, &spec)
+//	opt, err := spec.Options()
+//
+// See OptionsFromJSON for the fields.
+type OptionsSpec struct {
+	EpochMS        int64           `json:"epoch_ms,omitempty" yaml:"epoch_ms,omitempty" toml:"epoch_ms,omitempty"`
+	ReservedDays   int64           `json:"reserved_days,omitempty" yaml:"reserved_days,omitempty" toml:"reserved_days,omitempty"`
+	Signed         bool            `json:"signed,omitempty" yaml:"signed,omitempty" toml:"signed,omitempty"`
+	Max63Bits      bool            `json:"max_63_bits,omitempty" yaml:"max_63_bits,omitempty" toml:"max_63_bits,omitempty"`
+	AutoSize       bool            `json:"auto_size,omitempty" yaml:"auto_size,omitempty" toml:"auto_size,omitempty"`
+	Duplicates     DuplicatePolicy `json:"duplicates,omitempty" yaml:"duplicates,omitempty" toml:"duplicates,omitempty"`
+	BackfillWindow time.Duration   `json:"backfill_window,omitempty" yaml:"backfill_window,omitempty" toml:"backfill_window,omitempty"`
+	// the clock and generation policies, see Options
+	OnClockBackwards ClockPolicy      `json:"on_clock_backwards,omitempty" yaml:"on_clock_backwards,omitempty" toml:"on_clock_backwards,omitempty"`
+	ClockTolerance   time.Duration    `json:"clock_tolerance,omitempty" yaml:"clock_tolerance,omitempty" toml:"clock_tolerance,omitempty"`
+	Monotonic        bool             `json:"monotonic,omitempty" yaml:"monotonic,omitempty" toml:"monotonic,omitempty"`
+	ClockCheck       bool             `json:"clock_check,omitempty" yaml:"clock_check,omitempty" toml:"clock_check,omitempty"`
+	OnExhausted      ExhaustionPolicy `json:"on_exhausted,omitempty" yaml:"on_exhausted,omitempty" toml:"on_exhausted,omitempty"`
+	OnRandFailure    RandPolicy       `json:"on_rand_failure,omitempty" yaml:"on_rand_failure,omitempty" toml:"on_rand_failure,omitempty"`
+	SequenceBlock    int              `json:"sequence_block,omitempty" yaml:"sequence_block,omitempty" toml:"sequence_block,omitempty"`
+	LowVolume        bool             `json:"low_volume,omitempty" yaml:"low_volume,omitempty" toml:"low_volume,omitempty"`
+	Settings         map[string]int64 `json:"settings,omitempty" yaml:"settings,omitempty" toml:"settings,omitempty"`
+	Segments         []SegmentSpec    `json:"segments" yaml:"segments" toml:"segments"`
+}
+
+// SegmentSpec is the serializable form of Bits, see OptionsSpec
+type SegmentSpec struct {
+	// Source is the name of the DataSourceType, case-insensitive
+	Source string `json:"source" yaml:"source" toml:"source"`
+	Width  byte   `json:"width" yaml:"width" toml:"width"`
+	// Value is the fallback (or static) value
+	Value   int64         `json:"value,omitempty" yaml:"value,omitempty" toml:"value,omitempty"`
+	Key     string        `json:"key,omitempty" yaml:"key,omitempty" toml:"key,omitempty"`
+	Index   int           `json:"index,omitempty" yaml:"index,omitempty" toml:"index,omitempty"`
+	Private bool          `json:"private,omitempty" yaml:"private,omitempty" toml:"private,omitempty"`
+	Name    string        `json:"name,omitempty" yaml:"name,omitempty" toml:"name,omitempty"`
+	Query   []interface{} `json:"query,omitempty" yaml:"query,omitempty" toml:"query,omitempty"`
+}
+
+// Spec returns the serializable form of the options, the exact bit layout, the
+// epoch, the policies and the settings, e.g. for the audits and the documentation.
+// The Shared sequence, the journal, the metrics, the environment and the sink
+// are not included.
+func (o *Options) Spec() OptionsSpec {
+	c := o.clone()
+	s := OptionsSpec{
+		EpochMS:          c.EpochMS,
+		ReservedDays:     c.ReservedDays,
+		Signed:           c.Signed,
+		Max63Bits:        c.Max63Bits,
+		AutoSize:         c.AutoSize,
+		Duplicates:       c.Duplicates,
+		BackfillWindow:   c.BackfillWindow,
+		OnClockBackwards: c.OnClockBackwards,
+		ClockTolerance:   c.ClockTolerance,
+		Monotonic:        c.Monotonic,
+		ClockCheck:       c.ClockCheck,
+		OnExhausted:      c.OnExhausted,
+		OnRandFailure:    c.OnRandFailure,
+		SequenceBlock:    c.SequenceBlock,
+		LowVolume:        c.LowVolume,
+		Settings:         c.settings,
+		Segments:         make([]SegmentSpec, len(c.segments)),
+	}
+	for i, b := range c.segments {
+		s.Segments[i] = SegmentSpec{
+			Source:  b.Source.String(),
+			Width:   b.Width,
+			Value:   b.Value,
+			Key:     b.Key,
+			Index:   b.Index,
+			Private: b.Private,
+			Name:    b.Name,
+			Query:   b.query,
+		}
+	}
+	return s
+}
+
+func (s *OptionsSpec) options() (Options, error) {
+	o := Options{
+		EpochMS:          s.EpochMS,
+		ReservedDays:     s.ReservedDays,
+		Signed:           s.Signed,
+		Max63Bits:        s.Max63Bits,
+		AutoSize:         s.AutoSize,
+		Duplicates:       s.Duplicates,
+		BackfillWindow:   s.BackfillWindow,
+		OnClockBackwards: s.OnClockBackwards,
+		ClockTolerance:   s.ClockTolerance,
+		Monotonic:        s.Monotonic,
+		ClockCheck:       s.ClockCheck,
+		OnExhausted:      s.OnExhausted,
+		OnRandFailure:    s.OnRandFailure,
+		SequenceBlock:    s.SequenceBlock,
+		LowVolume:        s.LowVolume,
+	}
+	for k, v := range s.Settings {
+		o.Set(k, v)
+	}
+	for _, b := range s.Segments {
+		t, found := parseDataSourceType(b.Source)
+		if !found {
+			return Options{}, invalidOption("Segments", errorInvalidType, b.Source)
+		}
+		o.Add(Bits{
+			Source:  t,
+			Width:   b.Width,
+			Value:   b.Value,
+			Key:     b.Key,
+			Index:   b.Index,
+			Private: b.Private,
+			Name:    b.Name,
+			query:   b.Query,
+		})
+	}
+	return o, nil
+}
+
+// OptionsFromJSON returns the options described by the JSON configuration, the
+// same form as the files of DirStore:
+//
+//	{
+//	  "epoch_ms": 1609459200000,
+//	  "signed": false,
+//	  "settings": {"Host": 1},
+//	  "segments": [
+//	    {"source": "SequenceID", "width": 12},
+//	    {"source": "OS", "width": 4, "key": "SERVER_NODE_ID", "value": 0},
+//	    {"source": "Settings", "width": 6, "key": "Host"},
+//	    {"source": "DateTime", "width": 41}
+//	  ]
+//	}
+//
+// The segments are in the order of Options.Add, from the low bits, the value
+// is the fallback (or static) value, and the index of the DateTime segments is
+// the DateTimeType. The unknown fields and the invalid widths are rejected.
+func OptionsFromJSON(data []byte) (*Options, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields()
+	var spec OptionsSpec
+	if err := d.Decode(&spec); err != nil {
+		return nil, err
+	}
+	return spec.Options()
+}
+
+// MarshalJSON encodes the options as their Spec
+func (o Options) MarshalJSON() ([]byte, error) {
+	return json.Marshal(o.Spec())
+}
+
+// UnmarshalJSON decodes the options from the JSON of their Spec, see OptionsFromJSON
+func (o *Options) UnmarshalJSON(data []byte) error {
+	v, err := OptionsFromJSON(data)
+	if err != nil {
+		return err
+	}
+	*o = *v
+	return nil
+}
+
+// Options returns the options described by the spec, and rejects the invalid widths
+func (s *OptionsSpec) Options() (*Options, error) {
+	opt, err := s.options()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = NewDecoder(opt); err != nil {
+		return nil, err
+	}
+	return &opt, nil
+}
+
+// parseDataSourceType returns the DataSourceType of the name(case-insensitive)
+func parseDataSourceType(name string) (DataSourceType, bool) {
+	for i, n := range dataSourceTypeNames {
+		if strings.EqualFold(n, name) {
+			return DataSourceType(i), true
+		}
+	}
+	return 0, false
+}
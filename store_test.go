@@ -0,0 +1,132 @@
+package tsid
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDirStore(t *testing.T) {
+	d := DirStore(t.TempDir())
+	opt := Options{
+		EpochMS: EpochMS,
+		segments: []Bits{
+			Sequence(12).Hide(),
+			Data(5, "my_data_source", 3, "hit"),
+			Timestamp(41, TimestampMilliseconds),
+		},
+	}
+	opt.Set("Node", 3)
+	Define("TestDirStore", opt)
+	if e := SaveLayout(d, "TestDirStore"); e != nil {
+		t.Fatalf("want: nothing, got: error %s", e)
+		return
+	}
+	if e := SaveLayout(d, "NotFound"); e == nil {
+		t.Error("want: error, got: nothing")
+	}
+	scenes, e := d.Load()
+	if e != nil {
+		t.Fatalf("want: scenes, got: error %s", e)
+		return
+	}
+	got, found := scenes["testdirstore"]
+	if !found {
+		t.Fatalf("want: scene testdirstore, got: %v", scenes)
+		return
+	}
+	if r, _ := CompatibleWith(opt, got); !r.Compatible {
+		t.Errorf("want: the same layout, got: %v", r.Issues)
+	}
+	if !got.segments[0].Private || got.settings["Node"] != 3 || got.segments[1].query[0] != "hit" {
+		t.Errorf("want: %+v, got: %+v", opt, got)
+	}
+	if skipped, e := LoadLayouts(d); e != nil || len(skipped) != 1 {
+		t.Errorf("want: 1 skipped scene, got: %v, error %v", skipped, e)
+	}
+}
+
+func TestOptionsFromJSON(t *testing.T) {
+	opt, e := OptionsFromJSON([]byte(`{
+		"epoch_ms": 1609459200000,
+		"signed": true,
+		"on_exhausted": 2,
+		"settings": {"Host": 1},
+		"segments": [
+			{"source": "SequenceID", "width": 12},
+			{"source": "os", "width": 4, "key": "TSID_TEST_NODE", "value": 3},
+			{"source": "Settings", "width": 6, "key": "Host"},
+			{"source": "DateTime", "width": 41}
+		]
+	}`))
+	if e != nil {
+		t.Fatalf("want: options, got: error %s", e)
+		return
+	}
+	if opt.EpochMS != 1609459200000 || !opt.Signed || opt.OnExhausted != ExhaustionError || len(opt.segments) != 4 ||
+		opt.segments[1].Source != OS || opt.segments[1].Value != 3 || opt.settings["Host"] != 1 {
+		t.Errorf("want: the options of the configuration, got: %+v", opt)
+	}
+	b, e := Make(*opt)
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	if vs, _ := b.Decoder().Decompose(b.Next()); vs[1] != 3 || vs[2] != 1 {
+		t.Errorf("want: [_ 3 1 _], got: %v", vs)
+	}
+	for _, bad := range []string{
+		`{"segments": [{"source": "SequenceID", "widht": 12}]}`,
+		`{"segments": [{"source": "Unknown", "width": 12}]}`,
+		`{"segments": [{"source": "SequenceID", "width": 0}]}`,
+		`{"segments": []}`,
+		`[`,
+	} {
+		if _, e = OptionsFromJSON([]byte(bad)); e == nil {
+			t.Errorf("want: error of %s, got: nil", bad)
+		}
+	}
+}
+
+func TestOptionsSpecTags(t *testing.T) {
+	for _, v := range []interface{}{OptionsSpec{}, SegmentSpec{}} {
+		rt := reflect.TypeOf(v)
+		for i := 0; i < rt.NumField(); i++ {
+			f := rt.Field(i)
+			j := f.Tag.Get("json")
+			if j == "" || f.Tag.Get("yaml") != j || f.Tag.Get("toml") != j {
+				t.Errorf("want: the same json, yaml and toml tags of %s.%s, got: %s", rt.Name(), f.Name, f.Tag)
+			}
+		}
+	}
+	spec := OptionsSpec{Segments: []SegmentSpec{{Source: "SequenceID", Width: 12}, {Source: "DateTime", Width: 41}}}
+	if opt, e := spec.Options(); e != nil || len(opt.segments) != 2 {
+		t.Errorf("want: options, got: %v, error %v", opt, e)
+	}
+	spec.Segments[0].Width = 0
+	if _, e := spec.Options(); e == nil {
+		t.Error("want: error of the invalid width, got: nil")
+	}
+}
+
+func TestOptionsMarshalJSON(t *testing.T) {
+	b, _ := Make(*Config(3, 5, Sequence(12), Node(4, 0), Host(6, 0), Timestamp(41, TimestampMilliseconds)))
+	opt := b.Options()
+	opt.OnClockBackwards = ClockWait
+	data, e := json.Marshal(opt)
+	if e != nil {
+		t.Fatalf("want: JSON, got: error %s", e)
+		return
+	}
+	var got Options
+	if e = json.Unmarshal(data, &got); e != nil {
+		t.Fatalf("want: options, got: error %s", e)
+		return
+	}
+	if r, _ := CompatibleWith(opt, got); !r.Compatible || got.OnClockBackwards != ClockWait || got.settings["Node"] != 5 {
+		t.Errorf("want: %s, got: %+v", data, got)
+	}
+	if s := opt.Spec(); len(s.Segments) != 4 || s.Segments[3].Source != "DateTime" || s.Settings["Host"] != 3 {
+		t.Errorf("want: the spec of the layout, got: %+v", s)
+	}
+}
@@ -0,0 +1,30 @@
+package tsid
+
+import "context"
+
+// Stream returns a channel of the IDs pre-generated by a background goroutine,
+// so the handlers pull the IDs without blocking on the sequence spin loop while
+// the buffer is not empty. The channel is closed when ctx is done or the builder
+// fails. The buffered IDs are generated ahead of use, their timestamps are the
+// times of generation rather than of receipt.
+func (b *Builder) Stream(ctx context.Context, buffer int, argv ...int64) <-chan ID {
+	if buffer < 0 {
+		buffer = 0
+	}
+	c := make(chan ID, buffer)
+	go func() {
+		defer close(c)
+		for {
+			id, err := b.TryNext(argv...)
+			if err != nil {
+				return
+			}
+			select {
+			case c <- *id:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return c
+}
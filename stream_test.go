@@ -0,0 +1,28 @@
+package tsid
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStream(t *testing.T) {
+	b, _ := Make(Default())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := b.Stream(ctx, 64)
+	prev := <-c
+	for i := 0; i < 1000; i++ {
+		id := <-c
+		if !prev.Less(&id) {
+			t.Fatalf("want: incremental IDs, got: %s after %s", &id, &prev)
+			return
+		}
+		prev = id
+	}
+	cancel()
+	for range c {
+	}
+	if _, open := <-(&Builder{}).Stream(context.Background(), 1); open {
+		t.Error("want: closed channel, got: open")
+	}
+}
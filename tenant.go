@@ -0,0 +1,116 @@
+package tsid
+
+import (
+	"errors"
+	"sync"
+)
+
+// TenantKey is the name of the tenant argument of the TenantSharded layouts
+const TenantKey = "tenant"
+
+var (
+	// ErrTenantRange indicates that the tenant exceeds the width of the tenant segment
+	ErrTenantRange = errors.New("tsid: the tenant is out of the range of the tenant segment")
+	// ErrTenantLayout indicates that the widths of TenantSharded are out of range
+	ErrTenantLayout = errors.New("tsid: invalid widths of the tenant-sharded layout")
+)
+
+// TenantShards generates the IDs of the tenant-sharded layout, see TenantSharded
+type TenantShards struct {
+	mu       sync.Mutex
+	options  Options
+	builders []*Builder
+	decoder  *Decoder
+}
+
+// TenantSharded returns the generator of the layout, from the high bits:
+//
+//	timestamp(41, ms) | tenant(tenantBits) | shard(shardBits) | sequence(seqBits)
+//
+// The tenant is the argument of Next, the shard is derived from the tenant by
+// the jump consistent hash, and every shard has its own sequence. The width of
+// the tenant is [1, 32], the shard [1, 16], the sequence [8, 22].
+func TenantSharded(tenantBits, shardBits, seqBits byte) (*TenantShards, error) {
+	if tenantBits < 1 || tenantBits > 32 || shardBits < 1 || shardBits > 16 || seqBits < 8 || seqBits > 22 {
+		return nil, ErrTenantLayout
+	}
+	opt := Options{
+		EpochMS: EpochMS,
+		segments: []Bits{
+			Sequence(seqBits),
+			Shard(shardBits),
+			NamedArg(tenantBits, TenantKey, 0),
+			Timestamp(TimestampWidth, TimestampMilliseconds),
+		},
+	}
+	d, err := NewDecoder(opt)
+	if err != nil {
+		return nil, err
+	}
+	t := &TenantShards{options: opt, builders: make([]*Builder, 1<<shardBits), decoder: d}
+	// validates the layout
+	if _, err = t.builder(0); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// builder returns the builder of the shard, which is made on the first use
+func (t *TenantShards) builder(shard int) (*Builder, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if b := t.builders[shard]; b != nil {
+		return b, nil
+	}
+	o := t.options.clone()
+	o.Set(ShardKey, int64(shard))
+	b, err := Make(o)
+	if err != nil {
+		return nil, err
+	}
+	t.builders[shard] = b
+	return b, nil
+}
+
+// ShardOf returns the shard of the tenant
+func (t *TenantShards) ShardOf(tenant int64) int {
+	return jumpHash(uint64(tenant), len(t.builders))
+}
+
+// Next returns the next ID of the tenant
+func (t *TenantShards) Next(tenant int64) (*ID, error) {
+	if tenant < 0 || tenant > t.decoder.options.segments[2].mask {
+		return nil, ErrTenantRange
+	}
+	b, err := t.builder(t.ShardOf(tenant))
+	if err != nil {
+		return nil, err
+	}
+	return b.TryNext(tenant)
+}
+
+// Tenant returns the tenant and the shard of the ID
+func (t *TenantShards) Tenant(id *ID) (tenant int64, shard int, err error) {
+	vs, err := t.decoder.Decompose(id)
+	if err != nil {
+		return 0, 0, err
+	}
+	return vs[2], int(vs[1]), nil
+}
+
+// Decoder returns the decoder of the layout
+func (t *TenantShards) Decoder() *Decoder {
+	return t.decoder
+}
+
+// jumpHash maps the key to a bucket in [0, buckets) by the jump consistent hash
+// of Lamping and Veach, which moves the minimum keys when buckets grows.
+func jumpHash(key uint64, buckets int) int {
+	var b, j int64 = -1, 0
+	for j < int64(buckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
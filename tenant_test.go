@@ -0,0 +1,47 @@
+package tsid
+
+import "testing"
+
+func TestTenantSharded(t *testing.T) {
+	if _, e := TenantSharded(0, 4, 12); e != ErrTenantLayout {
+		t.Errorf("want: %s, got: %v", ErrTenantLayout, e)
+	}
+	ts, e := TenantSharded(16, 4, 12)
+	if e != nil {
+		t.Fatalf("want: a generator, got: error %s", e)
+		return
+	}
+	seen := map[ID]bool{}
+	shards := map[int]bool{}
+	for tenant := int64(0); tenant < 200; tenant++ {
+		for i := 0; i < 5; i++ {
+			id, e := ts.Next(tenant)
+			if e != nil || seen[*id] {
+				t.Fatalf("want: unique ID, got: %v, error %v", id, e)
+				return
+			}
+			seen[*id] = true
+			got, shard, e := ts.Tenant(id)
+			if e != nil || got != tenant || shard != ts.ShardOf(tenant) {
+				t.Errorf("want: tenant %d of shard %d, got: %d of %d, error %v", tenant, ts.ShardOf(tenant), got, shard, e)
+			}
+		}
+		shards[ts.ShardOf(tenant)] = true
+	}
+	if len(shards) != 16 {
+		t.Errorf("want: 16 shards, got: %d", len(shards))
+	}
+	if _, e = ts.Next(1 << 16); e != ErrTenantRange {
+		t.Errorf("want: %s, got: %v", ErrTenantRange, e)
+	}
+	// the jump hash moves about 1/n of the keys when the buckets grow to n
+	moved := 0
+	for k := uint64(0); k < 10000; k++ {
+		if jumpHash(k, 16) != jumpHash(k, 17) {
+			moved++
+		}
+	}
+	if moved < 400 || moved > 800 {
+		t.Errorf("want: about 588 moved, got: %d", moved)
+	}
+}
@@ -0,0 +1,82 @@
+package tsid
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrTokenMalformed indicates that the token is not in the form of "id.expiry.signature"
+	ErrTokenMalformed = errors.New("tsid: the token is malformed")
+	// ErrTokenSignature indicates that the signature of the token does not match
+	ErrTokenSignature = errors.New("tsid: the token signature is invalid")
+	// ErrTokenExpired indicates that the token has expired
+	ErrTokenExpired = errors.New("tsid: the token has expired")
+)
+
+// tokenSignatureSize is the number of bytes of the truncated HMAC-SHA256
+const tokenSignatureSize = 16
+
+// SignedToken renders an ID with a tamper-proof expiration as a URL-safe token,
+// in the form of encode(id) + "." + expiry + "." + hmac.
+type SignedToken struct {
+	// Key is the secret key of HMAC-SHA256
+	Key []byte
+	// Encoder is used to encode the ID, default is Base64
+	Encoder Encoder
+}
+
+func (s *SignedToken) encoder() Encoder {
+	if s.Encoder == nil {
+		return &Base64{}
+	}
+	return s.Encoder
+}
+
+func (s *SignedToken) sign(payload string) string {
+	m := hmac.New(sha256.New, s.Key)
+	m.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(m.Sum(nil)[:tokenSignatureSize])
+}
+
+// Sign returns the token of the ID which expires at expiry
+func (s *SignedToken) Sign(id *ID, expiry time.Time) string {
+	payload := s.encoder().Encode(id) + "." + strconv.FormatInt(expiry.Unix(), 36)
+	return payload + "." + s.sign(payload)
+}
+
+// Verify checks the signature and the expiration of the token,
+// returns the ID and the expiration time.
+func (s *SignedToken) Verify(token string) (id *ID, expiry time.Time, err error) {
+	// the encoded ID may contain '.', so split from the right
+	i := strings.LastIndexByte(token, '.')
+	if i < 0 {
+		return nil, expiry, ErrTokenMalformed
+	}
+	payload, signature := token[:i], token[i+1:]
+	j := strings.LastIndexByte(payload, '.')
+	if j < 0 {
+		return nil, expiry, ErrTokenMalformed
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.sign(payload))) {
+		return nil, expiry, ErrTokenSignature
+	}
+	e, err := strconv.ParseInt(payload[j+1:], 36, 64)
+	if err != nil {
+		return nil, expiry, ErrTokenMalformed
+	}
+	expiry = time.Unix(e, 0)
+	if time.Now().After(expiry) {
+		return nil, expiry, ErrTokenExpired
+	}
+	id, err = s.encoder().Decode(payload[:j])
+	if err != nil {
+		return nil, expiry, err
+	}
+	return id, expiry, nil
+}
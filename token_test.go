@@ -0,0 +1,38 @@
+package tsid
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignedToken(t *testing.T) {
+	s := &SignedToken{Key: []byte("secret")}
+	b, e := Make(Shuffle())
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	id := b.Next()
+	expiry := time.Now().Add(time.Hour)
+	token := s.Sign(id, expiry)
+	got, exp, e := s.Verify(token)
+	if e != nil || !got.Equal(id) || exp.Unix() != expiry.Unix() {
+		t.Errorf("want: %s, %s, got: %v, %s, error %v", id, expiry, got, exp, e)
+	}
+	tests := map[string]error{
+		"":                                       ErrTokenMalformed,
+		"abc":                                    ErrTokenMalformed,
+		"abc.def":                                ErrTokenMalformed,
+		token[:len(token)-1]:                     ErrTokenSignature,
+		s.Sign(id, time.Now().Add(-time.Minute)): ErrTokenExpired,
+	}
+	for token, want := range tests {
+		if _, _, e = s.Verify(token); e != want {
+			t.Errorf("token %q want: error(%s), got: %v", token, want, e)
+		}
+	}
+	o := &SignedToken{Key: []byte("other")}
+	if _, _, e = o.Verify(token); e != ErrTokenSignature {
+		t.Errorf("want: error(%s), got: %v", ErrTokenSignature, e)
+	}
+}
@@ -0,0 +1,42 @@
+// Package tsidapi is the stable interface of tsid for the framework authors,
+// which depends on nothing but the standard library. It follows semantic
+// versioning: the declarations are only added, never changed or removed,
+// within a major version. See tsid.Builder.Generator and tsid.StableEncoder
+// for the adapters of the implementations.
+package tsidapi
+
+import "time"
+
+// Version is the semantic version of the interface
+const Version = "1.0.0"
+
+// ID is the value of an ID, which converts to and from tsid.ID directly:
+//
+//	v := tsidapi.ID(*id)
+//	id := tsid.ID(v)
+type ID struct {
+	Main,
+	Ext int64
+	Signed bool
+}
+
+// Generator generates the IDs
+type Generator interface {
+	Next() (ID, error)
+}
+
+// Encoder converts the IDs to and from strings
+type Encoder interface {
+	Encode(id ID) string
+	Decode(s string) (ID, error)
+}
+
+// DataProvider reads the values of the Provider segments
+type DataProvider interface {
+	Read(query ...interface{}) (int64, error)
+}
+
+// Clock is the source of the current time
+type Clock interface {
+	Now() time.Time
+}
@@ -0,0 +1,120 @@
+// Package tsidprom exposes the statistics of the builders to Prometheus.
+//
+//	c := tsidprom.NewCollector()
+//	c.Register("orders", b)
+//	prometheus.MustRegister(c)
+//
+// The counters are monotonic, so derive the rates by the queries, e.g.
+// rate(tsid_generated_total[1m]), instead of per scrape.
+package tsidprom
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/StarryLab/tsid.go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Collector collects the statistics of the named builders, see tsid.Builder.Stats.
+// It is a prometheus.Collector.
+type Collector struct {
+	mu       sync.Mutex
+	builders map[string]*tsid.Builder
+	once     sync.Once
+	handler  http.Handler
+}
+
+// NewCollector returns an empty Collector
+func NewCollector() *Collector {
+	return &Collector{builders: map[string]*tsid.Builder{}}
+}
+
+// Register adds the builder by the name, which replaces the builder of the same name
+func (c *Collector) Register(name string, b *tsid.Builder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.builders[name] = b
+}
+
+// Unregister removes the builder of the name
+func (c *Collector) Unregister(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.builders, name)
+}
+
+// metric is a family of the exposed metrics
+type metric struct {
+	desc  *prometheus.Desc
+	kind  prometheus.ValueType
+	value func(s *tsid.Stats) float64
+}
+
+// newMetric returns the metric of the name labeled by the builder name
+func newMetric(name, help string, kind prometheus.ValueType, value func(s *tsid.Stats) float64) metric {
+	return metric{prometheus.NewDesc(name, help, []string{"builder"}, nil), kind, value}
+}
+
+var metrics = []metric{
+	newMetric("tsid_generated_total", "The number of IDs generated.", prometheus.CounterValue,
+		func(s *tsid.Stats) float64 { return float64(s.Generated) }),
+	newMetric("tsid_dropped_total", "The number of IDs dropped by the sink queue.", prometheus.CounterValue,
+		func(s *tsid.Stats) float64 { return float64(s.Dropped) }),
+	newMetric("tsid_rand_misses_total", "The number of the random values which waited for the random source.", prometheus.CounterValue,
+		func(s *tsid.Stats) float64 { return float64(s.RandMisses) }),
+	newMetric("tsid_clock_wait_seconds_total", "The time waited for the clock.", prometheus.CounterValue,
+		func(s *tsid.Stats) float64 { return s.ClockWait.Seconds() }),
+	newMetric("tsid_sequence_high_water_max", "The largest sequence issued in a tick since the builder is made.", prometheus.GaugeValue,
+		func(s *tsid.Stats) float64 { return float64(s.SequenceHighWater) }),
+	newMetric("tsid_sequence_capacity", "The number of IDs per tick of the timestamp.", prometheus.GaugeValue,
+		func(s *tsid.Stats) float64 { return float64(s.SequenceCapacity) }),
+	newMetric("tsid_epoch_remaining_days", "The days before the timestamp overflows, -1 if never.", prometheus.GaugeValue,
+		func(s *tsid.Stats) float64 {
+			if s.HeadroomSeconds < 0 {
+				return -1
+			}
+			return float64(s.HeadroomSeconds) / 86400
+		}),
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range metrics {
+		ch <- m.desc
+	}
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	names := make([]string, 0, len(c.builders))
+	for name := range c.builders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	stats := make([]tsid.Stats, len(names))
+	for i, name := range names {
+		stats[i] = c.builders[name].Stats()
+	}
+	c.mu.Unlock()
+
+	for _, m := range metrics {
+		for i, name := range names {
+			ch <- prometheus.MustNewConstMetric(m.desc, m.kind, m.value(&stats[i]), name)
+		}
+	}
+}
+
+// ServeHTTP serves the metrics of the collector alone, mount it at "/metrics"
+// if the application has no registry
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.once.Do(func() {
+		r := prometheus.NewPedanticRegistry()
+		r.MustRegister(c)
+		c.handler = promhttp.HandlerFor(r, promhttp.HandlerOpts{})
+	})
+	c.handler.ServeHTTP(w, r)
+}
@@ -0,0 +1,57 @@
+package tsidprom
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/StarryLab/tsid.go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	b, e := tsid.Make(tsid.Default())
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	c := NewCollector()
+	c.Register(`a"b`, b)
+	for i := 0; i < 20; i++ {
+		b.Next()
+	}
+	if err := testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP tsid_generated_total The number of IDs generated.
+# TYPE tsid_generated_total counter
+tsid_generated_total{builder="a\"b"} 20
+# HELP tsid_sequence_capacity The number of IDs per tick of the timestamp.
+# TYPE tsid_sequence_capacity gauge
+tsid_sequence_capacity{builder="a\"b"} 4096
+`), "tsid_generated_total", "tsid_sequence_capacity"); err != nil {
+		t.Errorf("want: nothing, got: error %s", err)
+	}
+	r := prometheus.NewPedanticRegistry()
+	if err := r.Register(c); err != nil {
+		t.Errorf("want: nothing, got: error %s", err)
+	}
+	w := httptest.NewRecorder()
+	c.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	out := w.Body.String()
+	for _, want := range []string{
+		"# TYPE tsid_clock_wait_seconds_total counter\n",
+		`tsid_epoch_remaining_days{builder="a\"b"} `,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("want: %q, got: %s", want, out)
+		}
+	}
+	if strings.Contains(out, "per_second") {
+		t.Errorf("want: no rates, got: %s", out)
+	}
+	c.Unregister(`a"b`)
+	if n := testutil.CollectAndCount(c); n != 0 {
+		t.Errorf("want: no samples, got: %d", n)
+	}
+}
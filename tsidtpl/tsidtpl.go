@@ -0,0 +1,77 @@
+// Package tsidtpl provides the template functions minting the IDs during
+// rendering, for the code generators and the templated fixtures.
+//
+//	t := template.New("fixture").Funcs(tsidtpl.FuncMap())
+//	// {{ tsidString }}, {{ tsidString "openid" }}, {{ (tsidDecode "...").Main }}
+package tsidtpl
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/StarryLab/tsid.go"
+)
+
+var builders sync.Map
+
+// builder returns the builder of the predefined scene, "default" if omitted
+func builder(scene []string) (*tsid.Builder, error) {
+	name := "default"
+	switch len(scene) {
+	case 0:
+	case 1:
+		name = scene[0]
+	default:
+		return nil, fmt.Errorf("tsidtpl: too many arguments %q", scene)
+	}
+	if b, found := builders.Load(name); found {
+		return b.(*tsid.Builder), nil
+	}
+	opt, found := tsid.Predefined(name)
+	if !found {
+		return nil, fmt.Errorf("tsidtpl: predefined options %q not found", name)
+	}
+	b, err := tsid.Make(opt)
+	if err != nil {
+		return nil, err
+	}
+	v, _ := builders.LoadOrStore(name, b)
+	return v.(*tsid.Builder), nil
+}
+
+// FuncMap returns the template functions, which can be passed to the Funcs
+// of text/template and html/template:
+//
+//	tsid [scene]        the next ID of the predefined scene
+//	tsidString [scene]  the next ID as string, see tsid.DefaultEncoder
+//	tsidDecode s        the ID of the string
+func FuncMap() map[string]interface{} {
+	return map[string]interface{}{
+		"tsid": func(scene ...string) (*tsid.ID, error) {
+			b, err := builder(scene)
+			if err != nil {
+				return nil, err
+			}
+			return b.TryNext()
+		},
+		"tsidString": func(scene ...string) (string, error) {
+			b, err := builder(scene)
+			if err != nil {
+				return "", err
+			}
+			id, err := b.TryNext()
+			if err != nil {
+				return "", err
+			}
+			s, err := id.MarshalText()
+			return string(s), err
+		},
+		"tsidDecode": func(s string) (*tsid.ID, error) {
+			id := &tsid.ID{}
+			if err := id.UnmarshalText([]byte(s)); err != nil {
+				return nil, err
+			}
+			return id, nil
+		},
+	}
+}
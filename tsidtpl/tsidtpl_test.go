@@ -0,0 +1,37 @@
+package tsidtpl
+
+import (
+	html "html/template"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/StarryLab/tsid.go"
+)
+
+func TestFuncMap(t *testing.T) {
+	tpl, e := template.New("t").Funcs(FuncMap()).Parse(
+		`{{ $s := tsidString }}{{ $s }} {{ eq (tsidDecode $s).String $s }} {{ (tsid "openid").Ext | printf "%d" | len | lt 0 }}`)
+	if e != nil {
+		t.Fatalf("want: a template, got: error %s", e)
+		return
+	}
+	var w strings.Builder
+	if e = tpl.Execute(&w, nil); e != nil {
+		t.Fatalf("want: rendered, got: error %s", e)
+		return
+	}
+	fs := strings.Fields(w.String())
+	if len(fs) != 3 || fs[1] != "true" || fs[2] != "true" {
+		t.Errorf("want: <id> true true, got: %s", w.String())
+	}
+	if _, e = tsid.ParseID(fs[0]); e != nil {
+		t.Errorf("want: an ID, got: error %s", e)
+	}
+	if e = template.Must(template.New("t").Funcs(FuncMap()).Parse(`{{ tsid "unknown" }}`)).Execute(&w, nil); e == nil {
+		t.Error("want: error, got: nothing")
+	}
+	if _, e = html.New("t").Funcs(FuncMap()).Parse(`{{ tsidString }}`); e != nil {
+		t.Errorf("want: a html template, got: error %s", e)
+	}
+}
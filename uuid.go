@@ -0,0 +1,76 @@
+package tsid
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+)
+
+// ErrUUIDFormat indicates that the string is not in the 8-4-4-4-12 hex form
+var ErrUUIDFormat = errors.New("tsid: the UUID must be in the 8-4-4-4-12 hex form")
+
+// UUID returns the 128 bits value of the ID (see Uint128) in the canonical
+// 8-4-4-4-12 hex form, e.g. 00000000-0000-0000-0000-000000000000.
+// The sign flag is not included.
+func (id *ID) UUID() string {
+	var a [16]byte
+	v := id.Uint128()
+	binary.BigEndian.PutUint64(a[:8], v[0])
+	binary.BigEndian.PutUint64(a[8:], v[1])
+	buf := make([]byte, 36)
+	hex.Encode(buf[0:8], a[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], a[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], a[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], a[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], a[10:])
+	return string(buf)
+}
+
+// FromUUID parses the UUID made by ID.UUID, the hex digits are case-insensitive
+func FromUUID(s string) (*ID, error) {
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return nil, ErrUUIDFormat
+	}
+	var a [16]byte
+	src := []byte(s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:])
+	if _, err := hex.Decode(a[:], src); err != nil {
+		return nil, ErrUUIDFormat
+	}
+	return FromUint128([2]uint64{
+		binary.BigEndian.Uint64(a[:8]),
+		binary.BigEndian.Uint64(a[8:]),
+	})
+}
+
+// ErrUUIDVersion indicates that the version or the variant of the UUID is not
+// the one expected by the UUID encoder
+var ErrUUIDVersion = errors.New("tsid: the UUID version or variant mismatches")
+
+// UUID is the Encoder of the canonical 8-4-4-4-12 hex form, see ID.UUID.
+// The IDs of the UUIDv7 layout are RFC 9562 UUIDv7, so the services can
+// replace the uuid libraries without changing the storage.
+type UUID struct {
+	// Version is the version (1-15) required by Decode with the RFC 9562
+	// variant, zero accepts any UUID
+	Version int
+}
+
+func (e *UUID) Encode(id *ID) string {
+	return id.UUID()
+}
+
+func (e *UUID) Decode(no string) (*ID, error) {
+	id, err := FromUUID(no)
+	if err != nil || e.Version == 0 {
+		return id, err
+	}
+	v := id.Uint128()
+	if int(v[0]>>12&0xf) != e.Version || v[1]>>62 != 2 {
+		return nil, ErrUUIDVersion
+	}
+	return id, nil
+}
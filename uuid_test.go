@@ -0,0 +1,69 @@
+package tsid
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUUID(t *testing.T) {
+	id := &ID{Main: 1<<63 - 1, Ext: 1}
+	if s := id.UUID(); s != "00000000-0000-0000-ffff-ffffffffffff" {
+		t.Errorf("want: 00000000-0000-0000-ffff-ffffffffffff, got: %s", s)
+	}
+	b, _ := Make(Default())
+	for i := 0; i < 10; i++ {
+		id = b.Next()
+		got, e := FromUUID(id.UUID())
+		if e != nil || !got.Equal(id) {
+			t.Errorf("want: %s, got: %v, error %v", id, got, e)
+		}
+	}
+	if got, e := FromUUID("00000000-0000-0000-FFFF-FFFFFFFFFFFF"); e != nil || got.Main != 1<<63-1 || got.Ext != 1 {
+		t.Errorf("want: upper case hex, got: %v, error %v", got, e)
+	}
+	for _, s := range []string{
+		"",
+		"00000000-0000-0000-0000-00000000000g",
+		"00000000+0000-0000-0000-000000000000",
+		"c0000000-0000-0000-0000-000000000000",
+	} {
+		if _, e := FromUUID(s); e == nil {
+			t.Errorf("%q want: error, got: nothing", s)
+		}
+	}
+}
+
+func TestUUIDv7(t *testing.T) {
+	b, err := Make(UUIDv7())
+	if err != nil {
+		t.Fatalf("want: builder, got: error %s", err)
+		return
+	}
+	e := &UUID{Version: 7}
+	b.Encoder = e
+	prev := ""
+	for i := 0; i < 100; i++ {
+		start := time.Now().UnixMilli()
+		id := b.Next()
+		s := b.NextString()
+		if s[14] != '7' || !strings.ContainsRune("89ab", rune(s[19])) || s <= prev {
+			t.Errorf("want: ordered UUIDv7, got: %s after %s", s, prev)
+		}
+		prev = s
+		ms, _ := strconv.ParseInt(strings.Replace(id.UUID()[:13], "-", "", 1), 16, 64)
+		if ms < start || ms > time.Now().UnixMilli() {
+			t.Errorf("want: unix_ts_ms %d, got: %d", start, ms)
+		}
+		if got, err := e.Decode(s); err != nil || got.UUID() != s {
+			t.Errorf("want: %s, got: %v, error %v", s, got, err)
+		}
+	}
+	if _, err := e.Decode("017f22e2-79b0-4cc6-98c4-dc0c0c07398f"); err != ErrUUIDVersion {
+		t.Errorf("want: %s, got: %v", ErrUUIDVersion, err)
+	}
+	if opt, found := Predefined("uuid7"); !found || opt.EpochMS != EpochUnix {
+		t.Errorf("want: uuidv7, got: %v", opt)
+	}
+}
@@ -0,0 +1,144 @@
+package tsid
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrFutureTime indicates that the timestamp of the ID is later than now
+	ErrFutureTime = errors.New("tsid: the timestamp of the ID is in the future")
+	// ErrSegmentRange indicates that the value of a segment is out of its range
+	ErrSegmentRange = errors.New("tsid: the value of the segment is out of range")
+	// ErrBeforeEpoch indicates that the timestamp of the ID is earlier than the epoch
+	ErrBeforeEpoch = errors.New("tsid: the timestamp of the ID is earlier than the epoch")
+	// ErrSignMismatch indicates that the sign of the ID differs from Options.Signed
+	ErrSignMismatch = errors.New("tsid: the sign of the ID mismatches the layout")
+)
+
+// ClockSkew is the tolerance of the future timestamps checked by ValidateAll
+// and Builder.DecodeStrict
+var ClockSkew = time.Second
+
+// ValidationIssue is a problem of an ID found by ValidateAll
+type ValidationIssue struct {
+	// Index of the ID in the slice
+	Index int
+	// Segment is the index of the segment in the layout, -1 for the whole ID
+	Segment int
+	Err     error
+}
+
+func (v ValidationIssue) Error() string {
+	if v.Segment < 0 {
+		return fmt.Sprintf("tsid: #%d: %s", v.Index, v.Err)
+	}
+	return fmt.Sprintf("tsid: #%d, segment %d: %s", v.Index, v.Segment, v.Err)
+}
+
+func (v ValidationIssue) Unwrap() error {
+	return v.Err
+}
+
+// timeRanges are the value ranges of the time segments, [min, max]
+var timeRanges = map[DateTimeType][2]int64{
+	TimeNanosecond:  {0, nsPerMilliseconds*msPerSecond - 1},
+	TimeMicrosecond: {0, usPerMilliseconds*msPerSecond - 1},
+	TimeMillisecond: {0, msPerSecond - 1},
+	TimeSecond:      {0, 59},
+	TimeMinute:      {0, 59},
+	TimeHour:        {0, 23},
+	TimeDay:         {1, 31},
+	TimeMonth:       {1, 12},
+	TimeYearDay:     {1, 366},
+	TimeWeekday:     {0, 6},
+	TimeWeekNumber:  {1, 53},
+}
+
+// ValidateAll checks the IDs minted elsewhere against the layout: the width and
+// the reserved segments, the timestamp is not in the future, and the values of
+// the static, backfilled and time segments are in their ranges.
+func ValidateAll(ids []ID, opt Options) []ValidationIssue {
+	d, err := NewDecoder(opt)
+	if err != nil {
+		return []ValidationIssue{{Index: -1, Segment: -1, Err: err}}
+	}
+	var issues []ValidationIssue
+	limit := time.Now().Add(ClockSkew)
+	for i := range ids {
+		id := &ids[i]
+		if err = d.Validate(id); err != nil {
+			issues = append(issues, ValidationIssue{Index: i, Segment: -1, Err: err})
+			continue
+		}
+		t, err := d.Time(id)
+		if err == nil && t.After(limit) {
+			issues = append(issues, ValidationIssue{Index: i, Segment: -1, Err: ErrFutureTime})
+		}
+		for j, v := range decompose(d.options.segments, id.Main, id.Ext) {
+			if !inRange(&d.options.segments[j], v) {
+				issues = append(issues, ValidationIssue{Index: i, Segment: j, Err: ErrSegmentRange})
+			}
+		}
+	}
+	return issues
+}
+
+// DecodeStrict decodes the string by the Encoder of the builder (ParseID if nil),
+// and rejects the foreign or corrupted IDs: the value exceeds the width of the
+// layout or sets the reserved bits, the sign differs from Options.Signed, the
+// timestamp is out of [epoch, now + ClockSkew], or the value of a static,
+// backfilled or time segment is out of its range.
+func (b *Builder) DecodeStrict(no string) (*ID, error) {
+	if !b.isReady() {
+		return nil, ErrNotReady
+	}
+	var id *ID
+	var err error
+	if e := b.Encoder; e != nil {
+		id, err = e.Decode(no)
+	} else {
+		id, err = ParseID(no)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if id.Signed != b.options.Signed {
+		return nil, ErrSignMismatch
+	}
+	d := b.Decoder()
+	t, err := d.Time(id)
+	if err != nil {
+		return nil, err
+	}
+	if t.Before(time.UnixMilli(epoch(d.options.EpochMS))) {
+		return nil, ErrBeforeEpoch
+	}
+	if t.After(b.timeNow().Add(ClockSkew)) {
+		return nil, ErrFutureTime
+	}
+	for i, v := range decompose(d.options.segments, id.Main, id.Ext) {
+		if !inRange(&d.options.segments[i], v) {
+			return nil, ErrSegmentRange
+		}
+	}
+	return id, nil
+}
+
+func inRange(segment *Bits, v int64) bool {
+	switch segment.Source {
+	case Static:
+		return v == segment.Value&segment.mask
+	case Backfilled:
+		return v <= 1
+	case DateTime:
+		r, found := timeRanges[DateTimeType(segment.Index)]
+		// the value may be truncated by a narrow segment
+		if !found || segment.mask < r[1] {
+			return true
+		}
+		return v >= r[0] && v <= r[1]
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+package tsid
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestValidateAll(t *testing.T) {
+	opt := Segments(
+		Sequence(12),
+		Fixed(4, 5),
+		Bits{Source: DateTime, Width: 4, Index: int(TimeMonth)},
+		Timestamp(41, TimestampMilliseconds),
+	)
+	b, e := Make(*opt)
+	if e != nil {
+		t.Fatalf("want: a builder instance, got: error %s", e)
+		return
+	}
+	future := time.Now().Add(time.Hour).UnixMilli() - b.options.EpochMS
+	ids := []ID{
+		*b.Next(),
+		{Main: 1 << 62},
+		{Main: 6 << 12},
+		{Main: future<<20 | 1<<16 | 5<<12},
+	}
+	issues := ValidateAll(ids, *opt)
+	want := []struct {
+		index, segment int
+		err            error
+	}{
+		{1, -1, ErrOutOfLayout},
+		{2, 1, ErrSegmentRange},
+		{2, 2, ErrSegmentRange},
+		{3, -1, ErrFutureTime},
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("want: %d issues, got: %v", len(want), issues)
+		return
+	}
+	for i, w := range want {
+		v := issues[i]
+		if v.Index != w.index || v.Segment != w.segment || !errors.Is(v, w.err) {
+			t.Errorf("want: %v, got: %v", w, v)
+		}
+	}
+	if issues = ValidateAll(ids[:1], Options{}); len(issues) != 1 || issues[0].Index != -1 {
+		t.Errorf("want: an issue of the layout, got: %v", issues)
+	}
+}
+
+func TestDecodeStrict(t *testing.T) {
+	opt := Segments(
+		Sequence(12),
+		Fixed(4, 5),
+		Bits{Source: DateTime, Width: 4, Index: int(TimeMonth)},
+		Timestamp(41, TimestampMilliseconds),
+	)
+	b, _ := Make(*opt)
+	if _, e := (&Builder{}).DecodeStrict("1"); e != ErrNotReady {
+		t.Errorf("want: %s, got: %v", ErrNotReady, e)
+	}
+	id := b.Next()
+	if got, e := b.DecodeStrict(id.String()); e != nil || !got.Equal(id) {
+		t.Errorf("want: %v, got: %v, error %v", id, got, e)
+	}
+	b.Encoder = &Base64{}
+	if got, e := b.DecodeStrict(b.Encoder.Encode(id)); e != nil || !got.Equal(id) {
+		t.Errorf("want: %v, got: %v, error %v", id, got, e)
+	}
+	future := time.Now().Add(time.Hour).UnixMilli() - b.options.EpochMS
+	for _, c := range []struct {
+		id  ID
+		err error
+	}{
+		{ID{Main: 1 << 62}, ErrOutOfLayout},
+		{ID{Main: id.Main, Signed: true}, ErrSignMismatch},
+		{ID{Main: future<<20 | 1<<16 | 5<<12}, ErrFutureTime},
+		{ID{Main: 6 << 12}, ErrSegmentRange},
+	} {
+		if _, e := b.DecodeStrict(b.Encoder.Encode(&c.id)); !errors.Is(e, c.err) {
+			t.Errorf("%v want: %s, got: %v", c.id, c.err, e)
+		}
+	}
+	if _, e := b.DecodeStrict("?"); e == nil {
+		t.Error("want: error, got: nothing")
+	}
+}
@@ -0,0 +1,270 @@
+// Package vet statically inspects Go source code which declares TSID layouts by
+// tsid.Segments, tsid.O and tsid.Config, and reports the layouts exceeding the
+// bit budget, missing the required segments or having values overflow their widths.
+//
+// Only the widths and values written as integer literals or the width constants of
+// the tsid package are evaluated, the segments of other expressions are skipped.
+package vet
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ImportPath is the import path of the tsid package
+const ImportPath = "github.com/StarryLab/tsid.go"
+
+const (
+	maxWidth = 63
+)
+
+// Severity of the diagnostic
+type Severity int
+
+const (
+	// Warning indicates a layout which works but is probably a mistake
+	Warning Severity = iota
+	// Error indicates a layout which fails at runtime
+	Error
+)
+
+func (s Severity) String() string {
+	if s == Error {
+		return "error"
+	}
+	return "warning"
+}
+
+// Diagnostic is a problem found in a layout
+type Diagnostic struct {
+	Pos      token.Position
+	Severity Severity
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s: %s", d.Pos, d.Severity, d.Message)
+}
+
+// constants is the values of the width constants of the tsid package
+var constants = map[string]int64{
+	"HostWidth":      6,
+	"NodeWidth":      4,
+	"TimestampWidth": 41,
+	"SequenceWidth":  12,
+}
+
+// segment describes the segment constructors, value is the index
+// of the argument of the fixed value or fallback, -1 if none.
+var segments = map[string]struct {
+	value int
+}{
+	"Host":      {1},
+	"Node":      {1},
+	"Timestamp": {-1},
+	"Random":    {-1},
+	"Sequence":  {-1},
+	"Fixed":     {1},
+	"Env":       {2},
+	"Arg":       {2},
+	"Option":    {2},
+	"Data":      {2},
+	"Backfill":  {-1},
+	"Reserved":  {-1},
+}
+
+// layouts is the functions declaring layouts, first is the index of the first segment
+var layouts = map[string]int{
+	"Segments": 0,
+	"O":        0,
+	"Config":   2,
+}
+
+// absolute is the DateTimeType names of the timestamps
+var absolute = map[string]bool{
+	"TimestampMilliseconds": true,
+	"TimestampNanoseconds":  true,
+	"TimestampMicroseconds": true,
+	"TimestampSeconds":      true,
+	"TimestampMinutes":      true,
+}
+
+type checker struct {
+	fset  *token.FileSet
+	names map[string]bool // the local names of the tsid package, "." for dot-import
+	diags []Diagnostic
+}
+
+// Check inspects the parsed file
+func Check(fset *token.FileSet, f *ast.File) []Diagnostic {
+	c := &checker{fset: fset, names: map[string]bool{}}
+	for _, spec := range f.Imports {
+		if p, _ := strconv.Unquote(spec.Path.Value); p == ImportPath {
+			name := "tsid"
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			c.names[name] = true
+		}
+	}
+	delete(c.names, "_")
+	if len(c.names) == 0 {
+		return nil
+	}
+	ast.Inspect(f, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			if first, ok := layouts[c.callee(call)]; ok && len(call.Args) >= first {
+				c.layout(call, call.Args[first:])
+			}
+		}
+		return true
+	})
+	return c.diags
+}
+
+// CheckPaths inspects the Go files and the directories (not recursive)
+func CheckPaths(paths ...string) ([]Diagnostic, error) {
+	fset := token.NewFileSet()
+	var diags []Diagnostic
+	for _, path := range paths {
+		files := []string{path}
+		if fi, err := os.Stat(path); err != nil {
+			return nil, err
+		} else if fi.IsDir() {
+			if files, err = filepath.Glob(filepath.Join(path, "*.go")); err != nil {
+				return nil, err
+			}
+		}
+		for _, file := range files {
+			f, err := parser.ParseFile(fset, file, nil, 0)
+			if err != nil {
+				return nil, err
+			}
+			diags = append(diags, Check(fset, f)...)
+		}
+	}
+	return diags, nil
+}
+
+// callee returns the name of the tsid function called, or ""
+func (c *checker) callee(call *ast.CallExpr) string {
+	switch fn := call.Fun.(type) {
+	case *ast.SelectorExpr:
+		if x, ok := fn.X.(*ast.Ident); ok && c.names[x.Name] {
+			return fn.Sel.Name
+		}
+	case *ast.Ident:
+		if c.names["."] {
+			return fn.Name
+		}
+	}
+	return ""
+}
+
+// ident returns the name of the tsid identifier, or ""
+func (c *checker) ident(e ast.Expr) string {
+	switch x := e.(type) {
+	case *ast.SelectorExpr:
+		if p, ok := x.X.(*ast.Ident); ok && c.names[p.Name] {
+			return x.Sel.Name
+		}
+	case *ast.Ident:
+		if c.names["."] {
+			return x.Name
+		}
+	}
+	return ""
+}
+
+// eval returns the value of the integer literal or the width constant
+func (c *checker) eval(e ast.Expr) (int64, bool) {
+	switch x := e.(type) {
+	case *ast.BasicLit:
+		if x.Kind == token.INT {
+			v, err := strconv.ParseInt(strings.ReplaceAll(x.Value, "_", ""), 0, 64)
+			return v, err == nil
+		}
+	case *ast.ParenExpr:
+		return c.eval(x.X)
+	case *ast.UnaryExpr:
+		if v, ok := c.eval(x.X); ok && x.Op == token.SUB {
+			return -v, true
+		}
+	default:
+		v, ok := constants[c.ident(e)]
+		return v, ok
+	}
+	return 0, false
+}
+
+func (c *checker) report(n ast.Node, s Severity, format string, a ...interface{}) {
+	c.diags = append(c.diags, Diagnostic{
+		Pos:      c.fset.Position(n.Pos()),
+		Severity: s,
+		Message:  fmt.Sprintf(format, a...),
+	})
+}
+
+func (c *checker) layout(call *ast.CallExpr, args []ast.Expr) {
+	if call.Ellipsis.IsValid() {
+		// Segments(list...) is not inspectable
+		return
+	}
+	total := int64(0)
+	known := true
+	timestamp, sequence := false, false
+	for _, arg := range args {
+		s, ok := arg.(*ast.CallExpr)
+		name := ""
+		if ok {
+			name = c.callee(s)
+		}
+		d, found := segments[name]
+		if !found || len(s.Args) < 1 {
+			known = false
+			continue
+		}
+		switch name {
+		case "Sequence":
+			sequence = true
+		case "Timestamp":
+			if len(s.Args) > 1 && absolute[c.ident(s.Args[1])] {
+				timestamp = true
+			}
+		}
+		w, ok := c.eval(s.Args[0])
+		if !ok {
+			known = false
+			continue
+		}
+		if w < 1 || w > maxWidth {
+			c.report(s, Error, "the width %d of %s is out of range [1, %d]", w, name, maxWidth)
+			continue
+		}
+		total += w
+		if d.value < 0 || d.value >= len(s.Args) {
+			continue
+		}
+		if v, ok := c.eval(s.Args[d.value]); ok && (v < 0 || v > 1<<w-1) {
+			c.report(s.Args[d.value], Error, "the value %d of %s overflows its width %d", v, name, w)
+		}
+	}
+	if !timestamp || !sequence {
+		c.report(call, Error, "the layout requires a timestamp segment and a sequence segment")
+	}
+	if !known {
+		return
+	}
+	switch {
+	case total > maxWidth*2:
+		c.report(call, Error, "the layout is %d bits, which exceeds %d bits", total, maxWidth*2)
+	case total > maxWidth:
+		c.report(call, Warning, "the layout is %d bits, which exceeds %d bits and spills into the extension part", total, maxWidth)
+	}
+}
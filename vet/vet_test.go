@@ -0,0 +1,54 @@
+package vet
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+const source = `package main
+
+import (
+	"github.com/StarryLab/tsid.go"
+	. "github.com/StarryLab/tsid.go"
+)
+
+var (
+	ok       = tsid.Segments(tsid.Sequence(tsid.SequenceWidth), tsid.Timestamp(41, tsid.TimestampMilliseconds))
+	missing  = tsid.Config(1, 2, tsid.Host(6, 0), tsid.Timestamp(41, tsid.TimeSecond))
+	overflow = tsid.O(tsid.Sequence(12), tsid.Fixed(2, 10), tsid.Timestamp(41, tsid.TimestampSeconds))
+	width    = O(Sequence(0), Timestamp(41, TimestampSeconds))
+	spills   = O(Sequence(12), Random(20), Timestamp(41, TimestampSeconds))
+	exceeds  = O(Sequence(63), Random(63), Timestamp(41, TimestampSeconds))
+	unknown  = O(Sequence(12), Random(w), Timestamp(63, TimestampSeconds), Random(63))
+)
+`
+
+func TestCheck(t *testing.T) {
+	fset := token.NewFileSet()
+	f, e := parser.ParseFile(fset, "main.go", source, 0)
+	if e != nil {
+		t.Fatal(e)
+		return
+	}
+	want := map[int]string{
+		10: "requires a timestamp segment",
+		11: "overflows its width",
+		12: "out of range",
+		13: "spills into the extension part",
+		14: "exceeds 126 bits",
+	}
+	diags := Check(fset, f)
+	for _, d := range diags {
+		w, found := want[d.Pos.Line]
+		if !found || !strings.Contains(d.Message, w) {
+			t.Errorf("unexpected diagnostic: %s", d)
+			continue
+		}
+		delete(want, d.Pos.Line)
+	}
+	for line, w := range want {
+		t.Errorf("line %d want: %s, got: nothing", line, w)
+	}
+}